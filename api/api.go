@@ -0,0 +1,294 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// How often a comment is sent on idle event streams, so proxies don't close them
+const KEEPALIVE_INTERVAL = 15 * time.Second
+
+// Interfaces lists and toggles the interfaces services are discovered on (implemented by network.Discovery)
+type Interfaces interface {
+	IsInterfaceEnabled(name string) bool
+	EnableInterface(iface *network.Interface) error
+	DisableInterface(iface *network.Interface) error
+}
+
+// Server exposes the entries of a registry over HTTP
+type Server struct {
+	registry   *network.Registry
+	interfaces Interfaces
+	available  func() []*network.Interface // interfaces that can be enabled
+}
+
+// NewServer returns an API server over the registry's entries, toggling the interfaces of discovery
+func NewServer(registry *network.Registry, discovery Interfaces) *Server {
+	return &Server{
+		registry:   registry,
+		interfaces: discovery,
+		available:  network.GetInterfaces,
+	}
+}
+
+// Service is the JSON representation of an entry
+type Service struct {
+	Name      string            `json:"name"`     // full instance name, ex: 'Obelix._ssh._tcp.local.'
+	Instance  string            `json:"instance"` // ex: 'Obelix'
+	Service   string            `json:"service"`  // ex: '_ssh._tcp'
+	Domain    string            `json:"domain"`
+	Hostname  string            `json:"hostname"`
+	IPv4      string            `json:"ipv4,omitempty"`
+	IPv6      string            `json:"ipv6,omitempty"`
+	Port      int               `json:"port"`
+	TXT       map[string]string `json:"txt,omitempty"`
+	Interface string            `json:"interface,omitempty"`
+	LastSeen  time.Time         `json:"last_seen"`
+}
+
+// Host is the JSON representation of a host and its services
+type Host struct {
+	Hostname string    `json:"hostname"`
+	IPv4     []string  `json:"ipv4,omitempty"`
+	IPv6     []string  `json:"ipv6,omitempty"`
+	Services []Service `json:"services"`
+}
+
+// Interface is the JSON representation of a network interface
+type Interface struct {
+	Name    string `json:"name"`
+	IPv4    string `json:"ipv4,omitempty"`
+	Enabled bool   `json:"enabled"`
+}
+
+// Event is the JSON data of a server-sent event, whose type is the event type
+type Event struct {
+	Type    string    `json:"type"` // 'added', 'updated' or 'removed'
+	Service Service   `json:"service"`
+	Time    time.Time `json:"time"`
+}
+
+// NewService converts an entry to its JSON representation
+func NewService(entry network.Entry) Service {
+	service := Service{
+		Name:      entry.Name,
+		Instance:  entry.Instance(),
+		Service:   entry.ServiceType(),
+		Domain:    entry.Domain(),
+		Hostname:  entry.Hostname(),
+		Port:      entry.Port,
+		TXT:       entry.TXT(),
+		Interface: entry.Interface,
+		LastSeen:  entry.LastSeen,
+	}
+	if entry.AddrV4 != nil {
+		service.IPv4 = entry.AddrV4.String()
+	}
+	if entry.AddrV6 != nil {
+		service.IPv6 = entry.AddrV6.String()
+	}
+	return service
+}
+
+// Handler returns the routes of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /services", s.listServices)
+	mux.HandleFunc("GET /services/{instance}", s.getService)
+	mux.HandleFunc("GET /hosts", s.listHosts)
+	mux.HandleFunc("GET /interfaces", s.listInterfaces)
+	mux.HandleFunc("POST /interfaces/{name}/enable", s.toggleInterface(true))
+	mux.HandleFunc("POST /interfaces/{name}/disable", s.toggleInterface(false))
+	mux.HandleFunc("GET /events", s.streamEvents)
+	return mux
+}
+
+// listServices returns the services matching all the query parameters: 'service', 'instance', 'hostname',
+// 'interface', 'domain' (exact, case-insensitive), 'port' and 'q' (substring of any field or TXT value)
+func (s *Server) listServices(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	services := []Service{}
+	for _, entry := range s.registry.Entries() {
+		service := NewService(entry)
+		if matches(service, query) {
+			services = append(services, service)
+		}
+	}
+	writeJSON(w, http.StatusOK, services)
+}
+
+// getService returns the services of an instance, by instance (ex: 'Obelix') or full name (ex: 'Obelix._ssh._tcp.local.')
+func (s *Server) getService(w http.ResponseWriter, r *http.Request) {
+	instance := r.PathValue("instance")
+	services := []Service{}
+	for _, entry := range s.registry.Entries() {
+		service := NewService(entry)
+		if strings.EqualFold(service.Name, instance) || strings.EqualFold(strings.TrimSuffix(service.Name, "."), instance) ||
+			strings.EqualFold(service.Instance, instance) {
+			services = append(services, service)
+		}
+	}
+	if len(services) == 0 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no service instance '%s'", instance))
+		return
+	}
+	writeJSON(w, http.StatusOK, services)
+}
+
+// listHosts returns the hosts sorted by name, with their addresses and services
+func (s *Server) listHosts(w http.ResponseWriter, r *http.Request) {
+	byName := map[string]*Host{}
+	for _, entry := range s.registry.Entries() {
+		service := NewService(entry)
+		host, ok := byName[service.Hostname]
+		if !ok {
+			host = &Host{Hostname: service.Hostname, Services: []Service{}}
+			byName[service.Hostname] = host
+		}
+		if service.IPv4 != "" && !slices.Contains(host.IPv4, service.IPv4) {
+			host.IPv4 = append(host.IPv4, service.IPv4)
+		}
+		if service.IPv6 != "" && !slices.Contains(host.IPv6, service.IPv6) {
+			host.IPv6 = append(host.IPv6, service.IPv6)
+		}
+		host.Services = append(host.Services, service)
+	}
+
+	hosts := []Host{}
+	for _, host := range byName {
+		hosts = append(hosts, *host)
+	}
+	slices.SortFunc(hosts, func(a, b Host) int { return strings.Compare(a.Hostname, b.Hostname) })
+	writeJSON(w, http.StatusOK, hosts)
+}
+
+// listInterfaces returns the interfaces that can be used for discovery and whether they are enabled
+func (s *Server) listInterfaces(w http.ResponseWriter, r *http.Request) {
+	interfaces := []Interface{}
+	for _, iface := range s.available() {
+		interfaces = append(interfaces, newInterface(iface, s.interfaces.IsInterfaceEnabled(iface.Name)))
+	}
+	writeJSON(w, http.StatusOK, interfaces)
+}
+
+// toggleInterface enables or disables discovery on an interface
+func (s *Server) toggleInterface(enable bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		idx := slices.IndexFunc(s.available(), func(iface *network.Interface) bool { return iface.Name == name })
+		if idx < 0 {
+			writeError(w, http.StatusNotFound, fmt.Errorf("no interface '%s' available", name))
+			return
+		}
+		iface := s.available()[idx]
+
+		var err error
+		if enable {
+			err = s.interfaces.EnableInterface(iface)
+		} else {
+			err = s.interfaces.DisableInterface(iface)
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, newInterface(iface, s.interfaces.IsInterfaceEnabled(name)))
+	}
+}
+
+// streamEvents sends the changes of the services as server-sent events until the client disconnects
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+	events, unsubscribe := s.registry.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(KEEPALIVE_INTERVAL)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(Event{Type: event.Type.String(), Service: NewService(event.Entry), Time: event.Time})
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+// matches returns whether a service matches all the filter query parameters
+func matches(service Service, query map[string][]string) bool {
+	exact := map[string]string{
+		"service":   service.Service,
+		"instance":  service.Instance,
+		"hostname":  service.Hostname,
+		"interface": service.Interface,
+		"domain":    service.Domain,
+		"port":      fmt.Sprint(service.Port),
+	}
+	for param, values := range query {
+		for _, value := range values {
+			if field, ok := exact[param]; ok && !strings.EqualFold(field, value) {
+				return false
+			}
+			if param == "q" && !service.Contains(value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Contains returns whether the name, hostname, an address, the interface or a TXT record ('key=value')
+// of the service contains s, ignoring case
+func (service Service) Contains(s string) bool {
+	fields := []string{service.Name, service.Hostname, service.IPv4, service.IPv6, service.Interface}
+	for key, value := range service.TXT {
+		fields = append(fields, key+"="+value)
+	}
+	s = strings.ToLower(s)
+	return slices.ContainsFunc(fields, func(field string) bool { return strings.Contains(strings.ToLower(field), s) })
+}
+
+func newInterface(iface *network.Interface, enabled bool) Interface {
+	i := Interface{Name: iface.Name, Enabled: enabled}
+	if iface.IPv4 != nil {
+		i.IPv4 = iface.IPv4.String()
+	}
+	return i
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,244 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// fakeInterfaces is the set of interfaces enabled, by name
+type fakeInterfaces map[string]bool
+
+func (f fakeInterfaces) IsInterfaceEnabled(name string) bool { return f[name] }
+func (f fakeInterfaces) EnableInterface(iface *network.Interface) error {
+	f[iface.Name] = true
+	return nil
+}
+func (f fakeInterfaces) DisableInterface(iface *network.Interface) error {
+	f[iface.Name] = false
+	return nil
+}
+
+func entry(name, host, ipv4 string, port int, iface string, txt ...string) network.Entry {
+	return network.Entry{
+		ServiceEntry: network.ServiceEntry{Name: name, Host: host, AddrV4: net.ParseIP(ipv4), Port: port, InfoFields: txt},
+		Interface:    iface,
+		LastSeen:     time.Now(),
+	}
+}
+
+// newTestServer returns an API server over a registry with a few entries, and interfaces eth0 (enabled) and wlan0
+func newTestServer() (*Server, *network.Registry, fakeInterfaces) {
+	registry := network.NewRegistry(time.Minute)
+	registry.Update(entry("Obelix._ssh._tcp.local.", "obelix.local.", "192.168.1.145", 22, "eth0"))
+	registry.Update(entry("Obelix._http._tcp.local.", "obelix.local.", "192.168.1.145", 80, "eth0", "path=/admin"))
+	registry.Update(entry("Asterix._http._tcp.local.", "asterix.local.", "192.168.1.1", 8080, "wlan0", "version=2.1"))
+	registry.Update(entry(`Living\ Room._googlecast._tcp.local.`, "cast.local.", "192.168.1.50", 8009, "eth0"))
+
+	interfaces := fakeInterfaces{"eth0": true}
+	s := NewServer(registry, interfaces)
+	s.available = func() []*network.Interface {
+		return []*network.Interface{
+			{Interface: &net.Interface{Name: "eth0"}, IPv4: net.ParseIP("192.168.1.2")},
+			{Interface: &net.Interface{Name: "wlan0"}},
+		}
+	}
+	return s, registry, interfaces
+}
+
+// get requests the API and decodes its JSON response in v
+func get(t *testing.T, s *Server, method, url string, v any) int {
+	t.Helper()
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, httptest.NewRequest(method, url, nil))
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	return w.Code
+}
+
+func names(services []Service) []string {
+	names := []string{}
+	for _, service := range services {
+		names = append(names, service.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestListServices(t *testing.T) {
+	s, _, _ := newTestServer()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"Asterix._http._tcp.local.", `Living\ Room._googlecast._tcp.local.`, "Obelix._http._tcp.local.", "Obelix._ssh._tcp.local."}},
+		{"service=_http._tcp", []string{"Asterix._http._tcp.local.", "Obelix._http._tcp.local."}},
+		{"instance=obelix", []string{"Obelix._http._tcp.local.", "Obelix._ssh._tcp.local."}},
+		{"instance=Living%20Room", []string{`Living\ Room._googlecast._tcp.local.`}},
+		{"hostname=ASTERIX.local", []string{"Asterix._http._tcp.local."}},
+		{"interface=wlan0", []string{"Asterix._http._tcp.local."}},
+		{"domain=local&port=22", []string{"Obelix._ssh._tcp.local."}},
+		{"port=22&port=80", []string{}},
+		{"q=192.168.1.14", []string{"Obelix._http._tcp.local.", "Obelix._ssh._tcp.local."}},
+		{"q=VERSION=2", []string{"Asterix._http._tcp.local."}},
+		{"q=obelix&service=_ssh._tcp", []string{"Obelix._ssh._tcp.local."}},
+		{"domain=example.com", []string{}},
+		{"unknown=ignored", []string{"Asterix._http._tcp.local.", `Living\ Room._googlecast._tcp.local.`, "Obelix._http._tcp.local.", "Obelix._ssh._tcp.local."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var services []Service
+			if code := get(t, s, http.MethodGet, "/services?"+tt.query, &services); code != http.StatusOK {
+				t.Fatalf("status %d", code)
+			}
+			if got := names(services); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetService(t *testing.T) {
+	s, _, _ := newTestServer()
+
+	tests := []struct {
+		instance string
+		code     int
+		want     []string
+	}{
+		{"Obelix", http.StatusOK, []string{"Obelix._http._tcp.local.", "Obelix._ssh._tcp.local."}},
+		{"Obelix._ssh._tcp.local.", http.StatusOK, []string{"Obelix._ssh._tcp.local."}},
+		{"obelix._ssh._tcp.local", http.StatusOK, []string{"Obelix._ssh._tcp.local."}},
+		{"Idefix", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.instance, func(t *testing.T) {
+			var services []Service
+			if tt.code == http.StatusNotFound {
+				var body map[string]string
+				if code := get(t, s, http.MethodGet, "/services/"+tt.instance, &body); code != tt.code || body["error"] == "" {
+					t.Errorf("got %d %v, want %d with an error", code, body, tt.code)
+				}
+				return
+			}
+			if code := get(t, s, http.MethodGet, "/services/"+tt.instance, &services); code != tt.code {
+				t.Fatalf("status %d, want %d", code, tt.code)
+			}
+			if got := names(services); !slices.Equal(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListHosts(t *testing.T) {
+	s, registry, _ := newTestServer()
+	ipv6 := entry("Obelix._smb._tcp.local.", "obelix.local.", "192.168.1.145", 445, "eth0")
+	ipv6.AddrV6 = net.ParseIP("fe80::145")
+	registry.Update(ipv6)
+
+	var hosts []Host
+	if code := get(t, s, http.MethodGet, "/hosts", &hosts); code != http.StatusOK {
+		t.Fatalf("status %d", code)
+	}
+	hostnames := []string{}
+	for _, host := range hosts {
+		hostnames = append(hostnames, host.Hostname)
+	}
+	if want := []string{"asterix.local", "cast.local", "obelix.local"}; !slices.Equal(hostnames, want) {
+		t.Fatalf("hosts = %q, want %q", hostnames, want)
+	}
+	obelix := hosts[2]
+	if len(obelix.Services) != 3 || !slices.Equal(obelix.IPv4, []string{"192.168.1.145"}) || !slices.Equal(obelix.IPv6, []string{"fe80::145"}) {
+		t.Errorf("obelix.local = %+v, want 3 services and its addresses once", obelix)
+	}
+}
+
+func TestInterfaces(t *testing.T) {
+	s, _, interfaces := newTestServer()
+
+	var list []Interface
+	get(t, s, http.MethodGet, "/interfaces", &list)
+	want := []Interface{{Name: "eth0", IPv4: "192.168.1.2", Enabled: true}, {Name: "wlan0"}}
+	if !slices.Equal(list, want) {
+		t.Errorf("GET /interfaces = %+v, want %+v", list, want)
+	}
+
+	tests := []struct {
+		method  string
+		url     string
+		code    int
+		enabled map[string]bool
+	}{
+		{http.MethodPost, "/interfaces/wlan0/enable", http.StatusOK, map[string]bool{"eth0": true, "wlan0": true}},
+		{http.MethodPost, "/interfaces/eth0/disable", http.StatusOK, map[string]bool{"eth0": false, "wlan0": true}},
+		{http.MethodPost, "/interfaces/eth1/enable", http.StatusNotFound, map[string]bool{"eth0": false, "wlan0": true}},
+		{http.MethodGet, "/interfaces/eth0/enable", http.StatusMethodNotAllowed, map[string]bool{"eth0": false, "wlan0": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+			if w.Code != tt.code {
+				t.Errorf("status %d, want %d", w.Code, tt.code)
+			}
+			for name, enabled := range tt.enabled {
+				if interfaces[name] != enabled {
+					t.Errorf("%s enabled = %v, want %v", name, interfaces[name], enabled)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamEvents(t *testing.T) {
+	s, registry, _ := newTestServer()
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	registry.Update(entry("Idefix._ssh._tcp.local.", "idefix.local.", "192.168.1.7", 22, "eth0"))
+
+	timer := time.AfterFunc(5*time.Second, func() { resp.Body.Close() })
+	defer timer.Stop()
+
+	scanner := bufio.NewScanner(resp.Body)
+	var eventType string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if value, ok := strings.CutPrefix(line, "event: "); ok {
+			eventType = value
+		}
+		data, ok := strings.CutPrefix(line, "data: ")
+		if !ok {
+			continue
+		}
+		var event Event
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			t.Fatal(err)
+		}
+		if eventType != "added" || event.Type != "added" || event.Service.Name != "Idefix._ssh._tcp.local." || event.Service.Port != 22 {
+			t.Errorf("event %q = %+v, want Idefix added", eventType, event)
+		}
+		return
+	}
+	t.Fatalf("no event received: %v", scanner.Err())
+}
@@ -0,0 +1,164 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/atotto/clipboard"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+	"gitlab.com/patopest/mdns-discovery/txt"
+)
+
+// CopiedMsg is sent once some text was copied to the clipboard
+type CopiedMsg struct {
+	What string // description of what was copied, ex: 'IP'
+}
+
+// CopyActions returns the actions copying the entries' details to the clipboard, resolving MAC addresses with neighbors
+func CopyActions(entries []network.Entry, neighbors network.NeighborTable) []Action {
+	formats := []struct {
+		name   string
+		format func(network.Entry) string
+	}{
+		{"IP", func(e network.Entry) string { return ipString(e.IP()) }},
+		{"MAC", func(e network.Entry) string { return macString(network.LookupMAC(neighbors, e)) }},
+		{"host:port", func(e network.Entry) string { return net.JoinHostPort(e.Hostname(), fmt.Sprint(e.Port)) }},
+		{"Instance name", func(e network.Entry) string { return e.Name }},
+		{"URL", func(e network.Entry) string { url, _ := ServiceURL(e); return url }},
+		{"TXT record", func(e network.Entry) string { return strings.Join(e.TXTFields(), "\n") }},
+	}
+
+	actions := []Action{}
+	for _, f := range formats {
+		lines := []string{}
+		for _, entry := range entries {
+			if line := f.format(entry); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		text := strings.Join(lines, "\n")
+		actions = append(actions, Action{
+			Name:        "Copy " + f.name,
+			Description: preview(lines),
+			Cmd:         Copy(f.name, text),
+		})
+	}
+
+	if text, err := entriesJSON(entries, neighbors); err == nil {
+		actions = append(actions, Action{
+			Name:        "Copy JSON",
+			Description: fmt.Sprintf("%d %s as JSON", len(entries), plural(len(entries), "entry", "entries")),
+			Cmd:         Copy("JSON", text),
+		})
+	}
+
+	return actions
+}
+
+// Copy copies text to the system clipboard, falling back to OSC 52 escape sequences (handled by
+// the terminal) over SSH or when no clipboard utility is available
+func Copy(what, text string) tea.Cmd {
+	return func() tea.Msg {
+		copied := func() tea.Msg { return CopiedMsg{What: what} }
+
+		if !isRemoteSession() {
+			if err := clipboard.WriteAll(text); err == nil {
+				return copied()
+			}
+		}
+		return tea.BatchMsg{tea.SetClipboard(text), copied}
+	}
+}
+
+// isRemoteSession returns whether the app runs in an SSH session, where the local clipboard is not the user's
+func isRemoteSession() bool {
+	return os.Getenv("SSH_TTY") != "" || os.Getenv("SSH_CONNECTION") != ""
+}
+
+// entryJSON is the JSON representation of an entry
+type entryJSON struct {
+	Instance  string            `json:"instance"`
+	Service   string            `json:"service"`
+	Hostname  string            `json:"hostname"`
+	IPv4      string            `json:"ipv4,omitempty"`
+	IPv6      string            `json:"ipv6,omitempty"`
+	MAC       string            `json:"mac,omitempty"`
+	Port      int               `json:"port"`
+	TXT       map[string]string `json:"txt,omitempty"`
+	Decoded   map[string]string `json:"decoded,omitempty"` // decoded TXT attributes
+	Interface string            `json:"interface,omitempty"`
+	LastSeen  time.Time         `json:"last_seen"`
+}
+
+// entriesJSON returns a JSON object for a single entry, an array otherwise
+func entriesJSON(entries []network.Entry, neighbors network.NeighborTable) (string, error) {
+	objects := []entryJSON{}
+	for _, entry := range entries {
+		object := entryJSON{
+			Instance:  entry.Name,
+			Service:   entry.ServiceType(),
+			Hostname:  entry.Hostname(),
+			IPv4:      ipString(entry.AddrV4),
+			IPv6:      ipString(entry.AddrV6),
+			MAC:       macString(network.LookupMAC(neighbors, entry)),
+			Port:      entry.Port,
+			TXT:       entry.TXT(),
+			Decoded:   map[string]string{},
+			Interface: entry.Interface,
+			LastSeen:  entry.LastSeen,
+		}
+		for _, field := range txt.Decode(entry.ServiceType(), object.TXT) {
+			object.Decoded[field.Key] = field.Value
+		}
+		objects = append(objects, object)
+	}
+
+	var data []byte
+	var err error
+	if len(objects) == 1 {
+		data, err = json.MarshalIndent(objects[0], "", "  ")
+	} else {
+		data, err = json.MarshalIndent(objects, "", "  ")
+	}
+	return string(data), err
+}
+
+// ipString returns the string of an IP, empty if there is none
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
+
+// macString returns the string of a MAC address, empty if unknown
+func macString(mac net.HardwareAddr) string {
+	if mac == nil {
+		return ""
+	}
+	return mac.String()
+}
+
+// preview returns the first line copied, and how many more there are
+func preview(lines []string) string {
+	if len(lines) > 1 {
+		return fmt.Sprintf("%s (+%d more)", lines[0], len(lines)-1)
+	}
+	return lines[0]
+}
+
+func plural(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
+}
@@ -0,0 +1,41 @@
+package actions
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"gitlab.com/patopest/mdns-discovery/export"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// ExportActions returns the actions copying the entries rendered with the built-in export formats
+// and the templates of the config file (by name)
+func ExportActions(entries []network.Entry, templates map[string]string) []Action {
+	actions := []Action{}
+	add := func(name, description, nameOrPath string) {
+		tmpl, err := export.Template(nameOrPath)
+		if err != nil {
+			actions = append(actions, Action{Name: "Copy " + name, Description: err.Error()})
+			return
+		}
+		text, err := export.RenderString(tmpl, entries)
+		if err != nil {
+			actions = append(actions, Action{Name: "Copy " + name, Description: err.Error()})
+			return
+		}
+		actions = append(actions, Action{
+			Name:        "Copy " + name,
+			Description: description,
+			Cmd:         Copy(name, text),
+		})
+	}
+
+	for _, format := range export.Formats {
+		add(format.Title, format.Description, format.Name)
+	}
+	for _, name := range slices.Sorted(maps.Keys(templates)) {
+		add(name, fmt.Sprintf("template %s", templates[name]), templates[name])
+	}
+	return actions
+}
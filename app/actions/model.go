@@ -0,0 +1,159 @@
+package actions
+
+import (
+	"fmt"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+	lg "charm.land/lipgloss/v2"
+
+	"gitlab.com/patopest/mdns-discovery/app/common"
+)
+
+// Styles
+type Styles struct {
+	Base  lg.Style
+	Title lg.Style
+	Empty lg.Style
+
+	Item list.DefaultItemStyles
+}
+
+func NewStyles() (s Styles) {
+	var c = &common.DefaultStyles.Color
+
+	s.Base = lg.NewStyle().
+		Padding(0, 2)
+
+	s.Title = lg.NewStyle().
+		Foreground(c.Mid)
+
+	s.Empty = lg.NewStyle().
+		Padding(0, 0, 0, 2).
+		Foreground(c.Grey50)
+
+	s.Item.NormalTitle = lg.NewStyle().
+		Foreground(c.Text).
+		Padding(0, 0, 0, 2)
+
+	s.Item.NormalDesc = s.Item.NormalTitle.
+		Foreground(lg.Darken(c.Text, 0.5))
+
+	s.Item.SelectedTitle = lg.NewStyle().
+		Border(lg.NormalBorder(), false, false, false, true).
+		BorderForeground(lg.Darken(c.MidLow, 0.30)).
+		Foreground(c.MidLow).
+		Padding(0, 0, 0, 1)
+
+	s.Item.SelectedDesc = s.Item.SelectedTitle.
+		Foreground(lg.Darken(c.MidLow, 0.30))
+
+	s.Item.DimmedTitle = s.Item.NormalTitle
+	s.Item.DimmedDesc = s.Item.NormalDesc
+	s.Item.FilterMatch = lg.NewStyle()
+
+	return s
+}
+
+// Action is an entry of the menu running a command when selected
+type Action struct {
+	Name        string
+	Description string
+	Cmd         tea.Cmd
+}
+
+// Item represents an action in the list
+type Item struct {
+	action Action
+}
+
+// Implements list.Item interface
+func (i Item) FilterValue() string { return i.action.Name }
+func (i Item) Title() string       { return i.action.Name }
+func (i Item) Description() string { return i.action.Description }
+
+// Model is a menu listing the actions available for the selected (or marked) rows
+type Model struct {
+	list list.Model
+
+	Keys   keyMap
+	Styles Styles
+}
+
+// New creates a new action menu
+func New() *Model {
+	styles := NewStyles()
+
+	delegate := list.NewDefaultDelegate()
+	delegate.Styles = styles.Item
+
+	l := list.New([]list.Item{}, delegate, 0, 0)
+	keys := newKeyMap()
+	l.KeyMap = keys.KeyMap
+	l.Styles.Title = styles.Title
+	l.SetShowHelp(false)
+	l.SetShowTitle(true)
+	l.SetShowFilter(false)
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+
+	return &Model{
+		list:   l,
+		Keys:   keys,
+		Styles: styles,
+	}
+}
+
+// CloseMsg is sent when an action was run and the menu should be closed
+type CloseMsg struct{}
+
+// SetActions updates the menu title and the actions listed
+func (m *Model) SetActions(title string, actions []Action) {
+	items := []list.Item{}
+	for _, action := range actions {
+		items = append(items, Item{action: action})
+	}
+	m.list.Title = title
+	m.list.SetItems(items)
+	m.list.Select(0)
+}
+
+func (m *Model) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, m.Keys.Select):
+			if item, ok := m.list.SelectedItem().(Item); ok {
+				cmds = append(cmds, item.action.Cmd, func() tea.Msg { return CloseMsg{} })
+			}
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return tea.Batch(cmds...)
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.list.SetSize(width, height)
+}
+
+func (m *Model) View() string {
+	var s = &m.Styles
+
+	if len(m.list.Items()) == 0 {
+		return lg.JoinVertical(
+			lg.Left,
+			s.Base.Render(s.Title.Render(m.list.Title)),
+			"",
+			s.Empty.Render(fmt.Sprintf("no actions available, press '%s' to close", m.Keys.Close.Help().Key)),
+		)
+	}
+	return m.list.View()
+}
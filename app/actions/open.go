@@ -0,0 +1,199 @@
+package actions
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode"
+
+	tea "charm.land/bubbletea/v2"
+
+	"gitlab.com/patopest/mdns-discovery/config"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// Built-in actions opening services by type, extended (or overridden by name) by the config file
+var DefaultOpenActions = []config.Action{
+	{Service: "_http._tcp", Name: "Open in browser", Command: "{{.Opener}} {{.URL}}"},
+	{Service: "_https._tcp", Name: "Open in browser", Command: "{{.Opener}} {{.URL}}"},
+	{Service: "_ssh._tcp", Name: "SSH", Command: "ssh -p {{.Port}} -- {{.Host}}", Terminal: true},
+	{Service: "_sftp-ssh._tcp", Name: "SFTP", Command: "sftp -P {{.Port}} -- {{.Host}}", Terminal: true},
+	{Service: "_smb._tcp", Name: "Open share", Command: "{{.Opener}} smb://{{.Host}}"},
+	{Service: "_afpovertcp._tcp", Name: "Open share", Command: "{{.Opener}} afp://{{.Host}}"},
+	{Service: "_rfb._tcp", Name: "Open VNC viewer", Command: "{{.Opener}} {{.URL}}"},
+	{Service: "_ipp._tcp", Name: "Open print queue", Command: "{{.Opener}} http://{{.Host}}:{{.Port}}/{{.TXT.rp}}"},
+	{Service: "_ipps._tcp", Name: "Open print queue", Command: "{{.Opener}} https://{{.Host}}:{{.Port}}/{{.TXT.rp}}"},
+}
+
+// OpenedMsg is sent once an action has run (or failed to start)
+type OpenedMsg struct {
+	Name string
+	Err  error
+}
+
+// commandData is the data available to the templates of an action's command
+type commandData struct {
+	Instance string
+	Service  string
+	Host     string
+	IP       string
+	Port     int
+	URL      string
+	TXT      map[string]string
+	Opener   string // command opening URLs with the default application
+}
+
+// OpenActions returns the actions available for the entries' service types, running on all entries
+// of that type at once
+func OpenActions(entries []network.Entry, custom []config.Action) []Action {
+	registry := openRegistry(custom)
+
+	actions := []Action{}
+	for _, definition := range registry {
+		commands := [][]string{}
+		for _, entry := range entries {
+			if entry.ServiceType() != definition.Service {
+				continue
+			}
+			args, err := expandCommand(definition.Command, entry)
+			if err != nil || len(args) == 0 {
+				continue
+			}
+			commands = append(commands, args)
+		}
+		if len(commands) == 0 {
+			continue
+		}
+
+		lines := []string{}
+		for _, args := range commands {
+			lines = append(lines, strings.Join(args, " "))
+		}
+		actions = append(actions, Action{
+			Name:        definition.Name,
+			Description: preview(lines),
+			Cmd:         runCommands(definition, commands),
+		})
+	}
+	return actions
+}
+
+// openRegistry returns the built-in actions with the custom ones added, replacing the built-in
+// actions with the same service type and name
+func openRegistry(custom []config.Action) []config.Action {
+	registry := slices.Clone(DefaultOpenActions)
+	for _, action := range custom {
+		idx := slices.IndexFunc(registry, func(a config.Action) bool {
+			return a.Service == action.Service && a.Name == action.Name
+		})
+		if idx >= 0 {
+			registry[idx] = action
+		} else {
+			registry = append(registry, action)
+		}
+	}
+	return registry
+}
+
+// expandCommand splits a command in arguments and executes each as a template with the entry's data.
+// Arguments are never passed to a shell, and the ones expanded from values advertised on the network are rejected if
+// they start with '-' so that they can't pass for options either (ex: a host named '-oProxyCommand=...').
+func expandCommand(command string, entry network.Entry) ([]string, error) {
+	if !validHost(entry.Hostname()) {
+		return nil, fmt.Errorf("invalid host name %q", entry.Hostname())
+	}
+
+	data := commandData{
+		Instance: entry.Name,
+		Service:  entry.ServiceType(),
+		Host:     entry.Hostname(),
+		IP:       ipString(entry.IP()),
+		Port:     entry.Port,
+		TXT:      entry.TXT(),
+		Opener:   opener(),
+	}
+	data.URL, _ = ServiceURL(entry)
+
+	args := []string{}
+	for _, field := range strings.Fields(command) {
+		tmpl, err := template.New("arg").Option("missingkey=zero").Parse(field)
+		if err != nil {
+			return nil, err
+		}
+		var arg strings.Builder
+		if err := tmpl.Execute(&arg, data); err != nil {
+			return nil, err
+		}
+		// the opener may be made of several arguments
+		if strings.Contains(field, ".Opener") {
+			args = append(args, strings.Fields(arg.String())...)
+			continue
+		}
+		if strings.HasPrefix(arg.String(), "-") && !strings.HasPrefix(field, "-") {
+			return nil, fmt.Errorf("argument %q of %q would be an option", arg.String(), field)
+		}
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// validHost returns whether a host name advertised on the network is an IP address or a DNS name
+func validHost(host string) bool {
+	if net.ParseIP(host) != nil {
+		return true
+	}
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// runCommands runs the commands of an action, one after the other in the terminal or all at once in the background
+func runCommands(action config.Action, commands [][]string) tea.Cmd {
+	if action.Terminal {
+		cmds := []tea.Cmd{}
+		for _, args := range commands {
+			cmds = append(cmds, tea.ExecProcess(exec.Command(args[0], args[1:]...), func(err error) tea.Msg {
+				return OpenedMsg{Name: action.Name, Err: err}
+			}))
+		}
+		return tea.Sequence(cmds...)
+	}
+
+	return func() tea.Msg {
+		for _, args := range commands {
+			cmd := exec.Command(args[0], args[1:]...)
+			if err := cmd.Start(); err != nil {
+				return OpenedMsg{Name: action.Name, Err: err}
+			}
+			go cmd.Wait() // reap the process
+		}
+		return OpenedMsg{Name: action.Name}
+	}
+}
+
+// opener returns the command opening files and URLs with the default application of the OS
+func opener() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "open"
+	case "windows":
+		return "rundll32 url.dll,FileProtocolHandler"
+	default:
+		return "xdg-open"
+	}
+}
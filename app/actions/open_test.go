@@ -0,0 +1,53 @@
+package actions
+
+import (
+	"slices"
+	"testing"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+func TestExpandCommand(t *testing.T) {
+	entry := func(host string, txt ...string) network.Entry {
+		return network.Entry{ServiceEntry: network.ServiceEntry{
+			Name:       "Server._ssh._tcp.local.",
+			Host:       host + ".",
+			Port:       22,
+			InfoFields: txt,
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		entry   network.Entry
+		want    []string // nil if refused
+	}{
+		{"ssh", "ssh -p {{.Port}} -- {{.Host}}", entry("server.local"), []string{"ssh", "-p", "22", "--", "server.local"}},
+		{"option as host", "ssh {{.Host}}", entry("-oProxyCommand=touch /tmp/pwned"), nil},
+		{"option label", "ssh {{.Host}}", entry("-oProxyCommand=id.local"), nil},
+		{"space in host", "ssh {{.Host}}", entry("server local"), nil},
+		{"IPv6 host", "ping {{.Host}}", entry("fe80::1"), []string{"ping", "fe80::1"}},
+		{"option in TXT", "curl {{.TXT.path}}", entry("server.local", "path=-o/etc/passwd"), nil},
+		{"TXT inside argument", "curl http://{{.Host}}/{{.TXT.path}}", entry("server.local", "path=-x"), []string{"curl", "http://server.local/-x"}},
+		{"literal option", "ssh -v {{.Host}}", entry("server.local"), []string{"ssh", "-v", "server.local"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := expandCommand(tt.command, tt.entry)
+			if tt.want == nil {
+				if err == nil {
+					t.Errorf("expandCommand() = %q, want an error", args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expandCommand() error: %v", err)
+			}
+			if !slices.Equal(args, tt.want) {
+				t.Errorf("expandCommand() = %q, want %q", args, tt.want)
+			}
+		})
+	}
+}
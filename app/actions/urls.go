@@ -0,0 +1,53 @@
+package actions
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// URL schemes of the service types that can be reached with a URL
+var urlSchemes = map[string]string{
+	"_http._tcp":       "http",
+	"_https._tcp":      "https",
+	"_ssh._tcp":        "ssh",
+	"_sftp-ssh._tcp":   "sftp",
+	"_ftp._tcp":        "ftp",
+	"_smb._tcp":        "smb",
+	"_afpovertcp._tcp": "afp",
+	"_nfs._tcp":        "nfs",
+	"_rfb._tcp":        "vnc",
+	"_ipp._tcp":        "ipp",
+	"_ipps._tcp":       "ipps",
+}
+
+// TXT record keys holding the resource path of a service type
+var urlPathKeys = map[string]string{
+	"_http._tcp":  "path",
+	"_https._tcp": "path",
+	"_ipp._tcp":   "rp",
+	"_ipps._tcp":  "rp",
+}
+
+// ServiceURL returns a URL to reach the service of an entry, if its service type has a known scheme
+func ServiceURL(entry network.Entry) (string, bool) {
+	scheme, ok := urlSchemes[entry.ServiceType()]
+	if !ok {
+		return "", false
+	}
+
+	host := entry.Hostname()
+	if host == "" && entry.IP() != nil {
+		host = entry.IP().String()
+	}
+	url := fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, fmt.Sprint(entry.Port)))
+
+	if key, ok := urlPathKeys[entry.ServiceType()]; ok {
+		if path, ok := entry.TXTValue(key); ok && path != "" {
+			url += "/" + strings.TrimPrefix(path, "/")
+		}
+	}
+	return url, true
+}
@@ -1,8 +1,13 @@
 package app
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"reflect"
+	"slices"
 	"strings"
+	"time"
 
 	"charm.land/bubbles/v2/help"
 	"charm.land/bubbles/v2/key"
@@ -10,27 +15,79 @@ import (
 	tea "charm.land/bubbletea/v2"
 	lg "charm.land/lipgloss/v2"
 
+	"gitlab.com/patopest/mdns-discovery/app/actions"
+	"gitlab.com/patopest/mdns-discovery/app/columns"
 	"gitlab.com/patopest/mdns-discovery/app/common"
+	"gitlab.com/patopest/mdns-discovery/app/history"
 	"gitlab.com/patopest/mdns-discovery/app/settings"
 	"gitlab.com/patopest/mdns-discovery/app/table"
+	"gitlab.com/patopest/mdns-discovery/app/views"
+	"gitlab.com/patopest/mdns-discovery/catalog"
+	"gitlab.com/patopest/mdns-discovery/config"
+	db "gitlab.com/patopest/mdns-discovery/history"
+	"gitlab.com/patopest/mdns-discovery/hooks"
 	"gitlab.com/patopest/mdns-discovery/network"
+	"gitlab.com/patopest/mdns-discovery/probe"
+	"gitlab.com/patopest/mdns-discovery/snapshot"
 )
 
 const APP_TITLE string = "mDNS Discovery"
 
+// How long toast notifications are shown in the footer
+const TOAST_DURATION = 2 * time.Second
+
+// Maximum number of web services fetched at a time
+const HTTP_PROBE_CONCURRENCY = 4
+
+// How often the neighbor table resolving MAC addresses is reloaded
+const NEIGHBORS_REFRESH_INTERVAL = 5 * time.Second
+
+// pane is the component currently displayed in the main view
+type pane int
+
+const (
+	paneTable pane = iota
+	paneSettings
+	paneViews
+	paneColumns
+	paneActions
+	paneHistory
+)
+
 type App struct {
 	// data
-	discovery    *network.Discovery
-	data         []network.ServiceEntry
-	entriesCh    chan network.ServiceEntry
-	showSettings bool
+	discovery *network.Discovery
+	data      []network.Entry
+	entriesCh chan network.Entry
+	config    *config.Config
+	pane      pane
 
 	// table component
 	table    table.Model
 	settings *settings.Model
+	views    *views.Model
+	columns  *columns.Model
+	actions  *actions.Model
+	history  *history.Model // nil unless recording the history
 	spinner  spinner.Model
 	help     help.Model
 
+	// toast notification shown in the footer
+	toast   string
+	toastID int
+
+	// reachability probing of the selected rows
+	prober      *probe.Prober
+	probeCancel context.CancelFunc // cancels the probes in progress, nil if none
+	probeRun    int
+	httpProber  *probe.HTTPProber // fetches the metadata of web services, nil if disabled
+	httpSlots   chan struct{}
+
+	// tracks the services appearing, changing and disappearing for the hooks, nil if none
+	registry *network.Registry
+	// records every service seen, nil if disabled
+	store *db.Store
+
 	// dimensions
 	totalWidth  int
 	totalHeight int
@@ -39,7 +96,8 @@ type App struct {
 	styles common.Styles
 }
 
-func NewApp(ifaces []string, domains []string) *App {
+// NewApp creates the app and starts the discovery. Call SetTheme before, to apply the theme and key bindings of the config.
+func NewApp(ifaces []string, domains []string, opts network.Options, cfg *config.Config) *App {
 	table := table.New()
 
 	help := help.New()
@@ -52,44 +110,161 @@ func NewApp(ifaces []string, domains []string) *App {
 	spin.Style = common.DefaultStyles.Header.Spinner
 
 	// Create the entries channel
-	entriesCh := make(chan network.ServiceEntry, 30)
-	discovery := network.InitDiscovery(ifaces, domains, entriesCh)
+	entriesCh := make(chan network.Entry, 30)
+	discovery := network.InitDiscovery(ifaces, domains, opts, entriesCh)
 	settings := settings.New(discovery)
+	views := views.New()
+	columns := columns.New()
+	actions := actions.New()
+
+	table.SetCatalog(catalog.New(cfg.Catalog))
+	table.ApplyLayout(cfg.Current().Columns)
 
 	app := &App{
-		discovery:    discovery,
-		entriesCh:    entriesCh,
-		showSettings: false,
-		table:        table,
-		settings:     settings,
-		spinner:      spin,
-		help:         help,
-		keys:         common.DefaultKeyMap,
-		styles:       common.DefaultStyles,
+		discovery: discovery,
+		entriesCh: entriesCh,
+		config:    cfg,
+		pane:      paneTable,
+		table:     table,
+		settings:  settings,
+		views:     views,
+		columns:   columns,
+		actions:   actions,
+		prober:    probe.New(),
+		spinner:   spin,
+		help:      help,
+		keys:      common.DefaultKeyMap,
+		styles:    common.DefaultStyles,
 	}
 
 	return app
 }
 
-type EntryMsg network.ServiceEntry
+// SetTheme sets the common styles and key bindings from the theme, colors and keys of the settings
+func SetTheme(s config.Settings) error {
+	if err := common.SetTheme(s.Theme, s.Colors); err != nil {
+		return err
+	}
+	return common.SetKeys(s.Keys)
+}
+
+// EntriesMsg is sent with the entries discovered (or refreshed) since the last one
+type EntriesMsg []network.Entry
+
+// ConfigChangedMsg is sent when the config file changed. Reload reads it again from the update loop, as it sets the
+// defaults of the flags, which must not race with the rest of the app reading them.
+type ConfigChangedMsg struct {
+	Reload func() ConfigMsg
+}
+
+// ConfigMsg is sent when the config file was reloaded, with the discovery settings resulting from it and the flags
+type ConfigMsg struct {
+	Config     *config.Config
+	Interfaces []string // all available interfaces if empty
+	Domains    []string
+	Options    network.Options
+	Err        error // failed to reload, nothing else is set
+}
+
+// clearToastMsg is sent when a toast notification expires
+type clearToastMsg struct {
+	id int
+}
+
+// neighborsRefreshedMsg is sent when the neighbor table has been reloaded
+type neighborsRefreshedMsg struct{}
+
+// refreshNeighbors reloads the neighbor table after a delay, outside of the update loop
+func (m *App) refreshNeighbors(delay time.Duration) tea.Cmd {
+	neighbors := m.table.NeighborTable()
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		neighbors.Refresh() // not available on all platforms, MAC addresses are left empty
+		return neighborsRefreshedMsg{}
+	})
+}
+
+// probeResultMsg is sent for each result of a probing run
+type probeResultMsg struct {
+	run    int
+	result probe.TargetResult
+}
+
+// probeDoneMsg is sent when a probing run is finished or cancelled
+type probeDoneMsg struct {
+	run int
+}
 
+// httpInfoMsg is sent with the metadata of a web service
+type httpInfoMsg struct {
+	id   string
+	info probe.HTTPInfo
+}
+
+// listenForEntries waits for an entry, and takes the ones received along with it to update the table once
 func (m *App) listenForEntries() tea.Cmd {
 	return func() tea.Msg {
-		entry := <-m.entriesCh
-		return EntryMsg(entry)
+		entries := EntriesMsg{<-m.entriesCh}
+		for len(entries) < cap(m.entriesCh) {
+			select {
+			case entry := <-m.entriesCh:
+				entries = append(entries, entry)
+			default:
+				return entries
+			}
+		}
+		return entries
 	}
 }
 
-func (m *App) InjectFakeData(entries []network.ServiceEntry) {
-	for _, entry := range entries {
-		m.data = append(m.data, entry)
-		m.table.SetRows(m.data)
+// ApplyView applies the saved view with the given name to the table
+func (m *App) ApplyView(name string) error {
+	view, ok := m.config.GetView(name)
+	if !ok {
+		return fmt.Errorf("unknown view '%s'", name)
 	}
+	m.table.ApplyView(view)
+	return nil
+}
+
+// SetNeighborTable sets the table used to resolve the MAC addresses of the hosts
+func (m *App) SetNeighborTable(neighbors network.NeighborTable) {
+	m.table.SetNeighborTable(neighbors)
+}
+
+// SetBaseline compares the services to a snapshot, coloring the rows added, changed or removed since
+func (m *App) SetBaseline(baseline snapshot.Snapshot) {
+	m.table.SetBaseline(baseline)
+}
+
+// EnableHTTPProbe fetches the page of web services when they are discovered or probed
+func (m *App) EnableHTTPProbe() {
+	m.httpProber = probe.NewHTTPProber()
+	m.httpSlots = make(chan struct{}, HTTP_PROBE_CONCURRENCY)
+}
+
+// EnableHooks fires the hooks of the runner as services appear, change and disappear
+func (m *App) EnableHooks(runner *hooks.Runner) {
+	m.registry = network.NewRegistry(m.discovery.Options.EntryTTL())
+	events, _ := m.registry.Subscribe()
+	go runner.Run(context.Background(), events)
+	go m.registry.Run(context.Background(), nil) // only expires entries, they are added on EntriesMsg
+}
+
+// EnableHistory records the services seen in the store, and shows them in the history pane
+func (m *App) EnableHistory(store *db.Store) {
+	m.store = store
+	m.history = history.New(store)
+	go store.AutoSave(context.Background(), func(err error) { log.Printf("failed to save history: %v", err) })
+}
+
+func (m *App) InjectFakeData(entries []network.ServiceEntry) {
+	m.data = append(m.data, network.FakeEntries(entries)...)
+	m.table.SetRows(m.data)
 }
 
 // Implement tea.Model interface
 func (m *App) Init() tea.Cmd {
-	return tea.Batch(m.listenForEntries(), m.spinner.Tick)
+	return tea.Batch(m.listenForEntries(), m.spinner.Tick, m.refreshNeighbors(0))
 }
 
 // Implement tea.Model interface
@@ -98,20 +273,31 @@ func (m *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
-	case EntryMsg:
-		entry := network.ServiceEntry(msg)
-		found := false
-		for _, existing := range m.data {
-			if reflect.DeepEqual(existing, entry) {
-				found = true
-				break
+	case EntriesMsg:
+		for _, entry := range msg {
+			if entry.Generation < m.discovery.Generation() { // found before a flush, still buffered in the channels
+				continue
+			}
+			if m.registry != nil {
+				m.registry.Update(entry)
+			}
+			if entry.Gone {
+				m.data, _ = network.RemoveEntry(m.data, entry)
+				continue
+			}
+			var added bool
+			m.data, added = network.MergeEntry(m.data, entry)
+			if added {
+				cmds = append(cmds, m.fetchHTTPInfo(entry))
+			}
+			if m.store != nil {
+				m.store.Record(entry)
 			}
 		}
-		if !found {
-			m.data = append(m.data, entry)
+		if !m.discovery.IsPaused() { // the table is frozen while paused
 			m.table.SetRows(m.data)
 		}
-		// Listen for the next entry
+		// Listen for the next entries
 		cmds = append(cmds, m.listenForEntries())
 
 	case tea.WindowSizeMsg:
@@ -125,24 +311,135 @@ func (m *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmd = m.table.Update(msg)
 			return m, cmd
 		}
+		if m.pane == paneViews && m.views.IsInputFocused() {
+			cmd = m.views.Update(msg)
+			return m, cmd
+		}
+		if m.pane == paneHistory && m.history.IsInputFocused() {
+			cmd = m.history.Update(msg)
+			return m, cmd
+		}
 
 		// Handle top-level keys
 		switch {
 		case key.Matches(msg, m.keys.Settings):
-			m.showSettings = !m.showSettings
-			if m.showSettings {
+			m.togglePane(paneSettings)
+			if m.pane == paneSettings {
 				m.settings.Refresh()
 			}
+		case key.Matches(msg, m.keys.Views):
+			m.togglePane(paneViews)
+			if m.pane == paneViews {
+				m.views.SetViews(m.config.Views)
+			}
+		case key.Matches(msg, m.keys.History) && m.history != nil:
+			m.togglePane(paneHistory)
+			if m.pane == paneHistory {
+				m.history.Refresh()
+			}
+			return m, tea.Batch(cmds...)
+		case key.Matches(msg, m.keys.Columns):
+			m.togglePane(paneColumns)
+			if m.pane == paneColumns {
+				m.columns.SetColumns(m.table.AvailableColumns(), m.table.GetVisibleColumns())
+			}
+		case key.Matches(msg, m.keys.Copy) && m.pane == paneTable:
+			if entries := m.table.TargetEntries(); len(entries) > 0 {
+				m.actions.SetActions("Copy", actions.CopyActions(entries, m.table.NeighborTable()))
+				m.pane = paneActions
+				// don't forward to the table
+				return m, tea.Batch(cmds...)
+			}
+		case key.Matches(msg, m.keys.Open) && m.pane == paneTable:
+			if entries := m.table.TargetEntries(); len(entries) > 0 {
+				m.actions.SetActions("Open", actions.OpenActions(entries, m.config.Actions))
+				m.pane = paneActions
+				return m, tea.Batch(cmds...)
+			}
+		case key.Matches(msg, m.keys.Export) && m.pane == paneTable:
+			if entries := m.table.TargetEntries(); len(entries) > 0 {
+				m.actions.SetActions("Export", actions.ExportActions(entries, m.config.Templates))
+				m.pane = paneActions
+				return m, tea.Batch(cmds...)
+			}
+		case key.Matches(msg, m.keys.Probe) && m.pane == paneTable:
+			if entries := m.table.TargetEntries(); len(entries) > 0 {
+				cmds = append(cmds, m.startProbing(entries))
+				return m, tea.Batch(cmds...)
+			}
+		case key.Matches(msg, m.keys.Unprobe) && m.probeCancel != nil:
+			m.probeCancel()
+			cmds = append(cmds, m.showToast("probing stopped"))
+		case key.Matches(msg, m.keys.Refresh) && m.pane == paneTable:
+			if m.discovery.IsPaused() {
+				cmds = append(cmds, m.showToast("paused, resume to refresh"))
+			} else if m.discovery.Options.Passive {
+				cmds = append(cmds, m.showToast("passive, not querying"))
+			} else {
+				m.discovery.Refresh()
+				cmds = append(cmds, m.showToast("refreshing"))
+			}
+			return m, tea.Batch(cmds...)
+		case key.Matches(msg, m.keys.Pause) && m.pane == paneTable:
+			if m.discovery.IsPaused() {
+				m.discovery.Resume()
+				m.table.SetRows(m.data)
+			} else {
+				m.discovery.Pause()
+			}
+			return m, tea.Batch(cmds...)
+		case key.Matches(msg, m.keys.Flush) && m.pane == paneTable:
+			m.discovery.Flush()
+			m.data = []network.Entry{}
+			m.table.SetRows(m.data)
+			cmds = append(cmds, m.showToast("cleared"))
+			return m, tea.Batch(cmds...)
 		case key.Matches(msg, m.keys.Help):
 			m.help.ShowAll = !m.help.ShowAll
 		case key.Matches(msg, m.keys.Quit):
 			cmds = append(cmds, tea.Quit)
 		}
 		// Component specific keys
-		if m.showSettings {
+		switch m.pane {
+		case paneSettings:
 			switch {
 			case key.Matches(msg, m.settings.Keys.Close):
-				m.showSettings = false
+				m.pane = paneTable
+			}
+		case paneViews:
+			switch {
+			case key.Matches(msg, m.views.Keys.Close):
+				m.pane = paneTable
+				// don't forward to the table, esc would clear the filter
+				return m, tea.Batch(cmds...)
+			}
+		case paneColumns:
+			switch {
+			case key.Matches(msg, m.columns.Keys.Close):
+				m.pane = paneTable
+				return m, tea.Batch(cmds...)
+			}
+		case paneActions:
+			switch {
+			case key.Matches(msg, m.actions.Keys.Close):
+				m.pane = paneTable
+				return m, tea.Batch(cmds...)
+			}
+		}
+
+	case ConfigChangedMsg:
+		return m.Update(msg.Reload())
+
+	case ConfigMsg:
+		switch {
+		case msg.Err != nil:
+			cmds = append(cmds, m.showToast(fmt.Sprintf("config not reloaded: %v", msg.Err)))
+		case reflect.DeepEqual(msg.Config, m.config): // saved by the app itself
+		default:
+			if err := m.applyConfig(msg); err != nil {
+				cmds = append(cmds, m.showToast(fmt.Sprintf("config partly reloaded: %v", err)))
+			} else {
+				cmds = append(cmds, m.showToast("config reloaded"))
 			}
 		}
 
@@ -152,19 +449,106 @@ func (m *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.discovery.DisableInterface(msg.Iface)
 		}
+
+	case views.ApplyViewMsg:
+		m.table.ApplyView(msg.View)
+		m.pane = paneTable
+
+	case views.SaveViewMsg:
+		m.config.SetView(m.table.CurrentView(msg.Name))
+		m.saveConfig()
+		m.views.SetViews(m.config.Views)
+
+	case views.DeleteViewMsg:
+		m.config.DeleteView(msg.Name)
+		m.saveConfig()
+		m.views.SetViews(m.config.Views)
+
+	case columns.ToggleColumnMsg:
+		m.table.ToggleColumn(msg.Key)
+		m.saveLayout()
+
+	case columns.MoveColumnMsg:
+		m.table.MoveColumn(msg.Key, msg.Delta)
+		m.saveLayout()
+
+	case columns.ResizeColumnMsg:
+		for _, column := range m.table.AvailableColumns() {
+			if column.Key() == msg.Key {
+				m.table.SetColumnWidth(msg.Key, table.ColumnWidth(column)+msg.Delta)
+				break
+			}
+		}
+		m.saveLayout()
+
+	case actions.CloseMsg:
+		m.pane = paneTable
+
+	case history.CloseMsg:
+		m.pane = paneTable
+
+	case actions.CopiedMsg:
+		cmds = append(cmds, m.showToast("copied "+msg.What+" to clipboard"))
+
+	case actions.OpenedMsg:
+		if msg.Err != nil {
+			cmds = append(cmds, m.showToast(fmt.Sprintf("%s failed: %v", msg.Name, msg.Err)))
+		}
+
+	case clearToastMsg:
+		if msg.id == m.toastID {
+			m.toast = ""
+		}
+
+	case neighborsRefreshedMsg:
+		if !m.discovery.IsPaused() {
+			m.table.SetRows(m.data)
+		}
+		cmds = append(cmds, m.refreshNeighbors(NEIGHBORS_REFRESH_INTERVAL))
+
+	case probeResultMsg:
+		if msg.run == m.probeRun {
+			m.table.SetProbeResult(msg.result.ID, msg.result.Result)
+		}
+
+	case httpInfoMsg:
+		m.table.SetHTTPInfo(msg.id, msg.info)
+
+	case probeDoneMsg:
+		if msg.run == m.probeRun {
+			m.probeCancel = nil
+			m.table.ResetProbing()
+		}
+
+	case columns.WrapColumnMsg:
+		for _, column := range m.table.AvailableColumns() {
+			if column.Key() == msg.Key {
+				m.table.SetColumnWrap(msg.Key, !column.IsWrapped())
+				break
+			}
+		}
+		m.saveLayout()
 	}
 
 	// Update components
 	m.spinner, cmd = m.spinner.Update(msg)
 	cmds = append(cmds, cmd)
 
-	if m.showSettings {
+	switch m.pane {
+	case paneSettings:
 		cmd = m.settings.Update(msg)
-		cmds = append(cmds, cmd)
-	} else {
+	case paneViews:
+		cmd = m.views.Update(msg)
+	case paneColumns:
+		cmd = m.columns.Update(msg)
+	case paneActions:
+		cmd = m.actions.Update(msg)
+	case paneHistory:
+		cmd = m.history.Update(msg)
+	default:
 		cmd = m.table.Update(msg)
-		cmds = append(cmds, cmd)
 	}
+	cmds = append(cmds, cmd)
 
 	return m, tea.Batch(cmds...)
 }
@@ -182,11 +566,28 @@ func (m *App) View() tea.View {
 	innerHeight := m.totalHeight - headerHeight - footerHeight
 
 	var mainView string
-	if m.showSettings {
+	switch m.pane {
+	case paneSettings:
 		m.settings.SetSize(m.totalWidth, innerHeight)
 		mainView = s.Settings.Base.Render(m.settings.View())
 		mainView = lg.Place(m.totalWidth, innerHeight, lg.Center, lg.Center, mainView)
-	} else {
+	case paneViews:
+		m.views.SetSize(m.totalWidth, innerHeight)
+		mainView = s.Settings.Base.Render(m.views.View())
+		mainView = lg.Place(m.totalWidth, innerHeight, lg.Center, lg.Center, mainView)
+	case paneColumns:
+		m.columns.SetSize(m.totalWidth, innerHeight)
+		mainView = s.Settings.Base.Render(m.columns.View())
+		mainView = lg.Place(m.totalWidth, innerHeight, lg.Center, lg.Center, mainView)
+	case paneActions:
+		m.actions.SetSize(m.totalWidth, innerHeight)
+		mainView = s.Settings.Base.Render(m.actions.View())
+		mainView = lg.Place(m.totalWidth, innerHeight, lg.Center, lg.Center, mainView)
+	case paneHistory:
+		m.history.SetSize(m.totalWidth, innerHeight)
+		mainView = s.Settings.Base.Render(m.history.View())
+		mainView = lg.Place(m.totalWidth, innerHeight, lg.Center, lg.Center, mainView)
+	default:
 		m.table.SetSize(m.totalWidth, innerHeight)
 		mainView = m.table.View()
 	}
@@ -207,11 +608,164 @@ func (m *App) View() tea.View {
 	return view
 }
 
+// togglePane shows the given pane, or goes back to the table if it is already shown
+func (m *App) togglePane(p pane) {
+	if m.pane == p {
+		m.pane = paneTable
+	} else {
+		m.pane = p
+	}
+}
+
+// showToast shows a notification in the footer for TOAST_DURATION
+func (m *App) showToast(toast string) tea.Cmd {
+	m.toast = toast
+	m.toastID++
+	id := m.toastID
+	return tea.Tick(TOAST_DURATION, func(time.Time) tea.Msg {
+		return clearToastMsg{id: id}
+	})
+}
+
+// startProbing checks the reachability of the entries in the background, cancelling the previous run
+func (m *App) startProbing(entries []network.Entry) tea.Cmd {
+	if m.probeCancel != nil {
+		m.probeCancel()
+	}
+	m.table.ResetProbing()
+
+	targets := []probe.Target{}
+	httpCmds := []tea.Cmd{}
+	for _, entry := range entries {
+		if target, ok := probe.TargetOf(entry); ok {
+			targets = append(targets, target)
+			m.table.SetProbeResult(target.ID, probe.Result{State: probe.STATE_PROBING})
+		}
+		httpCmds = append(httpCmds, m.fetchHTTPInfo(entry))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.probeCancel = cancel
+	m.probeRun++
+	run := m.probeRun
+	results := make(chan probe.TargetResult)
+	go m.prober.ProbeAll(ctx, targets, results)
+
+	return tea.Batch(listenForProbes(run, results), tea.Batch(httpCmds...))
+}
+
+// fetchHTTPInfo fetches the metadata of a web service in the background, if enabled
+func (m *App) fetchHTTPInfo(entry network.Entry) tea.Cmd {
+	target, ok := probe.HTTPTargetOf(entry)
+	if m.httpProber == nil || !ok {
+		return nil
+	}
+	prober, slots := m.httpProber, m.httpSlots
+	return func() tea.Msg {
+		slots <- struct{}{}
+		defer func() { <-slots }()
+		return httpInfoMsg{id: target.ID, info: prober.Fetch(context.Background(), target)}
+	}
+}
+
+// listenForProbes waits for the next result of a probing run
+func listenForProbes(run int, results <-chan probe.TargetResult) tea.Cmd {
+	return func() tea.Msg {
+		result, ok := <-results
+		if !ok {
+			return probeDoneMsg{run: run}
+		}
+		return tea.BatchMsg{
+			func() tea.Msg { return probeResultMsg{run: run, result: result} },
+			listenForProbes(run, results),
+		}
+	}
+}
+
+// saveLayout refreshes the column manager and saves the columns layout to the config
+func (m *App) saveLayout() {
+	m.columns.SetColumns(m.table.AvailableColumns(), m.table.GetVisibleColumns())
+	m.config.SetColumns(m.table.Layout())
+	m.saveConfig()
+}
+
+// applyConfig applies a reloaded config: discovery settings, columns, catalog, theme and key bindings.
+// Hooks are only read at startup.
+func (m *App) applyConfig(msg ConfigMsg) error {
+	previous := m.config.Current()
+	current := msg.Config.Current()
+	m.config = msg.Config
+
+	m.applyInterfaces(msg.Interfaces)
+	if !slices.Equal(msg.Domains, m.discovery.Domains) || !reflect.DeepEqual(msg.Options, m.discovery.Options) {
+		m.discovery.Reconfigure(msg.Domains, msg.Options)
+	}
+
+	m.table.SetCatalog(catalog.New(m.config.Catalog))
+	if !reflect.DeepEqual(current.Columns, previous.Columns) {
+		m.table.ApplyLayout(current.Columns)
+	}
+
+	if current.Theme == previous.Theme && reflect.DeepEqual(current.Colors, previous.Colors) && reflect.DeepEqual(current.Keys, previous.Keys) {
+		return nil
+	}
+	if err := SetTheme(current); err != nil {
+		return err
+	}
+	m.applyTheme()
+	return nil
+}
+
+// applyInterfaces enables the given interfaces (all available ones if empty) and disables the others
+func (m *App) applyInterfaces(names []string) {
+	itfs := network.GetInterfaces()
+	if len(names) > 0 {
+		itfs = network.GetInterfacesByName(names)
+	}
+
+	for _, enabled := range slices.Clone(m.discovery.Interfaces) {
+		if !slices.ContainsFunc(itfs, func(itf *network.Interface) bool { return itf.Name == enabled.Name }) {
+			m.discovery.DisableInterface(enabled)
+		}
+	}
+	for _, itf := range itfs {
+		m.discovery.EnableInterface(itf)
+	}
+}
+
+// applyTheme recreates the panes with the common styles and key bindings, after SetTheme changed them
+func (m *App) applyTheme() {
+	m.keys = common.DefaultKeyMap
+	m.styles = common.DefaultStyles
+	m.help.Styles.ShortKey = m.styles.Footer.Help
+	m.help.Styles.FullKey = m.styles.Footer.Help
+	m.spinner.Style = m.styles.Header.Spinner
+
+	m.table.ApplyTheme()
+	m.settings = settings.New(m.discovery)
+	m.views = views.New()
+	m.columns = columns.New()
+	m.actions = actions.New()
+	if m.history != nil {
+		m.history = history.New(m.store)
+	}
+	m.pane = paneTable
+}
+
+func (m *App) saveConfig() {
+	if err := m.config.Save(); err != nil {
+		log.Println("failed to save config:", err)
+	}
+}
+
 func (m *App) viewHeader() string {
 	var s = &m.styles
 
 	title := s.Header.Title.Render(APP_TITLE)
 	spinner := m.spinner.View()
+	if m.discovery.IsPaused() {
+		spinner = s.Header.Paused.Render("⏸ paused")
+	}
 
 	itfs := strings.Builder{}
 	itfs.WriteString("interfaces ")
@@ -240,6 +794,9 @@ func (m *App) viewFooter() string {
 	var s = &m.styles
 
 	footer := m.help.View(m)
+	if m.toast != "" {
+		footer = s.Footer.Toast.Render(m.toast)
+	}
 
 	return s.Footer.Base.Render(footer)
 }
@@ -247,11 +804,27 @@ func (m *App) viewFooter() string {
 // Implements help.KeyMap interface
 func (m App) ShortHelp() []key.Binding {
 	keys := []key.Binding{m.keys.Help}
-	if m.showSettings {
+	switch m.pane {
+	case paneSettings:
 		keys = append(keys, m.settings.ShortHelp()...)
-	} else {
+	case paneViews:
+		keys = append(keys, m.views.ShortHelp()...)
+	case paneColumns:
+		keys = append(keys, m.columns.ShortHelp()...)
+	case paneActions:
+		keys = append(keys, m.actions.ShortHelp()...)
+	case paneHistory:
+		keys = append(keys, m.history.ShortHelp()...)
+	default:
 		keys = append(keys, m.table.ShortHelp()...)
-		keys = append(keys, m.keys.Settings)
+		keys = append(keys, m.keys.Copy, m.keys.Open, m.keys.Export, m.keys.Probe)
+		if m.probeCancel != nil {
+			keys = append(keys, m.keys.Unprobe)
+		}
+		keys = append(keys, m.keys.Refresh, m.keys.Pause, m.keys.Settings, m.keys.Views, m.keys.Columns)
+		if m.history != nil {
+			keys = append(keys, m.keys.History)
+		}
 	}
 	keys = append(keys, m.keys.Quit)
 	return keys
@@ -260,11 +833,23 @@ func (m App) ShortHelp() []key.Binding {
 // Implements help.KeyMap interface
 func (m App) FullHelp() [][]key.Binding {
 	var keys [][]key.Binding
-	if m.showSettings {
+	switch m.pane {
+	case paneSettings:
 		keys = append(keys, m.settings.FullHelp()...)
-	} else {
+	case paneViews:
+		keys = append(keys, m.views.FullHelp()...)
+	case paneColumns:
+		keys = append(keys, m.columns.FullHelp()...)
+	case paneActions:
+		keys = append(keys, m.actions.FullHelp()...)
+	case paneHistory:
+		keys = append(keys, m.history.FullHelp()...)
+	default:
 		keys = append(keys, m.table.FullHelp()...)
-		keys = append(keys, []key.Binding{m.keys.Settings})
+		keys = append(keys, []key.Binding{m.keys.Copy, m.keys.Open, m.keys.Export}, []key.Binding{m.keys.Probe, m.keys.Unprobe}, []key.Binding{m.keys.Refresh, m.keys.Pause, m.keys.Flush}, []key.Binding{m.keys.Settings, m.keys.Views, m.keys.Columns})
+		if m.history != nil {
+			keys = append(keys, []key.Binding{m.keys.History})
+		}
 	}
 	keys = append(keys, []key.Binding{m.keys.Help, m.keys.Quit})
 	return keys
@@ -0,0 +1,275 @@
+package columns
+
+import (
+	"fmt"
+	"io"
+	"slices"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+	lg "charm.land/lipgloss/v2"
+
+	"gitlab.com/patopest/mdns-discovery/app/common"
+	"gitlab.com/patopest/mdns-discovery/app/table/table"
+)
+
+// Styles
+type Styles struct {
+	Base lg.Style
+
+	Title lg.Style
+
+	NormalTitle lg.Style
+	NormalDesc  lg.Style
+
+	SelectedTitle lg.Style
+	SelectedDesc  lg.Style
+
+	NormalItem   lg.Style
+	SelectedItem lg.Style
+}
+
+func NewStyles() (s Styles) {
+	var c = &common.DefaultStyles.Color
+
+	s.Base = lg.NewStyle().
+		Padding(0, 2)
+
+	s.Title = lg.NewStyle().
+		Foreground(c.Mid)
+
+	s.NormalTitle = lg.NewStyle().
+		Foreground(c.Text).
+		Padding(0, 0, 0, 2)
+
+	s.NormalDesc = s.NormalTitle.
+		Foreground(lg.Darken(c.Text, 0.5))
+
+	s.SelectedTitle = s.NormalTitle.
+		Foreground(c.MidLow)
+
+	s.SelectedDesc = s.NormalDesc.
+		Foreground(lg.Darken(c.MidLow, 0.30))
+
+	s.NormalItem = lg.NewStyle().
+		Padding(0, 0, 0, 2)
+
+	s.SelectedItem = s.NormalItem.
+		Border(lg.NormalBorder(), false, false, false, true).
+		BorderForeground(lg.Darken(c.MidLow, 0.30)).
+		Padding(0, 0, 0, 1)
+
+	return s
+}
+
+// Item represents a column in the list
+type Item struct {
+	column  table.Column
+	visible bool
+}
+
+// Implements list.Item interface
+func (i Item) FilterValue() string { return i.column.Key() }
+func (i Item) Title() string       { return i.column.Title() }
+func (i Item) Description() string {
+	desc := fmt.Sprintf("%s | width %d", i.column.Key(), i.column.Width())
+	if i.column.IsFlex() {
+		desc = fmt.Sprintf("%s | flex %d", i.column.Key(), i.column.FlexFactor())
+	}
+	if i.column.IsWrapped() {
+		desc += " | wrap"
+	}
+	return desc
+}
+
+// Delegate customizes how column items are rendered
+type Delegate struct {
+	Styles Styles
+}
+
+func NewDelegate() Delegate {
+	return Delegate{
+		Styles: NewStyles(),
+	}
+}
+
+// Implements list.ItemDelegate interface
+func (d Delegate) Height() int                               { return 2 }
+func (d Delegate) Spacing() int                              { return 1 }
+func (d Delegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
+func (d Delegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+
+	i, ok := item.(Item)
+	if !ok {
+		return
+	}
+	var s = &d.Styles
+
+	var checkbox string
+	if i.visible {
+		checkbox = "[✓]"
+	} else {
+		checkbox = "[ ]"
+	}
+
+	title := i.Title()
+	desc := i.Description()
+
+	if index == m.Index() {
+		checkbox = s.SelectedTitle.UnsetPadding().Render(checkbox)
+		title = s.SelectedTitle.Render(title)
+		desc = s.SelectedDesc.Render(desc)
+	} else {
+		if i.visible {
+			checkbox = s.NormalTitle.UnsetPadding().Render(checkbox)
+		} else {
+			checkbox = s.NormalDesc.UnsetPadding().Render(checkbox)
+		}
+		title = s.NormalTitle.Render(title)
+		desc = s.NormalDesc.Render(desc)
+	}
+
+	view := lg.JoinHorizontal(
+		lg.Left,
+		checkbox,
+		title+"\n"+desc,
+	)
+
+	if index == m.Index() {
+		view = s.SelectedItem.Render(view)
+	} else {
+		view = s.NormalItem.Render(view)
+	}
+
+	fmt.Fprintf(w, "%s", s.Base.Render(view))
+}
+
+// Model is the column manager listing visible columns (in display order) followed by hidden ones
+type Model struct {
+	list list.Model
+
+	Keys   keyMap
+	Styles Styles
+}
+
+// New creates a new column manager
+func New() *Model {
+	styles := NewStyles()
+
+	delegate := NewDelegate()
+	delegate.Styles = styles
+
+	l := list.New([]list.Item{}, delegate, 0, 0)
+	l.Title = "Columns"
+	keys := newKeyMap()
+	l.KeyMap = keys.KeyMap
+	l.Styles.Title = styles.Title
+	l.SetShowHelp(false)
+	l.SetShowTitle(true)
+	l.SetShowFilter(false)
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+
+	return &Model{
+		list:   l,
+		Keys:   keys,
+		Styles: styles,
+	}
+}
+
+// ToggleColumnMsg is sent when a column is shown or hidden
+type ToggleColumnMsg struct {
+	Key string
+}
+
+// MoveColumnMsg is sent when a visible column is moved
+type MoveColumnMsg struct {
+	Key   string
+	Delta int
+}
+
+// ResizeColumnMsg is sent when a column is made wider or narrower
+type ResizeColumnMsg struct {
+	Key   string
+	Delta int
+}
+
+// WrapColumnMsg is sent when the wrapping of a column's cells is toggled
+type WrapColumnMsg struct {
+	Key string
+}
+
+// SetColumns updates the listed columns, keeping the cursor on the same column
+func (m *Model) SetColumns(all []table.Column, visible []string) {
+	var selected string
+	if item, ok := m.list.SelectedItem().(Item); ok {
+		selected = item.column.Key()
+	}
+
+	items := []list.Item{}
+	for _, key := range visible {
+		idx := slices.IndexFunc(all, func(c table.Column) bool { return c.Key() == key })
+		if idx >= 0 {
+			items = append(items, Item{column: all[idx], visible: true})
+		}
+	}
+	for _, column := range all {
+		if !slices.Contains(visible, column.Key()) {
+			items = append(items, Item{column: column, visible: false})
+		}
+	}
+	m.list.SetItems(items)
+
+	for i, item := range items {
+		if item.(Item).column.Key() == selected {
+			m.list.Select(i)
+			break
+		}
+	}
+}
+
+func (m *Model) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		item, ok := m.list.SelectedItem().(Item)
+		if !ok {
+			break
+		}
+		var event tea.Msg
+		switch {
+		case key.Matches(msg, m.Keys.Select):
+			event = ToggleColumnMsg{Key: item.column.Key()}
+		case key.Matches(msg, m.Keys.MoveUp):
+			event = MoveColumnMsg{Key: item.column.Key(), Delta: -1}
+		case key.Matches(msg, m.Keys.MoveDown):
+			event = MoveColumnMsg{Key: item.column.Key(), Delta: 1}
+		case key.Matches(msg, m.Keys.Wider):
+			event = ResizeColumnMsg{Key: item.column.Key(), Delta: 1}
+		case key.Matches(msg, m.Keys.Narrower):
+			event = ResizeColumnMsg{Key: item.column.Key(), Delta: -1}
+		case key.Matches(msg, m.Keys.Wrap):
+			event = WrapColumnMsg{Key: item.column.Key()}
+		}
+		if event != nil {
+			cmds = append(cmds, func() tea.Msg { return event })
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return tea.Batch(cmds...)
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.list.SetSize(width, height)
+}
+
+func (m *Model) View() string {
+	return m.list.View()
+}
@@ -1,6 +1,10 @@
 package common
 
 import (
+	"fmt"
+	"reflect"
+	"strings"
+
 	"charm.land/bubbles/v2/key"
 )
 
@@ -20,6 +24,22 @@ type KeyMap struct {
 	SortIp       key.Binding
 	SortPort     key.Binding
 
+	// secondary sorting (table)
+	SortAdd         key.Binding // fake key only for description purposes (in help)
+	SortAddName     key.Binding
+	SortAddService  key.Binding
+	SortAddDomain   key.Binding
+	SortAddHostname key.Binding
+	SortAddIp       key.Binding
+	SortAddPort     key.Binding
+
+	// marking (table)
+	Mark       key.Binding
+	MarkRange  key.Binding
+	MarkInvert key.Binding
+	Hide       key.Binding
+	Unhide     key.Binding
+
 	// fitlering (table)
 	Filter      key.Binding
 	FilterBlur  key.Binding
@@ -27,14 +47,38 @@ type KeyMap struct {
 
 	// modes / settings / panes
 	Settings key.Binding
+	Views    key.Binding
+	Columns  key.Binding
+	Copy     key.Binding
+	Open     key.Binding
+	Export   key.Binding
+	Probe    key.Binding
+	Unprobe  key.Binding
+	History  key.Binding
+	Refresh  key.Binding
+	Pause    key.Binding
+	Flush    key.Binding
 	Select   key.Binding
+	Details  key.Binding
 	Close    key.Binding
 
+	// views (picker)
+	SaveView   key.Binding
+	DeleteView key.Binding
+
+	// columns (manager)
+	MoveUp   key.Binding
+	MoveDown key.Binding
+	Wider    key.Binding
+	Narrower key.Binding
+	Wrap     key.Binding
+
 	// other
 	Help key.Binding
 	Quit key.Binding
 }
 
+// DefaultKeyMap are the key bindings of the UI, changed by SetKeys
 var DefaultKeyMap = KeyMap{
 	// navigation
 	Up: key.NewBinding(
@@ -47,11 +91,11 @@ var DefaultKeyMap = KeyMap{
 	),
 	Left: key.NewBinding(
 		key.WithKeys("left", "h"),
-		key.WithHelp("←/h", "move left"),
+		key.WithHelp("←/h", "scroll left"),
 	),
 	Right: key.NewBinding(
 		key.WithKeys("right", "l"),
-		key.WithHelp("→/l", "move right"),
+		key.WithHelp("→/l", "scroll right"),
 	),
 
 	// sorting (table)
@@ -84,7 +128,58 @@ var DefaultKeyMap = KeyMap{
 		key.WithHelp("6", "sort by port "),
 	),
 
+	// secondary sorting (table)
+	SortAdd: key.NewBinding(
+		key.WithKeys(""),
+		key.WithHelp("shift+[1-6]", "add sort"),
+	),
+	SortAddName: key.NewBinding(
+		key.WithKeys("!", "shift+1"),
+		key.WithHelp("!", "then by name"),
+	),
+	SortAddService: key.NewBinding(
+		key.WithKeys("@", "shift+2"),
+		key.WithHelp("@", "then by service"),
+	),
+	SortAddDomain: key.NewBinding(
+		key.WithKeys("#", "shift+3"),
+		key.WithHelp("#", "then by domain"),
+	),
+	SortAddHostname: key.NewBinding(
+		key.WithKeys("$", "shift+4"),
+		key.WithHelp("$", "then by hostname"),
+	),
+	SortAddIp: key.NewBinding(
+		key.WithKeys("%", "shift+5"),
+		key.WithHelp("%", "then by ip"),
+	),
+	SortAddPort: key.NewBinding(
+		key.WithKeys("^", "shift+6"),
+		key.WithHelp("^", "then by port"),
+	),
+
 	// fitlering (table)
+	Mark: key.NewBinding(
+		key.WithKeys("space"),
+		key.WithHelp("space", "mark"),
+	),
+	MarkRange: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "mark range"),
+	),
+	MarkInvert: key.NewBinding(
+		key.WithKeys("*"),
+		key.WithHelp("*", "invert marks"),
+	),
+	Hide: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "hide"),
+	),
+	Unhide: key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "show hidden"),
+	),
+
 	Filter: key.NewBinding(
 		key.WithKeys("/"),
 		key.WithHelp("/", "filter"),
@@ -103,15 +198,95 @@ var DefaultKeyMap = KeyMap{
 		key.WithKeys("s"),
 		key.WithHelp("s", "settings"),
 	),
+	Views: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "views"),
+	),
+	Columns: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "columns"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy"),
+	),
+	Open: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "open"),
+	),
+	Export: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "export"),
+	),
+	Probe: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "probe"),
+	),
+	Unprobe: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "stop probing"),
+	),
+	History: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "history"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh"),
+	),
+	Pause: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "pause/resume"),
+	),
+	Flush: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "clear"),
+	),
 	Select: key.NewBinding(
 		key.WithKeys("space", "enter"),
 		key.WithHelp("space/enter", "select"),
 	),
+	Details: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "details"),
+	),
 	Close: key.NewBinding(
 		key.WithKeys("esc"),
 		key.WithHelp("esc", "close"),
 	),
 
+	// views (picker)
+	SaveView: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "save current as view"),
+	),
+	DeleteView: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "delete view"),
+	),
+
+	// columns (manager)
+	MoveUp: key.NewBinding(
+		key.WithKeys("shift+up", "K"),
+		key.WithHelp("shift+↑/K", "move column left"),
+	),
+	MoveDown: key.NewBinding(
+		key.WithKeys("shift+down", "J"),
+		key.WithHelp("shift+↓/J", "move column right"),
+	),
+	Wider: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "wider"),
+	),
+	Narrower: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "narrower"),
+	),
+	Wrap: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "wrap"),
+	),
+
 	// other
 	Help: key.NewBinding(
 		key.WithKeys("?"),
@@ -122,3 +297,31 @@ var DefaultKeyMap = KeyMap{
 		key.WithHelp("q", "quit"),
 	),
 }
+
+// builtinKeyMap keeps the bindings SetKeys starts from
+var builtinKeyMap = DefaultKeyMap
+
+// SetKeys replaces the DefaultKeyMap with the built-in one where the given actions are rebound, ex: 'quit: [q, ctrl+c]'.
+// Actions are named after the KeyMap fields, case insensitive and ignoring '-' and '_' (ex: 'sort-add-name').
+// An empty list of keys disables the action. Models created afterwards use them.
+func SetKeys(bindings map[string][]string) error {
+	keys := builtinKeyMap
+	v := reflect.ValueOf(&keys).Elem()
+
+	for action, list := range bindings {
+		field := v.FieldByNameFunc(func(name string) bool {
+			return strings.EqualFold(name, strings.NewReplacer("-", "", "_", "").Replace(action))
+		})
+		if !field.IsValid() {
+			return fmt.Errorf("unknown key binding action '%s'", action)
+		}
+
+		binding := field.Addr().Interface().(*key.Binding)
+		binding.SetKeys(list...)
+		binding.SetHelp(strings.Join(list, "/"), binding.Help().Desc)
+		binding.SetEnabled(len(list) > 0)
+	}
+
+	DefaultKeyMap = keys
+	return nil
+}
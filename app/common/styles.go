@@ -6,23 +6,26 @@ import (
 	lg "charm.land/lipgloss/v2"
 )
 
+// Palette is the set of colors the styles are built from, the theme
+type Palette struct {
+	Top    color.Color
+	Mid    color.Color
+	MidLow color.Color
+	Bottom color.Color
+
+	Text      color.Color
+	Highlight color.Color
+	Lowlight  color.Color
+
+	Grey25 color.Color
+	Grey50 color.Color
+	Grey75 color.Color
+	Grey90 color.Color
+}
+
 type Styles struct {
 	// Colors
-	Color struct {
-		Top    color.Color
-		Mid    color.Color
-		MidLow color.Color
-		Bottom color.Color
-
-		Text      color.Color
-		Highlight color.Color
-		Lowlight  color.Color
-
-		Grey25 color.Color
-		Grey50 color.Color
-		Grey75 color.Color
-		Grey90 color.Color
-	}
+	Color Palette
 
 	// Styles
 	Base lg.Style
@@ -31,6 +34,7 @@ type Styles struct {
 		Base       lg.Style
 		Title      lg.Style
 		Spinner    lg.Style
+		Paused     lg.Style // shown instead of the spinner
 		Interfaces lg.Style
 		Interface  lg.Style
 	}
@@ -41,6 +45,10 @@ type Styles struct {
 		Row                lg.Style
 		RowCell            lg.Style
 		Selected           lg.Style
+		Marked             lg.Style
+		Added              lg.Style // rows added since the baseline
+		Changed            lg.Style // rows changed since the baseline
+		Removed            lg.Style // rows of the baseline missing now
 		FilterMatch        lg.Style
 		FilterInputFocused lg.Style
 		FilterInputBlurred lg.Style
@@ -58,26 +66,20 @@ type Styles struct {
 	}
 
 	Footer struct {
-		Base lg.Style
-		Help lg.Style
+		Base  lg.Style
+		Help  lg.Style
+		Toast lg.Style
 	}
 }
 
 func NewStyles() (s Styles) {
-	// Colors
-	s.Color.Top = lg.Color("202")
-	s.Color.Mid = lg.Color("203")
-	s.Color.MidLow = lg.Color("204")
-	s.Color.Bottom = lg.Color("205")
-
-	s.Color.Text = lg.Color("252")
-	s.Color.Highlight = lg.Color("204")
-	s.Color.Lowlight = lg.Color("96")
+	return NewThemeStyles(Themes[DEFAULT_THEME])
+}
 
-	s.Color.Grey25 = lg.Color("250")
-	s.Color.Grey50 = lg.Color("244")
-	s.Color.Grey75 = lg.Color("239")
-	s.Color.Grey90 = lg.Color("237")
+// NewThemeStyles returns the styles built from the colors of a theme
+func NewThemeStyles(palette Palette) (s Styles) {
+	// Colors
+	s.Color = palette
 
 	// Styles
 	s.Base = lg.NewStyle()
@@ -91,7 +93,11 @@ func NewStyles() (s Styles) {
 
 	s.Header.Spinner = lg.NewStyle().
 		PaddingRight(2).
-		Foreground(lg.Color("255"))
+		Foreground(s.Color.Text)
+
+	s.Header.Paused = s.Header.Spinner.
+		Foreground(s.Color.Highlight).
+		Bold(true)
 
 	s.Header.Interfaces = lg.NewStyle().
 		Foreground(s.Color.Grey50)
@@ -122,11 +128,25 @@ func NewStyles() (s Styles) {
 		Background(s.Color.Lowlight).
 		Foreground(lg.Color("255"))
 
+	s.Table.Marked = lg.NewStyle().
+		Foreground(s.Color.Top).
+		Bold(true)
+
+	s.Table.Added = lg.NewStyle().
+		Foreground(lg.Color("114"))
+
+	s.Table.Changed = lg.NewStyle().
+		Foreground(lg.Color("179"))
+
+	s.Table.Removed = lg.NewStyle().
+		Foreground(lg.Color("167")).
+		Faint(true)
+
 	s.Table.FilterMatch = lg.NewStyle().
 		Foreground(s.Color.Highlight)
 
 	s.Table.FilterInputFocused = lg.NewStyle().
-		Foreground(lg.Color("255"))
+		Foreground(s.Color.Text)
 
 	s.Table.FilterInputBlurred = s.Table.FilterInputFocused.
 		Foreground(s.Color.Grey25)
@@ -159,9 +179,14 @@ func NewStyles() (s Styles) {
 	s.Footer.Help = lg.NewStyle().
 		Foreground(s.Color.Bottom)
 
+	s.Footer.Toast = lg.NewStyle().
+		Foreground(s.Color.Highlight).
+		Bold(true)
+
 	return s
 }
 
+// DefaultStyles are the styles of the UI, changed by SetTheme
 var DefaultStyles Styles
 
 func init() {
@@ -0,0 +1,115 @@
+package common
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	lg "charm.land/lipgloss/v2"
+)
+
+// Theme used when none is configured
+const DEFAULT_THEME = "default"
+
+// Themes are the built-in palettes, by name
+var Themes = map[string]Palette{
+	DEFAULT_THEME: {
+		Top:       lg.Color("202"),
+		Mid:       lg.Color("203"),
+		MidLow:    lg.Color("204"),
+		Bottom:    lg.Color("205"),
+		Text:      lg.Color("252"),
+		Highlight: lg.Color("204"),
+		Lowlight:  lg.Color("96"),
+		Grey25:    lg.Color("250"),
+		Grey50:    lg.Color("244"),
+		Grey75:    lg.Color("239"),
+		Grey90:    lg.Color("237"),
+	},
+	// for terminals with a light background
+	"light": {
+		Top:       lg.Color("166"),
+		Mid:       lg.Color("161"),
+		MidLow:    lg.Color("162"),
+		Bottom:    lg.Color("163"),
+		Text:      lg.Color("236"),
+		Highlight: lg.Color("162"),
+		Lowlight:  lg.Color("139"),
+		Grey25:    lg.Color("239"),
+		Grey50:    lg.Color("244"),
+		Grey75:    lg.Color("249"),
+		Grey90:    lg.Color("253"),
+	},
+	"ocean": {
+		Top:       lg.Color("39"),
+		Mid:       lg.Color("38"),
+		MidLow:    lg.Color("37"),
+		Bottom:    lg.Color("36"),
+		Text:      lg.Color("252"),
+		Highlight: lg.Color("45"),
+		Lowlight:  lg.Color("24"),
+		Grey25:    lg.Color("250"),
+		Grey50:    lg.Color("244"),
+		Grey75:    lg.Color("239"),
+		Grey90:    lg.Color("237"),
+	},
+	"mono": {
+		Top:       lg.Color("255"),
+		Mid:       lg.Color("252"),
+		MidLow:    lg.Color("250"),
+		Bottom:    lg.Color("248"),
+		Text:      lg.Color("252"),
+		Highlight: lg.Color("255"),
+		Lowlight:  lg.Color("240"),
+		Grey25:    lg.Color("250"),
+		Grey50:    lg.Color("244"),
+		Grey75:    lg.Color("239"),
+		Grey90:    lg.Color("237"),
+	},
+}
+
+// SetTheme replaces the DefaultStyles with the ones of a built-in theme (default one if empty), whose colors
+// can be overridden by name, ex: 'top: "#ff8700"'. Models created afterwards use them.
+func SetTheme(name string, colors map[string]string) error {
+	if name == "" {
+		name = DEFAULT_THEME
+	}
+	palette, ok := Themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme '%s', available: %s", name, strings.Join(slices.Sorted(maps.Keys(Themes)), ", "))
+	}
+
+	for name, value := range colors {
+		c := lg.Color(value)
+		switch strings.ToLower(name) {
+		case "top":
+			palette.Top = c
+		case "mid":
+			palette.Mid = c
+		case "midlow":
+			palette.MidLow = c
+		case "bottom":
+			palette.Bottom = c
+		case "text":
+			palette.Text = c
+		case "highlight":
+			palette.Highlight = c
+		case "lowlight":
+			palette.Lowlight = c
+		case "grey25":
+			palette.Grey25 = c
+		case "grey50":
+			palette.Grey50 = c
+		case "grey75":
+			palette.Grey75 = c
+		case "grey90":
+			palette.Grey90 = c
+		default:
+			return fmt.Errorf("unknown color '%s'", name)
+		}
+	}
+
+	DefaultStyles = NewThemeStyles(palette)
+	return nil
+}
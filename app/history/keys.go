@@ -0,0 +1,77 @@
+package history
+
+import (
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/list"
+
+	"gitlab.com/patopest/mdns-discovery/app/common"
+)
+
+type keyMap struct {
+	list.KeyMap
+
+	Up   key.Binding
+	Down key.Binding
+
+	Details key.Binding
+	Close   key.Binding
+
+	// filter input
+	Confirm key.Binding
+	Cancel  key.Binding
+}
+
+// Implements help.KeyMap interface
+func (m *Model) ShortHelp() []key.Binding {
+	switch {
+	case m.IsInputFocused():
+		return []key.Binding{m.Keys.Confirm, m.Keys.Cancel}
+	case m.details != nil:
+		return []key.Binding{m.Keys.Close}
+	}
+	return []key.Binding{m.Keys.Details, m.Keys.Filter, m.Keys.Close}
+}
+
+// Implements help.KeyMap interface
+func (m *Model) FullHelp() [][]key.Binding {
+	switch {
+	case m.IsInputFocused():
+		return [][]key.Binding{{m.Keys.Confirm, m.Keys.Cancel}}
+	case m.details != nil:
+		return [][]key.Binding{{m.Keys.Close}}
+	}
+	return [][]key.Binding{
+		{m.Keys.Up, m.Keys.Down},        // first column
+		{m.Keys.Details, m.Keys.Filter}, // second column
+		{m.Keys.Close},                  // ...
+	}
+}
+
+// newKeyMap returns the key bindings of the pane, from the common ones (rebound by the config)
+func newKeyMap() keyMap {
+	return keyMap{
+		KeyMap: list.KeyMap{
+			CursorUp:             common.DefaultKeyMap.Up,
+			CursorDown:           common.DefaultKeyMap.Down,
+			Filter:               common.DefaultKeyMap.Filter,
+			ClearFilter:          common.DefaultKeyMap.FilterClear,
+			AcceptWhileFiltering: key.NewBinding(key.WithKeys("enter")),
+			CancelWhileFiltering: key.NewBinding(key.WithKeys("esc")),
+		},
+
+		Up:   common.DefaultKeyMap.Up,
+		Down: common.DefaultKeyMap.Down,
+
+		Details: common.DefaultKeyMap.Details,
+		Close:   common.DefaultKeyMap.Close,
+
+		Confirm: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "apply filter"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+	}
+}
@@ -0,0 +1,266 @@
+package history
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/list"
+	tea "charm.land/bubbletea/v2"
+	lg "charm.land/lipgloss/v2"
+
+	"gitlab.com/patopest/mdns-discovery/app/common"
+	db "gitlab.com/patopest/mdns-discovery/history"
+)
+
+// Styles
+type Styles struct {
+	Base   lg.Style
+	Title  lg.Style
+	Empty  lg.Style
+	Label  lg.Style
+	Value  lg.Style
+	Online lg.Style
+
+	Item list.DefaultItemStyles
+}
+
+func NewStyles() (s Styles) {
+	var c = &common.DefaultStyles.Color
+
+	s.Base = lg.NewStyle().
+		Padding(0, 2)
+
+	s.Title = lg.NewStyle().
+		Foreground(c.Mid)
+
+	s.Empty = lg.NewStyle().
+		Padding(0, 0, 0, 2).
+		Foreground(c.Grey50)
+
+	s.Label = lg.NewStyle().
+		Foreground(c.MidLow).
+		Width(12)
+
+	s.Value = lg.NewStyle().
+		Foreground(c.Text)
+
+	s.Online = lg.NewStyle().
+		Foreground(c.MidLow).
+		Bold(true)
+
+	s.Item.NormalTitle = lg.NewStyle().
+		Foreground(c.Text).
+		Padding(0, 0, 0, 2)
+
+	s.Item.NormalDesc = s.Item.NormalTitle.
+		Foreground(lg.Darken(c.Text, 0.5))
+
+	s.Item.SelectedTitle = lg.NewStyle().
+		Border(lg.NormalBorder(), false, false, false, true).
+		BorderForeground(lg.Darken(c.MidLow, 0.30)).
+		Foreground(c.MidLow).
+		Padding(0, 0, 0, 1)
+
+	s.Item.SelectedDesc = s.Item.SelectedTitle.
+		Foreground(lg.Darken(c.MidLow, 0.30))
+
+	s.Item.DimmedTitle = s.Item.NormalTitle
+	s.Item.DimmedDesc = s.Item.NormalDesc
+	s.Item.FilterMatch = lg.NewStyle().Underline(true)
+
+	return s
+}
+
+// Item represents a recorded instance in the list
+type Item struct {
+	instance db.Instance
+	now      time.Time
+	gap      time.Duration // see db.Store.Gap
+}
+
+// Implements list.Item interface
+func (i Item) FilterValue() string {
+	return strings.Join(append([]string{i.instance.Name, i.instance.Hostname}, i.instance.IPs()...), " ")
+}
+func (i Item) Title() string { return i.instance.Name }
+func (i Item) Description() string {
+	parts := []string{i.instance.Hostname}
+	if ips := i.instance.IPs(); len(ips) > 0 {
+		parts = append(parts, ips[0])
+	}
+	parts = append(parts, "first seen "+db.FormatTime(i.instance.FirstSeen, i.now))
+	parts = append(parts, "last seen "+lastSeen(i.instance, i.now, i.gap))
+	return strings.Join(parts, " | ")
+}
+
+// Model is the list of the instances recorded in the history, with the details of one of them
+type Model struct {
+	store   *db.Store
+	list    list.Model
+	details *db.Instance // instance shown, nil for the list
+	now     time.Time
+	height  int
+
+	Keys   keyMap
+	Styles Styles
+}
+
+// New creates a new history list of the store's instances
+func New(store *db.Store) *Model {
+	styles := NewStyles()
+
+	delegate := list.NewDefaultDelegate()
+	delegate.Styles = styles.Item
+
+	l := list.New([]list.Item{}, delegate, 0, 0)
+	l.Title = "History"
+	keys := newKeyMap()
+	l.KeyMap = keys.KeyMap
+	l.Styles.Title = styles.Title
+	l.SetShowHelp(false)
+	l.SetShowTitle(true)
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(true)
+	l.DisableQuitKeybindings()
+
+	return &Model{
+		store:  store,
+		list:   l,
+		Keys:   keys,
+		Styles: styles,
+	}
+}
+
+// CloseMsg is sent when the history should be closed
+type CloseMsg struct{}
+
+// Refresh reloads the instances from the store, most recently seen first
+func (m *Model) Refresh() {
+	m.now = time.Now()
+	m.details = nil
+
+	items := []list.Item{}
+	for _, instance := range m.store.Instances(db.Query{}) {
+		items = append(items, Item{instance: instance, now: m.now, gap: m.store.Gap})
+	}
+	m.list.SetItems(items)
+	m.list.Title = fmt.Sprintf("History (%d instances)", len(items))
+}
+
+// IsInputFocused returns whether the filter input is focused
+func (m *Model) IsInputFocused() bool {
+	return m.list.SettingFilter()
+}
+
+func (m *Model) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+
+	if msg, ok := msg.(tea.KeyPressMsg); ok && !m.IsInputFocused() {
+		switch {
+		case m.details != nil:
+			if key.Matches(msg, m.Keys.Close, m.Keys.Details) {
+				m.details = nil
+			}
+			return nil
+		case key.Matches(msg, m.Keys.Details):
+			if item, ok := m.list.SelectedItem().(Item); ok {
+				m.details = &item.instance
+			}
+			return nil
+		case key.Matches(msg, m.Keys.Close) && !m.list.IsFiltered():
+			return func() tea.Msg { return CloseMsg{} }
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	return cmd
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.list.SetSize(width, height)
+	m.height = height
+}
+
+func (m *Model) View() string {
+	var s = &m.Styles
+
+	if m.details != nil {
+		return s.Base.Render(m.viewDetails(*m.details))
+	}
+	if len(m.list.Items()) == 0 {
+		return lg.JoinVertical(lg.Left,
+			s.Base.Render(s.Title.Render(m.list.Title)),
+			"",
+			s.Empty.Render("no service recorded yet, run with --history to record them"),
+		)
+	}
+	return m.list.View()
+}
+
+// viewDetails renders everything recorded about an instance, the most recent presence intervals and TXT
+// records first, cut to the available height
+func (m *Model) viewDetails(instance db.Instance) string {
+	var s = &m.Styles
+
+	lines := []string{s.Title.Render(instance.Name), ""}
+	field := func(label, value string) {
+		lines = append(lines, s.Label.Render(label)+s.Value.Render(value))
+	}
+	field("hostname", fmt.Sprintf("%s:%d", instance.Hostname, instance.Port))
+	field("interfaces", strings.Join(instance.Interfaces, ", "))
+	field("first seen", db.FormatTime(instance.FirstSeen, m.now))
+	if instance.IsOnline(m.now, m.store.Gap) {
+		lines = append(lines, s.Label.Render("last seen")+s.Online.Render("online"))
+	} else {
+		field("last seen", db.FormatTime(instance.LastSeen, m.now))
+	}
+
+	lines = append(lines, "")
+	for i, address := range slices.Backward(instance.Addresses) {
+		label := ""
+		if i == len(instance.Addresses)-1 {
+			label = "addresses"
+		}
+		field(label, fmt.Sprintf("%s (%s → %s)", address.IP, db.FormatTime(address.FirstSeen, m.now), db.FormatTime(address.LastSeen, m.now)))
+	}
+
+	lines = append(lines, "")
+	for i, interval := range slices.Backward(instance.Presence) {
+		label := ""
+		if i == len(instance.Presence)-1 {
+			label = "presence"
+		}
+		field(label, fmt.Sprintf("%s → %s (%s)", db.FormatTime(interval.Start, m.now), db.FormatTime(interval.End, m.now),
+			db.FormatDuration(interval.End.Sub(interval.Start))))
+	}
+
+	lines = append(lines, "")
+	for i, record := range slices.Backward(instance.TXT) {
+		label := ""
+		if i == len(instance.TXT)-1 {
+			label = "txt"
+		}
+		fields := []string{}
+		for _, key := range slices.Sorted(maps.Keys(record.TXT)) {
+			fields = append(fields, key+"="+record.TXT[key])
+		}
+		field(label, db.FormatTime(record.Time, m.now)+": "+strings.Join(fields, " "))
+	}
+
+	if m.height > 0 && len(lines) > m.height {
+		lines = lines[:m.height]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// lastSeen formats the last time an instance was seen, 'online' if it is still announced
+func lastSeen(instance db.Instance, now time.Time, gap time.Duration) string {
+	if instance.IsOnline(now, gap) {
+		return "online"
+	}
+	return db.FormatTime(instance.LastSeen, now)
+}
@@ -31,15 +31,18 @@ func (m *Model) FullHelp() [][]key.Binding {
 	}
 }
 
-var SettingsKeyMap = keyMap{
-	KeyMap: list.KeyMap{
-		CursorUp:   common.DefaultKeyMap.Up,
-		CursorDown: common.DefaultKeyMap.Down,
-	},
-
-	Up:   common.DefaultKeyMap.Up,
-	Down: common.DefaultKeyMap.Down,
-
-	Select: common.DefaultKeyMap.Select,
-	Close:  common.DefaultKeyMap.Close,
+// newKeyMap returns the key bindings of the pane, from the common ones (rebound by the config)
+func newKeyMap() keyMap {
+	return keyMap{
+		KeyMap: list.KeyMap{
+			CursorUp:   common.DefaultKeyMap.Up,
+			CursorDown: common.DefaultKeyMap.Down,
+		},
+
+		Up:   common.DefaultKeyMap.Up,
+		Down: common.DefaultKeyMap.Down,
+
+		Select: common.DefaultKeyMap.Select,
+		Close:  common.DefaultKeyMap.Close,
+	}
 }
@@ -183,7 +183,8 @@ func New(discovery *network.Discovery) *Model {
 
 	l := list.New(items, delegate, 0, 0)
 	l.Title = "Select network interfaces"
-	l.KeyMap = SettingsKeyMap.KeyMap
+	keys := newKeyMap()
+	l.KeyMap = keys.KeyMap
 	l.Styles.Title = styles.Title
 	l.SetShowHelp(false)
 	l.SetShowTitle(true)
@@ -195,7 +196,7 @@ func New(discovery *network.Discovery) *Model {
 	return &Model{
 		discovery: discovery,
 		list:      l,
-		Keys:      SettingsKeyMap,
+		Keys:      keys,
 		Styles:    styles,
 	}
 }
@@ -0,0 +1,57 @@
+package table
+
+import (
+	"slices"
+	"time"
+
+	lg "charm.land/lipgloss/v2"
+
+	"gitlab.com/patopest/mdns-discovery/app/common"
+	"gitlab.com/patopest/mdns-discovery/app/table/table"
+	"gitlab.com/patopest/mdns-discovery/network"
+	"gitlab.com/patopest/mdns-discovery/snapshot"
+)
+
+// SetBaseline compares the entries to a snapshot, shown in the diff column and by the color of the rows.
+// The services of the baseline missing from the entries are listed as removed.
+func (m *Model) SetBaseline(baseline snapshot.Snapshot) {
+	m.baseline = &baseline
+	if columns := m.GetVisibleColumns(); !slices.Contains(columns, "diff") {
+		m.SetVisibleColumns(append(columns, "diff"))
+	}
+	m.SetRows(m.entries)
+}
+
+// compareToBaseline returns the entries followed by the ones of the baseline missing from them
+func (m *Model) compareToBaseline(entries []network.Entry) []network.Entry {
+	m.diff = snapshot.Compare(*m.baseline, snapshot.New(entries, time.Now()), nil)
+	m.removed = []network.Entry{}
+	for _, service := range m.diff.Removed {
+		m.removed = append(m.removed, snapshot.Entry(service))
+	}
+	return slices.Concat(entries, m.removed)
+}
+
+// diffValue returns the value of the diff column of an entry
+func (m *Model) diffValue(entry network.Entry) string {
+	if m.baseline == nil {
+		return ""
+	}
+	return m.diff.Status(entry.Name).String()
+}
+
+// diffStyle colors the rows added, changed or removed since the baseline
+func diffStyle(row table.Row) lg.Style {
+	var styles = &common.DefaultStyles
+
+	switch row.GetString("diff") {
+	case snapshot.STATUS_ADDED.String():
+		return styles.Table.Added
+	case snapshot.STATUS_CHANGED.String():
+		return styles.Table.Changed
+	case snapshot.STATUS_REMOVED.String():
+		return styles.Table.Removed
+	default:
+		return lg.NewStyle()
+	}
+}
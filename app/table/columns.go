@@ -0,0 +1,235 @@
+package table
+
+import (
+	"cmp"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	lg "charm.land/lipgloss/v2"
+
+	"gitlab.com/patopest/mdns-discovery/app/common"
+	"gitlab.com/patopest/mdns-discovery/app/table/table"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// Prefix of the optional columns extracting a single key of the TXT record, ex: 'txt.version'
+const TXT_COLUMN_PREFIX = "txt."
+
+// Value of the description column for service types missing from the catalog
+const UNKNOWN_SERVICE = "(unknown type)"
+
+// Keys of the columns visible by default
+var DefaultColumns = []string{"name", "service", "protocol", "domain", "hostname", "ip", "port", "info"}
+
+// newColumns returns all the built-in columns, default and optional ones
+func newColumns() []table.Column {
+	var styles = &common.DefaultStyles
+
+	return []table.Column{
+		// default
+		table.NewFlexColumn("name", "Name", 20).WithFiltering(true),
+		table.NewFlexColumn("service", "Service", 14).WithFiltering(true),
+		table.NewFlexColumn("description", "Description", 20).WithFiltering(true), // optional
+		table.NewFlexColumn("protocol", "Protocol", 6).WithFiltering(true),
+		table.NewFlexColumn("domain", "Domain", 6).WithFiltering(true),
+		table.NewFlexColumn("hostname", "Hostname", 18).WithFiltering(true),
+		table.NewColumn("ip", "IP", 15).WithFiltering(true).WithSortFunc(SortIPs),
+		table.NewColumn("port", "Port", 6).WithFiltering(true).WithStyle(styles.Table.RowCell.Align(lg.Right).PaddingRight(1)),
+		table.NewFlexColumn("info", "Info", 20).WithFiltering(true).WithStyle(styles.Table.RowCell.UnsetPadding()),
+		// optional
+		table.NewColumn("ipv6", "IPv6", 26).WithFiltering(true).WithSortFunc(SortIPs),
+		table.NewFlexColumn("interface", "Interface", 6).WithFiltering(true),
+		table.NewColumn("mac", "MAC", 19).WithFiltering(true),
+		table.NewFlexColumn("vendor", "Vendor", 10).WithFiltering(true),
+		table.NewFlexColumn("model", "Model", 14).WithFiltering(true),
+		table.NewColumn("status", "Status", 12).WithFiltering(true),
+		table.NewColumn("diff", "Diff", 9).WithFiltering(true),
+		table.NewColumn("lastseen", "Last Seen", 10).WithFiltering(true).WithSortFunc(SortTimestamps),
+		table.NewColumn("ttl", "TTL", 8).WithFiltering(true).WithSortFunc(SortTTLs),
+	}
+}
+
+// newTxtColumn returns a column showing the value of a single TXT record key
+func newTxtColumn(txtKey string) table.Column {
+	return table.NewFlexColumn(TXT_COLUMN_PREFIX+txtKey, TXT_COLUMN_PREFIX+txtKey, 8).WithFiltering(true)
+}
+
+// AvailableColumns returns all columns that can be displayed, including TXT columns for the keys seen so far
+func (m *Model) AvailableColumns() []table.Column {
+	return m.allColumns
+}
+
+// findColumn returns a column definition by key, creating TXT columns on demand
+func (m *Model) findColumn(key string) (table.Column, bool) {
+	idx := slices.IndexFunc(m.allColumns, func(c table.Column) bool { return c.Key() == key })
+	if idx >= 0 {
+		return m.allColumns[idx], true
+	}
+	if txtKey, ok := strings.CutPrefix(key, TXT_COLUMN_PREFIX); ok && txtKey != "" {
+		column := newTxtColumn(txtKey)
+		m.allColumns = append(m.allColumns, column)
+		return column, true
+	}
+	return table.Column{}, false
+}
+
+// addTxtColumns makes a TXT column available for each TXT key found in the rows
+func (m *Model) addTxtColumns(rows []table.Row) {
+	for _, row := range rows {
+		keys := []string{}
+		for key := range row.Data {
+			if strings.HasPrefix(key, TXT_COLUMN_PREFIX) {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			m.findColumn(key)
+		}
+	}
+}
+
+// ToggleColumn shows or hides a column (the last visible column can't be hidden)
+func (m *Model) ToggleColumn(key string) {
+	idx := slices.IndexFunc(m.columns, func(c table.Column) bool { return c.Key() == key })
+	if idx >= 0 {
+		if len(m.columns) > 1 {
+			m.columns = slices.Delete(m.columns, idx, idx+1)
+		}
+	} else if column, ok := m.findColumn(key); ok {
+		m.columns = append(m.columns, column)
+	}
+	m.applySort()
+}
+
+// MoveColumn moves a visible column by delta positions (negative moves left)
+func (m *Model) MoveColumn(key string, delta int) {
+	idx := slices.IndexFunc(m.columns, func(c table.Column) bool { return c.Key() == key })
+	if idx < 0 {
+		return
+	}
+	newIdx := min(max(idx+delta, 0), len(m.columns)-1)
+	column := m.columns[idx]
+	m.columns = slices.Delete(m.columns, idx, idx+1)
+	m.columns = slices.Insert(m.columns, newIdx, column)
+	m.applySort()
+}
+
+// SetColumnWidth sets the width of a fixed column, or the flex factor of a flexible one
+func (m *Model) SetColumnWidth(key string, width int) {
+	width = max(width, 1)
+	resize := func(c table.Column) table.Column {
+		if c.IsFlex() {
+			return c.WithFlexFactor(width)
+		}
+		return c.WithWidth(width)
+	}
+
+	for i, column := range m.allColumns {
+		if column.Key() == key {
+			m.allColumns[i] = resize(column)
+		}
+	}
+	for i, column := range m.columns {
+		if column.Key() == key {
+			m.columns[i] = resize(column)
+		}
+	}
+	m.applySort()
+}
+
+// SetColumnWrap sets whether a column wraps long cells over multiple lines
+func (m *Model) SetColumnWrap(key string, wrap bool) {
+	for i, column := range m.allColumns {
+		if column.Key() == key {
+			m.allColumns[i] = column.WithWrap(wrap)
+		}
+	}
+	for i, column := range m.columns {
+		if column.Key() == key {
+			m.columns[i] = column.WithWrap(wrap)
+		}
+	}
+	m.applySort()
+}
+
+// ColumnWidth returns the width of a fixed column, or the flex factor of a flexible one
+func ColumnWidth(c table.Column) int {
+	if c.IsFlex() {
+		return c.FlexFactor()
+	}
+	return c.Width()
+}
+
+// parseTxt returns the key/value pairs of a TXT record
+func parseTxt(entry network.Entry) map[string]string {
+	fields := entry.InfoFields
+	if len(fields) == 0 && entry.Info != "" {
+		fields = strings.Split(entry.Info, "|")
+	}
+
+	txt := map[string]string{}
+	for _, field := range fields {
+		key, value, _ := strings.Cut(field, "=")
+		if key != "" {
+			txt[key] = network.Unescape(value)
+		}
+	}
+	return txt
+}
+
+// describeService returns the catalog description of a service type, flagging unknown ones
+func (m *Model) describeService(service string) string {
+	if description, ok := m.catalog.Describe(service); ok {
+		return description
+	}
+	return UNKNOWN_SERVICE
+}
+
+// ipValue returns the row value of an IP, nil if there is none so that it is displayed empty
+func ipValue(ip net.IP) interface{} {
+	if ip == nil {
+		return nil
+	}
+	return ip
+}
+
+// TTL is the time to live of the records of an entry, displayed empty if unknown (ex: fake data)
+type TTL time.Duration
+
+func (t TTL) String() string {
+	if t <= 0 {
+		return ""
+	}
+	return time.Duration(t).String()
+}
+
+// SortTTLs is a special sort function to sort the TTL values of a column
+func SortTTLs(a, b interface{}) int {
+	ttlA, _ := a.(TTL)
+	ttlB, _ := b.(TTL)
+	return cmp.Compare(ttlA, ttlB)
+}
+
+// Timestamp is a time displayed as a time of day in the table
+type Timestamp time.Time
+
+func (t Timestamp) String() string {
+	if time.Time(t).IsZero() {
+		return ""
+	}
+	return time.Time(t).Format(time.TimeOnly)
+}
+
+func (t Timestamp) MarshalText() ([]byte, error) {
+	return time.Time(t).MarshalText()
+}
+
+// SortTimestamps is a special sort function to sort the Timestamp values of a column
+func SortTimestamps(a, b interface{}) int {
+	timeA, _ := a.(Timestamp)
+	timeB, _ := b.(Timestamp)
+	return time.Time(timeA).Compare(time.Time(timeB))
+}
@@ -0,0 +1,47 @@
+package table
+
+import (
+	"net"
+
+	"gitlab.com/patopest/mdns-discovery/device"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// SetNeighborTable sets the table used to resolve the MAC addresses of the hosts, refreshed by the caller
+func (m *Model) SetNeighborTable(neighbors network.NeighborTable) {
+	m.neighbors = neighbors
+	m.SetRows(m.entries)
+}
+
+// NeighborTable returns the table used to resolve the MAC addresses of the hosts
+func (m *Model) NeighborTable() network.NeighborTable {
+	return m.neighbors
+}
+
+// macValue formats a MAC address for display
+func macValue(mac net.HardwareAddr) string {
+	if mac == nil {
+		return ""
+	}
+	return mac.String()
+}
+
+// identifyDevices guesses the vendor and model of each host from all its entries and its MAC address
+func (m *Model) identifyDevices(entries []network.Entry) map[string]device.Info {
+	byHost := map[string][]network.Entry{}
+	for _, entry := range entries {
+		byHost[entry.Host] = append(byHost[entry.Host], entry)
+	}
+
+	devices := map[string]device.Info{}
+	for host, hostEntries := range byHost {
+		var mac net.HardwareAddr
+		for _, entry := range hostEntries {
+			if mac == nil {
+				mac = network.LookupMAC(m.neighbors, entry)
+			}
+		}
+		devices[host] = device.Identify(hostEntries, mac)
+	}
+	return devices
+}
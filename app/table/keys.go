@@ -18,6 +18,17 @@ type KeyMap struct {
 	SortIp       key.Binding
 	SortPort     key.Binding
 
+	SortAdd         key.Binding // fake key only for description purposes (in help)
+	SortAddName     key.Binding
+	SortAddService  key.Binding
+	SortAddDomain   key.Binding
+	SortAddHostname key.Binding
+	SortAddIp       key.Binding
+	SortAddPort     key.Binding
+
+	Hide   key.Binding
+	Unhide key.Binding
+
 	Select key.Binding
 	Close  key.Binding
 }
@@ -34,6 +45,14 @@ func (m Model) ShortHelp() []key.Binding {
 	} else {
 		keys = append(keys, m.Keys.Sort, m.Keys.Filter, m.Keys.Select)
 	}
+	if !m.isViewportVisible && !m.table.IsFilterInputFocused() {
+		if len(m.table.MarkedRows()) > 0 {
+			keys = append(keys, m.Keys.Hide)
+		}
+		if m.HiddenCount() > 0 {
+			keys = append(keys, m.Keys.Unhide)
+		}
+	}
 	return keys
 }
 
@@ -41,9 +60,15 @@ func (m Model) ShortHelp() []key.Binding {
 func (m Model) FullHelp() [][]key.Binding {
 	keys := [][]key.Binding{
 		{m.Keys.Up, m.Keys.Down},              // first column
-		{m.Keys.SortName, m.Keys.SortService}, // second column
+		{m.Keys.Left, m.Keys.Right},           // second column
+		{m.Keys.SortName, m.Keys.SortService}, // ...
 		{m.Keys.SortDomain, m.Keys.SortHostname},
 		{m.Keys.SortIp, m.Keys.SortPort},
+		{m.Keys.SortAddName, m.Keys.SortAddService},
+		{m.Keys.SortAddDomain, m.Keys.SortAddHostname},
+		{m.Keys.SortAddIp, m.Keys.SortAddPort},
+		{m.Keys.Mark, m.Keys.MarkRange, m.Keys.MarkInvert},
+		{m.Keys.Hide, m.Keys.Unhide},
 	}
 	if m.isViewportVisible {
 		keys = append(keys, []key.Binding{m.Keys.Select})
@@ -57,26 +82,44 @@ func (m Model) FullHelp() [][]key.Binding {
 	return keys
 }
 
-var TableKeyMap = KeyMap{
-	KeyMap: table.KeyMap{
-		Up:    common.DefaultKeyMap.Up,
-		Down:  common.DefaultKeyMap.Down,
-		Left:  common.DefaultKeyMap.Left,
-		Right: common.DefaultKeyMap.Right,
-
-		Filter:      common.DefaultKeyMap.Filter,
-		FilterBlur:  common.DefaultKeyMap.FilterBlur,
-		FilterClear: common.DefaultKeyMap.FilterClear,
-	},
-
-	Sort:         common.DefaultKeyMap.Sort,
-	SortName:     common.DefaultKeyMap.SortName,
-	SortService:  common.DefaultKeyMap.SortService,
-	SortDomain:   common.DefaultKeyMap.SortDomain,
-	SortHostname: common.DefaultKeyMap.SortHostname,
-	SortIp:       common.DefaultKeyMap.SortIp,
-	SortPort:     common.DefaultKeyMap.SortPort,
-
-	Select: common.DefaultKeyMap.Select,
-	Close:  common.DefaultKeyMap.Close,
+// newKeyMap returns the key bindings of the pane, from the common ones (rebound by the config)
+func newKeyMap() KeyMap {
+	return KeyMap{
+		KeyMap: table.KeyMap{
+			Up:    common.DefaultKeyMap.Up,
+			Down:  common.DefaultKeyMap.Down,
+			Left:  common.DefaultKeyMap.Left,
+			Right: common.DefaultKeyMap.Right,
+
+			Mark:       common.DefaultKeyMap.Mark,
+			MarkRange:  common.DefaultKeyMap.MarkRange,
+			MarkInvert: common.DefaultKeyMap.MarkInvert,
+
+			Filter:      common.DefaultKeyMap.Filter,
+			FilterBlur:  common.DefaultKeyMap.FilterBlur,
+			FilterClear: common.DefaultKeyMap.FilterClear,
+		},
+
+		Sort:         common.DefaultKeyMap.Sort,
+		SortName:     common.DefaultKeyMap.SortName,
+		SortService:  common.DefaultKeyMap.SortService,
+		SortDomain:   common.DefaultKeyMap.SortDomain,
+		SortHostname: common.DefaultKeyMap.SortHostname,
+		SortIp:       common.DefaultKeyMap.SortIp,
+		SortPort:     common.DefaultKeyMap.SortPort,
+
+		SortAdd:         common.DefaultKeyMap.SortAdd,
+		SortAddName:     common.DefaultKeyMap.SortAddName,
+		SortAddService:  common.DefaultKeyMap.SortAddService,
+		SortAddDomain:   common.DefaultKeyMap.SortAddDomain,
+		SortAddHostname: common.DefaultKeyMap.SortAddHostname,
+		SortAddIp:       common.DefaultKeyMap.SortAddIp,
+		SortAddPort:     common.DefaultKeyMap.SortAddPort,
+
+		Hide:   common.DefaultKeyMap.Hide,
+		Unhide: common.DefaultKeyMap.Unhide,
+
+		Select: common.DefaultKeyMap.Details,
+		Close:  common.DefaultKeyMap.Close,
+	}
 }
@@ -0,0 +1,66 @@
+package table
+
+import (
+	"slices"
+
+	"gitlab.com/patopest/mdns-discovery/app/table/table"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// TargetEntries returns the entries of the marked rows (in display order), or the entry of
+// the selected row if none is marked. Bulk actions apply to these entries.
+func (m *Model) TargetEntries() []network.Entry {
+	rows := m.table.MarkedRows()
+	if len(rows) == 0 && m.table.VisibleRowCount() > 0 {
+		rows = append(rows, m.table.SelectedRow())
+	}
+
+	entries := []network.Entry{}
+	for _, row := range rows {
+		if entry, ok := m.Entry(row); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// VisibleEntries returns the entries of the visible rows, filtered and sorted as displayed
+func (m *Model) VisibleEntries() []network.Entry {
+	entries := []network.Entry{}
+	for _, row := range m.table.Rows() {
+		if entry, ok := m.Entry(row); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Entry returns the entry a row was generated from
+func (m *Model) Entry(row table.Row) (network.Entry, bool) {
+	entries := slices.Concat(m.entries, m.removed)
+	idx := slices.IndexFunc(entries, func(e network.Entry) bool { return e.ID() == row.ID })
+	if idx < 0 {
+		return network.Entry{}, false
+	}
+	return entries[idx], true
+}
+
+// HideTargets hides the marked rows (or the selected row) until ShowHidden is called
+func (m *Model) HideTargets() {
+	for _, entry := range m.TargetEntries() {
+		m.hidden[entry.ID()] = true
+	}
+	m.table.ClearMarks()
+	m.SetRows(m.entries)
+}
+
+// ShowHidden shows all the rows hidden with HideTargets
+func (m *Model) ShowHidden() {
+	m.hidden = map[string]bool{}
+	m.SetRows(m.entries)
+}
+
+// HiddenCount returns the number of hidden entries
+func (m *Model) HiddenCount() int {
+	return len(m.hidden)
+}
@@ -1,9 +1,8 @@
 package table
 
 import (
+	"cmp"
 	"fmt"
-	"golang.org/x/text/cases"
-	"golang.org/x/text/language"
 	"net"
 	"slices"
 	"strings"
@@ -15,7 +14,11 @@ import (
 
 	"gitlab.com/patopest/mdns-discovery/app/common"
 	"gitlab.com/patopest/mdns-discovery/app/table/table"
+	"gitlab.com/patopest/mdns-discovery/catalog"
 	"gitlab.com/patopest/mdns-discovery/network"
+	"gitlab.com/patopest/mdns-discovery/probe"
+	"gitlab.com/patopest/mdns-discovery/snapshot"
+	"gitlab.com/patopest/mdns-discovery/txt"
 )
 
 const (
@@ -26,10 +29,20 @@ const (
 
 // Model wraps the table Model with additional logic for sorting and data transformation
 type Model struct {
-	table           table.Model
-	columns         []table.Column
-	sortedColumnKey string
-	sortedDirection int
+	table      table.Model
+	allColumns []table.Column // all available columns
+	columns    []table.Column // visible columns
+	sortStack  []SortKey      // by order of priority
+
+	entries   []network.Entry           // entries the rows are generated from
+	hidden    map[string]bool           // IDs of the entries hidden by the user
+	probes    map[string]probe.Result   // reachability of the entries, by ID
+	httpInfo  map[string]probe.HTTPInfo // metadata of the web services, by ID
+	catalog   *catalog.Catalog
+	neighbors network.NeighborTable // resolves MAC addresses
+	baseline  *snapshot.Snapshot    // compared to the entries, nil if none
+	diff      snapshot.Diff         // of the entries since the baseline
+	removed   []network.Entry       // entries of the baseline missing now
 
 	viewport          viewport.Model
 	isViewportVisible bool
@@ -41,46 +54,49 @@ type Model struct {
 
 // NewModel creates a new table wrapper with predefined columns
 func New() Model {
-	var styles = &common.DefaultStyles
-
-	columns := []table.Column{
-		table.NewFlexColumn("name", "Name", 20).WithFiltering(true),
-		table.NewFlexColumn("service", "Service", 14).WithFiltering(true),
-		table.NewFlexColumn("protocol", "Protocol", 6).WithFiltering(true),
-		table.NewFlexColumn("domain", "Domain", 6).WithFiltering(true),
-		table.NewFlexColumn("hostname", "Hostname", 18).WithFiltering(true),
-		table.NewColumn("ip", "IP", 15).WithFiltering(true).WithSortFunc(SortIPs),
-		table.NewColumn("port", "Port", 6).WithFiltering(true).WithStyle(styles.Table.RowCell.Align(lg.Right).PaddingRight(1)),
-		table.NewFlexColumn("info", "Info", 20).WithFiltering(true).WithStyle(styles.Table.RowCell.UnsetPadding()),
-	}
+	columns := newColumns()
 
-	table := table.New(columns).WithFiltering(true)
+	table := table.New(columns).WithFiltering(true).WithFrozenColumns(1) // keep the name in place when scrolling horizontally
 	table.Focus(true)
-	table.Keys = TableKeyMap.KeyMap
-	table.Styles.Base = styles.Table.Base
-	table.Styles.Header = styles.Table.Header
-	table.Styles.Row = styles.Table.Row
-	table.Styles.RowCell = styles.Table.RowCell
-	table.Styles.Selected = styles.Table.Selected
-	table.Styles.FilterMatch = styles.Table.FilterMatch
-	table.Styles.FilterInputFocused = styles.Table.FilterInputFocused
-	table.Styles.FilterInputBlurred = styles.Table.FilterInputBlurred
-	table.Styles.Footer = styles.Table.Footer
-
-	viewport := viewport.New()
-	viewport.Style = styles.Viewport.Base
-
-	return Model{
+	table = table.WithRowStyleFunc(diffStyle)
+
+	m := Model{
 		table:             table,
-		columns:           columns,
-		sortedColumnKey:   "",
-		sortedDirection:   SortedNone,
-		viewport:          viewport,
+		allColumns:        columns,
+		sortStack:         []SortKey{},
+		hidden:            map[string]bool{},
+		probes:            map[string]probe.Result{},
+		httpInfo:          map[string]probe.HTTPInfo{},
+		catalog:           catalog.New(nil),
+		neighbors:         network.NewNeighborTable(),
+		viewport:          viewport.New(),
 		isViewportVisible: false,
-		Keys:              TableKeyMap,
 		offsetX:           10,
 		offsetY:           6,
 	}
+	m.ApplyTheme()
+	m.SetVisibleColumns(DefaultColumns)
+
+	return m
+}
+
+// ApplyTheme applies the common styles and key bindings, after the config changed them
+func (m *Model) ApplyTheme() {
+	var styles = &common.DefaultStyles
+
+	m.Keys = newKeyMap()
+	m.table.Keys = m.Keys.KeyMap
+	m.table.Styles.Base = styles.Table.Base
+	m.table.Styles.Header = styles.Table.Header
+	m.table.Styles.Row = styles.Table.Row
+	m.table.Styles.RowCell = styles.Table.RowCell
+	m.table.Styles.Selected = styles.Table.Selected
+	m.table.Styles.Marked = styles.Table.Marked
+	m.table.Styles.FilterMatch = styles.Table.FilterMatch
+	m.table.Styles.FilterInputFocused = styles.Table.FilterInputFocused
+	m.table.Styles.FilterInputBlurred = styles.Table.FilterInputBlurred
+	m.table.Styles.Footer = styles.Table.Footer
+	m.viewport.Style = styles.Viewport.Base
 }
 
 // UpdateColumns updates the column definitions
@@ -89,86 +105,214 @@ func (m *Model) UpdateColumns(columns []table.Column) {
 	m.table = m.table.WithColumns(columns)
 }
 
+// SetVisibleColumns shows only the given columns in the given order (default columns if empty)
+func (m *Model) SetVisibleColumns(keys []string) {
+	if len(keys) == 0 {
+		keys = DefaultColumns
+	}
+	m.columns = []table.Column{}
+	for _, key := range keys {
+		if column, ok := m.findColumn(key); ok {
+			m.columns = append(m.columns, column)
+		}
+	}
+	m.applySort()
+}
+
+// GetVisibleColumns returns the keys of the visible columns
+func (m *Model) GetVisibleColumns() []string {
+	keys := []string{}
+	for _, column := range m.columns {
+		keys = append(keys, column.Key())
+	}
+	return keys
+}
+
+// Columns returns the visible columns
+func (m *Model) Columns() []table.Column {
+	return m.columns
+}
+
+// Rows returns the visible rows, filtered and sorted as displayed
+func (m *Model) Rows() []table.Row {
+	return m.table.Rows()
+}
+
+// SetFilter applies a filter to the table
+func (m *Model) SetFilter(filter string) {
+	m.table = m.table.WithFilterText(filter)
+}
+
+// GetFilter returns the current filter
+func (m *Model) GetFilter() string {
+	return m.table.FilterText()
+}
+
+// SetCatalog sets the catalog describing service types
+func (m *Model) SetCatalog(c *catalog.Catalog) {
+	m.catalog = c
+	m.SetRows(m.entries)
+}
+
 // SetRows sets the table rows from network service entries
-func (m *Model) SetRows(entries []network.ServiceEntry) {
+func (m *Model) SetRows(entries []network.Entry) {
+	m.entries = entries
 	rows := m.generateRowsFromData(entries)
+	m.addTxtColumns(rows)
 	m.table = m.table.WithRows(rows)
 	m.applySort()
 }
 
 // generateRowsFromData converts network entries to table rows
-func (m *Model) generateRowsFromData(data []network.ServiceEntry) []table.Row {
+func (m *Model) generateRowsFromData(data []network.Entry) []table.Row {
 	rows := []table.Row{}
+	if m.baseline != nil {
+		data = m.compareToBaseline(data)
+	}
+	devices := m.identifyDevices(data)
 
 	for _, entry := range data {
+		if m.hidden[entry.ID()] {
+			continue
+		}
 		name := strings.Split(entry.Name, ".")
-		row := table.NewRow(table.RowData{
-			"name":     unescapeString(name[0]),
-			"service":  name[1][1:],
-			"protocol": name[2][1:],
-			"domain":   name[3],
-			"hostname": entry.Host,
-			"ip":       entry.AddrV4,
-			"port":     entry.Port,
-			"info":     unescapeString(entry.Info),
-		})
-
-		rows = append(rows, row)
+		rowData := table.RowData{
+			"name":        entry.Instance(),
+			"service":     name[1][1:],
+			"description": m.describeService(name[1]),
+			"protocol":    name[2][1:],
+			"domain":      name[3],
+			"hostname":    entry.Host,
+			"ip":          ipValue(entry.AddrV4),
+			"port":        entry.Port,
+			"info":        network.Unescape(entry.Info),
+			"ipv6":        ipValue(entry.AddrV6),
+			"interface":   entry.Interface,
+			"mac":         macValue(network.LookupMAC(m.neighbors, entry)),
+			"status":      m.statusValue(entry),
+			"diff":        m.diffValue(entry),
+			"vendor":      devices[entry.Host].Vendor,
+			"model":       devices[entry.Host].Model,
+			"lastseen":    Timestamp(entry.LastSeen),
+			"ttl":         TTL(entry.TTL),
+		}
+		for key, value := range parseTxt(entry) {
+			rowData[TXT_COLUMN_PREFIX+key] = value
+		}
+
+		rows = append(rows, table.NewRow(rowData).WithID(entry.ID()))
 	}
 
 	return rows
 }
 
-// NextSort advances the sort direction and applies sorting
+// SortKey is a column and direction in the sort stack
+type SortKey struct {
+	Column    string
+	Direction int
+}
+
+// NextSort sorts by a single column, advancing its direction if it was already the only sorted column
 func (m *Model) NextSort(columnKey string) {
-	if m.sortedColumnKey == columnKey {
-		m.sortedDirection++
-		if m.sortedDirection > SortedDesc {
-			m.sortedDirection = SortedNone
+	if len(m.sortStack) == 1 && m.sortStack[0].Column == columnKey {
+		m.sortStack[0].Direction++
+		if m.sortStack[0].Direction > SortedDesc {
+			m.sortStack = []SortKey{}
 		}
 	} else {
-		m.sortedColumnKey = columnKey
-		m.sortedDirection = SortedAsc
+		m.sortStack = []SortKey{{Column: columnKey, Direction: SortedAsc}}
 	}
 	m.applySort()
 }
 
+// NextSecondarySort adds a column to the end of the sort stack, or advances its direction if
+// already in the stack (removing it after descending)
+func (m *Model) NextSecondarySort(columnKey string) {
+	idx := slices.IndexFunc(m.sortStack, func(k SortKey) bool { return k.Column == columnKey })
+	if idx < 0 {
+		m.sortStack = append(m.sortStack, SortKey{Column: columnKey, Direction: SortedAsc})
+	} else {
+		m.sortStack[idx].Direction++
+		if m.sortStack[idx].Direction > SortedDesc {
+			m.sortStack = slices.Delete(m.sortStack, idx, idx+1)
+		}
+	}
+	m.applySort()
+}
+
+// SetSort sorts by a single column in the given direction
+func (m *Model) SetSort(columnKey string, direction int) {
+	m.SetSortStack([]SortKey{{Column: columnKey, Direction: direction}})
+}
+
+// SetSortStack sorts by multiple columns, the first key having the highest priority
+func (m *Model) SetSortStack(stack []SortKey) {
+	m.sortStack = []SortKey{}
+	for _, k := range stack {
+		if k.Column != "" && k.Direction != SortedNone {
+			m.sortStack = append(m.sortStack, k)
+		}
+	}
+	m.applySort()
+}
+
+// GetSortStack returns the columns the table is sorted by, by order of priority
+func (m *Model) GetSortStack() []SortKey {
+	return slices.Clone(m.sortStack)
+}
+
 // applySort applies the current sort to the table
 func (m *Model) applySort() {
-	if m.sortedDirection == SortedAsc {
-		m.table = m.table.SortByAsc(m.sortedColumnKey)
-	} else if m.sortedDirection == SortedDesc {
-		m.table = m.table.SortByDesc(m.sortedColumnKey)
-	} else {
-		m.table = m.table.SortByAsc("")
+	keys := []table.SortKey{}
+	for _, k := range m.sortStack {
+		keys = append(keys, table.SortKey{Column: k.Column, Ascending: k.Direction == SortedAsc})
 	}
+	m.table = m.table.SortBy(keys)
 
-	// Update column headers with sort indicators
+	// Update column headers with sort indicators (and priority if sorting by multiple columns)
 	newColumns := slices.Clone(m.columns)
-	for idx, column := range m.columns {
-		if column.Key() == m.sortedColumnKey {
-			title := column.Title()
-			if m.sortedDirection == SortedAsc {
-				title = fmt.Sprintf("%s ▼", title)
-			} else if m.sortedDirection == SortedDesc {
-				title = fmt.Sprintf("%s ▲", title)
-			}
+	for idx, column := range newColumns {
+		priority := slices.IndexFunc(m.sortStack, func(k SortKey) bool { return k.Column == column.Key() })
+		if priority < 0 {
+			continue
+		}
+
+		var indicator string
+		if m.sortStack[priority].Direction == SortedAsc {
+			indicator = "▼"
+		} else {
+			indicator = "▲"
+		}
+		if len(m.sortStack) > 1 {
+			indicator = fmt.Sprintf("%s%d", indicator, priority+1)
+		}
 
-			newColumns[idx] = column.WithTitle(title)
-			break
+		newColumns[idx] = column.WithTitle(fmt.Sprintf("%s %s", column.Title(), indicator))
+	}
+
+	// Hidden columns are still passed to the table for filtering and sorting
+	for _, column := range m.allColumns {
+		if !slices.ContainsFunc(m.columns, func(c table.Column) bool { return c.Key() == column.Key() }) {
+			newColumns = append(newColumns, column.WithHidden(true))
 		}
 	}
 	m.table = m.table.WithColumns(newColumns)
 }
 
-// GetSortedColumn returns the currently sorted column key
+// GetSortedColumn returns the primary sorted column key
 func (m *Model) GetSortedColumn() string {
-	return m.sortedColumnKey
+	if len(m.sortStack) == 0 {
+		return ""
+	}
+	return m.sortStack[0].Column
 }
 
-// GetSortedDirection returns the current sort direction
+// GetSortedDirection returns the primary sort direction
 func (m *Model) GetSortedDirection() int {
-	return m.sortedDirection
+	if len(m.sortStack) == 0 {
+		return SortedNone
+	}
+	return m.sortStack[0].Direction
 }
 
 // IsFilterInputFocused returns whether the filter input is focused
@@ -200,12 +344,16 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 				m.table, cmd = m.table.Update(msg)
 				return cmd
 			}
-			
+
 			switch {
 			case key.Matches(msg, m.Keys.Select) && !m.IsFilterInputFocused():
 				m.isViewportVisible = true
 				m.table.Focus(false)
 				m.viewport.SetContent(m.renderSelectedRow())
+			case key.Matches(msg, m.Keys.Hide):
+				m.HideTargets()
+			case key.Matches(msg, m.Keys.Unhide):
+				m.ShowHidden()
 			case key.Matches(msg, m.Keys.SortName):
 				m.NextSort("name")
 			case key.Matches(msg, m.Keys.SortService):
@@ -218,6 +366,18 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 				m.NextSort("ip")
 			case key.Matches(msg, m.Keys.SortPort):
 				m.NextSort("port")
+			case key.Matches(msg, m.Keys.SortAddName):
+				m.NextSecondarySort("name")
+			case key.Matches(msg, m.Keys.SortAddService):
+				m.NextSecondarySort("service")
+			case key.Matches(msg, m.Keys.SortAddDomain):
+				m.NextSecondarySort("domain")
+			case key.Matches(msg, m.Keys.SortAddHostname):
+				m.NextSecondarySort("hostname")
+			case key.Matches(msg, m.Keys.SortAddIp):
+				m.NextSecondarySort("ip")
+			case key.Matches(msg, m.Keys.SortAddPort):
+				m.NextSecondarySort("port")
 			default:
 				m.table, cmd = m.table.Update(msg)
 				return cmd
@@ -227,6 +387,8 @@ func (m *Model) Update(msg tea.Msg) tea.Cmd {
 			case key.Matches(msg, m.Keys.Close), key.Matches(msg, m.Keys.Select):
 				m.isViewportVisible = false
 				m.table.Focus(true)
+			default: // scroll long details
+				m.viewport, cmd = m.viewport.Update(msg)
 			}
 		}
 	case table.RowSelectedMsg:
@@ -270,11 +432,12 @@ func (m *Model) renderSelectedRow() string {
 	s := &common.DefaultStyles
 	row := m.table.SelectedRow()
 
-	caser := cases.Title(language.English)
-
 	var lines []string
-	for _, col := range m.columns {
+	for _, col := range m.allColumns {
 		key := col.Key()
+		if strings.HasPrefix(key, TXT_COLUMN_PREFIX) { // already shown in info
+			continue
+		}
 		value := row.GetString(key)
 
 		if key == "info" {
@@ -282,9 +445,9 @@ func (m *Model) renderSelectedRow() string {
 			if len(infos) > 1 {
 				parsed := []string{""}
 				for _, info := range infos {
-					split := strings.Split(info, "=")
-					subkey := s.Viewport.Label.Foreground(s.Color.Bottom).Render(split[0] + ":")
-					subval := s.Viewport.Value.Render(split[1])
+					k, v, _ := strings.Cut(info, "=")
+					subkey := s.Viewport.Label.Foreground(s.Color.Bottom).Render(k + ":")
+					subval := s.Viewport.Value.Render(v)
 					subline := lg.JoinHorizontal(lg.Left, subkey, subval)
 					parsed = append(parsed, subline)
 				}
@@ -292,19 +455,48 @@ func (m *Model) renderSelectedRow() string {
 			}
 		}
 
-		label := s.Viewport.Label.Width(15).Render(caser.String(key) + ":")
+		label := s.Viewport.Label.Width(15).Render(col.Title() + ":")
 		val := s.Viewport.Value.Render(value)
 		line := lg.JoinHorizontal(lg.Left, label, val)
 		lines = append(lines, line)
 	}
 
+	// Decoded TXT attributes of well-known service types
+	if entry, ok := m.Entry(row); ok {
+		fields := txt.Decode(entry.ServiceType(), entry.TXT())
+		if len(fields) > 0 {
+			label := s.Viewport.Label.Width(15).Render("Decoded:")
+			parsed := []string{""}
+			for _, field := range fields {
+				subkey := s.Viewport.Label.Foreground(s.Color.Bottom).Render(field.Label + ":")
+				subval := s.Viewport.Value.Render(field.Value)
+				parsed = append(parsed, lg.JoinHorizontal(lg.Left, subkey, subval))
+			}
+			val := s.Viewport.Value.Render(lg.JoinVertical(lg.Left, parsed...))
+			lines = append(lines, lg.JoinHorizontal(lg.Left, label, val))
+		}
+
+		// Metadata of web services
+		if info, ok := m.HTTPInfo(entry); ok {
+			label := s.Viewport.Label.Width(15).Render("HTTP:")
+			parsed := []string{""}
+			for _, field := range httpInfoFields(info) {
+				subkey := s.Viewport.Label.Foreground(s.Color.Bottom).Render(field[0] + ":")
+				subval := s.Viewport.Value.Render(field[1])
+				parsed = append(parsed, lg.JoinHorizontal(lg.Left, subkey, subval))
+			}
+			val := s.Viewport.Value.Render(lg.JoinVertical(lg.Left, parsed...))
+			lines = append(lines, lg.JoinHorizontal(lg.Left, label, val))
+		}
+	}
+
 	return lg.JoinVertical(lg.Left, lines...)
 }
 
 // SortIPs is a special sort function to sort the net.IP of the "ip" column
 func SortIPs(a, b interface{}) int {
-	ipA := a.(net.IP)
-	ipB := b.(net.IP)
+	ipA, _ := a.(net.IP) // nil if no IP
+	ipB, _ := b.(net.IP)
 
 	for i := 0; i < len(ipA) && i < len(ipB); i++ {
 		if ipA[i] > ipB[i] {
@@ -313,37 +505,5 @@ func SortIPs(a, b interface{}) int {
 			return -1
 		}
 	}
-	return 0
-}
-
-// unescapeString handles escaped characters in mDNS service names
-func unescapeString(s string) string {
-	var buf strings.Builder
-	for i := 0; i < len(s); i++ {
-		if s[i] == '\\' && i+1 < len(s) {
-			next := s[i+1]
-			// Check if next char is a digit (for decimal byte values)
-			if next >= '0' && next <= '9' {
-				start := i + 1
-				end := start
-				for end < len(s) && s[end] >= '0' && s[end] <= '9' {
-					end++
-				}
-				// Parse decimal value and convert to byte
-				val := 0
-				for j := start; j < end; j++ {
-					val = val*10 + int(s[j]-'0')
-				}
-				buf.WriteByte(byte(val))
-				i = end - 1
-			} else {
-				// Any other escaped character - output it literally
-				buf.WriteByte(next)
-				i++
-			}
-		} else {
-			buf.WriteByte(s[i])
-		}
-	}
-	return buf.String()
+	return cmp.Compare(len(ipA), len(ipB))
 }
@@ -0,0 +1,70 @@
+package table
+
+import (
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+	"gitlab.com/patopest/mdns-discovery/probe"
+)
+
+// SetProbeResult sets the reachability of an entry, shown in the status column
+func (m *Model) SetProbeResult(id string, result probe.Result) {
+	m.probes[id] = result
+	m.SetRows(m.entries)
+	m.refreshDetails()
+}
+
+// ResetProbing clears the state of the entries still being probed (ex: when cancelled)
+func (m *Model) ResetProbing() {
+	for id, result := range m.probes {
+		if result.State == probe.STATE_PROBING {
+			delete(m.probes, id)
+		}
+	}
+	m.SetRows(m.entries)
+}
+
+// statusValue returns the value of the status column of an entry
+func (m *Model) statusValue(entry network.Entry) string {
+	return m.probes[entry.ID()].String()
+}
+
+// SetHTTPInfo sets the HTTP metadata of an entry, shown in the detail view
+func (m *Model) SetHTTPInfo(id string, info probe.HTTPInfo) {
+	m.httpInfo[id] = info
+	m.refreshDetails()
+}
+
+// refreshDetails renders the detail view again if it is open
+func (m *Model) refreshDetails() {
+	if m.isViewportVisible {
+		m.viewport.SetContent(m.renderSelectedRow())
+	}
+}
+
+// HTTPInfo returns the HTTP metadata of an entry, if it was fetched
+func (m *Model) HTTPInfo(entry network.Entry) (probe.HTTPInfo, bool) {
+	info, ok := m.httpInfo[entry.ID()]
+	return info, ok
+}
+
+// httpInfoFields returns the HTTP metadata as label and value pairs for the detail view
+func httpInfoFields(info probe.HTTPInfo) [][2]string {
+	fields := [][2]string{{"URL", info.URL}}
+	add := func(label, value string) {
+		if value != "" {
+			fields = append(fields, [2]string{label, value})
+		}
+	}
+	add("Error", info.Error)
+	add("Status", info.Status)
+	add("Location", info.Location)
+	add("Server", info.Server)
+	add("Title", info.Title)
+	if info.Cert != nil {
+		add("Certificate", info.Cert.Subject)
+		add("Issuer", info.Cert.Issuer)
+		add("Expires", info.Cert.NotAfter.Format(time.DateOnly))
+	}
+	return fields
+}
@@ -16,6 +16,8 @@ type Column struct {
 	flex       int
 	isFlex     bool
 	filterable bool
+	hidden     bool
+	wrap       bool
 	sortFunc   SortFunc
 	style      lg.Style
 	isStyled   bool
@@ -24,10 +26,10 @@ type Column struct {
 // NewColumn creates a new fixed-width column
 func NewColumn(key, title string, width int) Column {
 	return Column{
-		key:    key,
-		title:  title,
-		width:  width,
-		isFlex: false,
+		key:      key,
+		title:    title,
+		width:    width,
+		isFlex:   false,
 		sortFunc: defaultSortFunc,
 	}
 }
@@ -35,10 +37,10 @@ func NewColumn(key, title string, width int) Column {
 // NewFlexColumn creates a new flexible-width column
 func NewFlexColumn(key, title string, flex int) Column {
 	return Column{
-		key:    key,
-		title:  title,
-		flex:   flex,
-		isFlex: true,
+		key:      key,
+		title:    title,
+		flex:     flex,
+		isFlex:   true,
 		sortFunc: defaultSortFunc,
 	}
 }
@@ -49,12 +51,36 @@ func (c Column) WithFiltering(filterable bool) Column {
 	return c
 }
 
+// WithHidden hides the column from rendering, it is still used for filtering and sorting
+func (c Column) WithHidden(hidden bool) Column {
+	c.hidden = hidden
+	return c
+}
+
+// WithWrap wraps the cells of this column over several lines instead of truncating them
+func (c Column) WithWrap(wrap bool) Column {
+	c.wrap = wrap
+	return c
+}
+
 // WithTitle updates the column title
 func (c Column) WithTitle(title string) Column {
 	c.title = title
 	return c
 }
 
+// WithWidth sets the width of a fixed-width column
+func (c Column) WithWidth(width int) Column {
+	c.width = width
+	return c
+}
+
+// WithFlexFactor sets the flex factor of a flexible-width column
+func (c Column) WithFlexFactor(flex int) Column {
+	c.flex = flex
+	return c
+}
+
 // WithSortFunc sets a custom sort function for this column
 func (c Column) WithSortFunc(fn SortFunc) Column {
 	c.sortFunc = fn
@@ -98,6 +124,16 @@ func (c Column) IsFilterable() bool {
 	return c.filterable
 }
 
+// IsHidden returns true if this column is not rendered
+func (c Column) IsHidden() bool {
+	return c.hidden
+}
+
+// IsWrapped returns true if the cells of this column wrap over several lines
+func (c Column) IsWrapped() bool {
+	return c.wrap
+}
+
 // GetSortFunc returns the custom sort function for this column (may be nil)
 func (c Column) GetSortFunc() SortFunc {
 	return c.sortFunc
@@ -8,6 +8,12 @@ type RowSelectedMsg struct {
 	Index int
 }
 
+// RowsMarkedMsg is sent when rows are marked or unmarked with the KeyMap.Mark, KeyMap.MarkRange and KeyMap.MarkInvert keys
+type RowsMarkedMsg struct {
+	// The number of visible marked rows
+	Count int
+}
+
 // FilterInputFocusedMsg is sent when the filter input is put into focus with the KeyMap.Filter key
 type FilterInputFocusedMsg struct{}
 
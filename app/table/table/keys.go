@@ -10,6 +10,10 @@ type KeyMap struct {
 	Left  key.Binding
 	Right key.Binding
 
+	Mark       key.Binding
+	MarkRange  key.Binding
+	MarkInvert key.Binding
+
 	Filter      key.Binding
 	FilterBlur  key.Binding
 	FilterClear key.Binding
@@ -25,7 +29,8 @@ func (m Model) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{m.Keys.Up, m.Keys.Down},    // first column
 		{m.Keys.Left, m.Keys.Right}, // second column
-		{m.Keys.Filter},             // ...
+		{m.Keys.Mark, m.Keys.MarkRange, m.Keys.MarkInvert},
+		{m.Keys.Filter}, // ...
 	}
 }
 
@@ -47,6 +52,19 @@ var DefaultKeyMap = KeyMap{
 		key.WithHelp("right/l", "scroll right"),
 	),
 
+	Mark: key.NewBinding(
+		key.WithKeys("space"),
+		key.WithHelp("space", "mark"),
+	),
+	MarkRange: key.NewBinding(
+		key.WithKeys("V"),
+		key.WithHelp("V", "mark range"),
+	),
+	MarkInvert: key.NewBinding(
+		key.WithKeys("*"),
+		key.WithHelp("*", "invert marks"),
+	),
+
 	Filter: key.NewBinding(
 		key.WithKeys("/"),
 		key.WithHelp("/", "filter"),
@@ -1,6 +1,7 @@
 package table
 
 import (
+	"fmt"
 	"slices"
 	"strings"
 
@@ -18,14 +19,21 @@ type Model struct {
 	allRows []Row // stores all rows for filtering
 
 	// Display
-	width       int
-	height      int
-	innerHeight int // height available to rows (after header and footer)
+	width         int
+	height        int
+	innerHeight   int             // height available to rows (after header and footer)
+	displayed     []int           // indices of the columns rendered (not hidden nor scrolled out)
+	frozenColumns int             // number of leftmost columns kept in place when scrolling horizontally
+	columnOffset  int             // number of columns scrolled out to the left (after the frozen ones)
+	heights       []int           // lines taken by each row, nil if no displayed column wraps its cells
+	cellHeights   map[cellKey]int // of the wrapped cells rendered, to only render the new ones when the rows change
 
 	// Control
 	Keys   KeyMap
 	Styles Styles
 
+	rowStyle func(Row) lg.Style // additional style of each row, nil for none
+
 	// Focus and state
 	focused            bool
 	cursor             int // selected row index
@@ -39,8 +47,25 @@ type Model struct {
 	filterText       string
 
 	// Sorting
-	sortColumn string
-	sortAsc    bool
+	sortKeys []SortKey // by order of priority
+
+	// Marking
+	marked       map[string]bool // IDs of the marked rows
+	rangeMarking bool            // whether a range is being marked (from rangeAnchor to the cursor)
+	rangeAnchor  string          // ID of the row where the range marking started
+}
+
+// cellKey identifies the rendering of a wrapped cell, whose height is cached
+type cellKey struct {
+	column string
+	width  int
+	value  string
+}
+
+// SortKey defines the sorting of a single column
+type SortKey struct {
+	Column    string
+	Ascending bool
 }
 
 // New creates a new table model with the given columns
@@ -63,8 +88,8 @@ func New(columns []Column) Model {
 		filterInput:      ti,
 		filteringEnabled: false,
 		filterText:       "",
-		sortColumn:       "",
-		sortAsc:          true,
+		sortKeys:         []SortKey{},
+		marked:           map[string]bool{},
 	}
 }
 
@@ -88,6 +113,20 @@ func (m Model) WithColumns(columns []Column) Model {
 	return m
 }
 
+// WithFrozenColumns keeps the n leftmost columns in place when scrolling horizontally
+func (m Model) WithFrozenColumns(n int) Model {
+	m.frozenColumns = n
+	m.calculateColumnWidths()
+	return m
+}
+
+// WithRowStyleFunc sets a function returning an additional style for each row, applied before the selected
+// and marked styles
+func (m Model) WithRowStyleFunc(f func(Row) lg.Style) Model {
+	m.rowStyle = f
+	return m
+}
+
 // WithMinimumHeight sets the minimum height (used for layout)
 func (m Model) WithMinimumHeight(height int) Model {
 	m.height = height
@@ -125,6 +164,19 @@ func (m Model) IsFilterInputFocused() bool {
 	return m.filterInputFocused
 }
 
+// FilterText returns the current filter text
+func (m Model) FilterText() string {
+	return m.filterText
+}
+
+// WithFilterText sets the filter text as if it was typed in the filter input
+func (m Model) WithFilterText(text string) Model {
+	m.filterText = text
+	m.filterInput.SetValue(text)
+	m.applyFilterAndSort()
+	return m
+}
+
 // TotalRowCount returns the total number of rows without filtering
 func (m Model) TotalRowCount() int {
 	return len(m.allRows)
@@ -132,7 +184,7 @@ func (m Model) TotalRowCount() int {
 
 // VisibleRowCount returns the number of rows taking filtering into account
 func (m Model) VisibleRowCount() int {
-	return len(m.allRows)
+	return len(m.rows)
 }
 
 // SelectedIndex returns the index of the currently selected row
@@ -148,18 +200,129 @@ func (m Model) SelectedRow() Row {
 	return Row{}
 }
 
+// Rows returns the rows as displayed, with filtering and sorting applied
+func (m Model) Rows() []Row {
+	return m.rows
+}
+
+// MarkedRows returns the visible marked rows, in display order
+func (m Model) MarkedRows() []Row {
+	rows := []Row{}
+	for _, row := range m.rows {
+		if m.marked[row.ID] {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// IsMarked returns whether a row is marked (or in the range being marked)
+func (m Model) IsMarked(row Row) bool {
+	if row.ID == "" {
+		return false
+	}
+	if m.marked[row.ID] {
+		return true
+	}
+	if m.rangeMarking {
+		start, end := m.markingRange()
+		for i := start; i <= end; i++ {
+			if m.rows[i].ID == row.ID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsRangeMarking returns whether a range of rows is being marked
+func (m Model) IsRangeMarking() bool {
+	return m.rangeMarking
+}
+
+// ClearMarks unmarks all rows
+func (m *Model) ClearMarks() {
+	m.marked = map[string]bool{}
+	m.rangeMarking = false
+}
+
+// markingRange returns the indices of the first and last rows of the range being marked
+func (m *Model) markingRange() (int, int) {
+	anchor := slices.IndexFunc(m.rows, func(r Row) bool { return r.ID == m.rangeAnchor })
+	if anchor < 0 { // anchor row filtered out or gone
+		anchor = m.cursor
+	}
+	return min(anchor, m.cursor), max(anchor, m.cursor)
+}
+
+// toggleMark marks or unmarks the selected row
+func (m *Model) toggleMark() {
+	if len(m.rows) == 0 {
+		return
+	}
+	id := m.rows[m.cursor].ID
+	if id == "" {
+		return
+	}
+	if m.marked[id] {
+		delete(m.marked, id)
+	} else {
+		m.marked[id] = true
+	}
+}
+
+// toggleRangeMarking starts marking a range at the cursor, or marks all the rows of the current range
+func (m *Model) toggleRangeMarking() {
+	if len(m.rows) == 0 {
+		return
+	}
+	if !m.rangeMarking {
+		m.rangeMarking = true
+		m.rangeAnchor = m.rows[m.cursor].ID
+		return
+	}
+	start, end := m.markingRange()
+	for i := start; i <= end; i++ {
+		if id := m.rows[i].ID; id != "" {
+			m.marked[id] = true
+		}
+	}
+	m.rangeMarking = false
+}
+
+// invertMarks marks all the visible unmarked rows and unmarks the marked ones
+func (m *Model) invertMarks() {
+	for _, row := range m.rows {
+		if row.ID == "" {
+			continue
+		}
+		if m.marked[row.ID] {
+			delete(m.marked, row.ID)
+		} else {
+			m.marked[row.ID] = true
+		}
+	}
+}
+
 // SortByAsc sorts by a column in ascending order
 func (m Model) SortByAsc(column string) Model {
-	m.sortColumn = column
-	m.sortAsc = true
-	m.applyFilterAndSort()
-	return m
+	return m.SortBy([]SortKey{{Column: column, Ascending: true}})
 }
 
 // SortByDesc sorts by a column in descending order
 func (m Model) SortByDesc(column string) Model {
-	m.sortColumn = column
-	m.sortAsc = false
+	return m.SortBy([]SortKey{{Column: column, Ascending: false}})
+}
+
+// SortBy sorts by multiple columns, the first key having the highest priority.
+// Rows comparing equal on all keys keep their original order.
+func (m Model) SortBy(keys []SortKey) Model {
+	m.sortKeys = []SortKey{}
+	for _, k := range keys {
+		if k.Column != "" {
+			m.sortKeys = append(m.sortKeys, k)
+		}
+	}
 	m.applyFilterAndSort()
 	return m
 }
@@ -178,31 +341,39 @@ func (m *Model) applyFilterAndSort() {
 	}
 
 	// Then sort
-	if m.sortColumn != "" {
-		var sortFunc SortFunc
-		for _, col := range m.columns {
-			if col.Key() == m.sortColumn {
-				sortFunc = col.GetSortFunc()
-				break
+	if len(m.sortKeys) > 0 {
+		sortFuncs := make([]SortFunc, len(m.sortKeys))
+		for i, sortKey := range m.sortKeys {
+			for _, col := range m.columns {
+				if col.Key() == sortKey.Column {
+					sortFuncs[i] = col.GetSortFunc()
+					break
+				}
+			}
+			if sortFuncs[i] == nil { // unknown column
+				sortFuncs[i] = defaultSortFunc
 			}
 		}
 
 		slices.SortStableFunc(filtered, func(a, b Row) int {
-			valA := a.Data[m.sortColumn]
-			valB := b.Data[m.sortColumn]
-			cmp := sortFunc(valA, valB)
-			// Reverse if descending
-			if !m.sortAsc {
-				cmp = -cmp
+			for i, sortKey := range m.sortKeys {
+				valA := a.Data[sortKey.Column]
+				valB := b.Data[sortKey.Column]
+				cmp := sortFuncs[i](valA, valB)
+				// Reverse if descending
+				if !sortKey.Ascending {
+					cmp = -cmp
+				}
+				if cmp != 0 {
+					return cmp
+				}
 			}
-			return cmp
+			return 0
 		})
 	}
 
 	m.rows = filtered
-
-	// Reset scroll offset when filtering
-	m.scrollOffset = 0
+	m.updateRowHeights()
 
 	// Ensure cursor is valid
 	if m.cursor >= len(m.rows) && len(m.rows) > 0 {
@@ -210,6 +381,9 @@ func (m *Model) applyFilterAndSort() {
 	} else if len(m.rows) == 0 {
 		m.cursor = 0
 	}
+
+	// Keep the cursor visible (rows are refreshed periodically, don't reset the scroll)
+	m.updateScrollOffset()
 }
 
 // filterRows filters rows based on the search text
@@ -249,28 +423,94 @@ func (m *Model) filterRows(rows []Row, search string) []Row {
 
 // updateScrollOffset adjusts scrollOffset to ensure cursor is visible
 func (m *Model) updateScrollOffset() {
-	if m.innerHeight > 0 && len(m.rows) > m.innerHeight {
-		// Cursor below visible area - scroll down
-		if m.cursor >= m.scrollOffset+m.innerHeight {
-			m.scrollOffset = m.cursor - m.innerHeight + 1
-		}
+	if m.innerHeight > 0 && m.rowsHeight(0, len(m.rows)) > m.innerHeight {
 		// Cursor above visible area - scroll up
 		if m.cursor < m.scrollOffset {
 			m.scrollOffset = m.cursor
 		}
-		// Clamp scrollOffset to valid bounds
-		maxOffset := len(m.rows) - m.innerHeight
-		if m.scrollOffset > maxOffset {
-			m.scrollOffset = maxOffset
+		// Cursor below visible area - scroll down
+		for m.scrollOffset < m.cursor && m.rowsHeight(m.scrollOffset, m.cursor+1) > m.innerHeight {
+			m.scrollOffset++
 		}
-		if m.scrollOffset < 0 {
-			m.scrollOffset = 0
+		// Clamp scrollOffset so that the last rows fill the visible area
+		for m.scrollOffset > 0 && m.rowsHeight(m.scrollOffset-1, len(m.rows)) <= m.innerHeight {
+			m.scrollOffset--
 		}
 	} else {
 		m.scrollOffset = 0
 	}
 }
 
+// rowsHeight returns the number of lines taken by the rows in [start, end)
+func (m *Model) rowsHeight(start, end int) int {
+	if m.heights == nil {
+		return end - start
+	}
+	height := 0
+	for _, h := range m.heights[start:end] {
+		height += h
+	}
+	return height
+}
+
+// rowHeight returns the number of lines taken by the row at index (more than 1 if it has wrapped cells)
+func (m *Model) rowHeight(index int) int {
+	if index >= len(m.heights) {
+		return 1
+	}
+	return m.heights[index]
+}
+
+// updateRowHeights computes the lines taken by each row, only rendering the wrapped cells not rendered before with
+// the same width. Must be called when the rows or the columns change.
+func (m *Model) updateRowHeights() {
+	m.heights = nil
+	if !m.hasWrappedColumns() {
+		m.cellHeights = nil
+		return
+	}
+
+	// A new map (the previous one may be shared by a copy of the model), only keeping the cells still shown
+	cellHeights := make(map[cellKey]int, len(m.cellHeights))
+	m.heights = make([]int, len(m.rows))
+	for r, row := range m.rows {
+		height := 1
+		for _, i := range m.displayed {
+			col := m.columns[i]
+			if !col.wrap {
+				continue
+			}
+			key := cellKey{column: col.Key(), width: col.width, value: row.GetString(col.Key())}
+			h, ok := m.cellHeights[key]
+			if !ok {
+				h = lg.Height(m.cellStyle(col).Width(col.width).Render(key.value))
+			}
+			cellHeights[key] = h
+			height = max(height, h)
+		}
+		m.heights[r] = height
+	}
+	m.cellHeights = cellHeights
+}
+
+// hasWrappedColumns returns whether any displayed column wraps its cells
+func (m *Model) hasWrappedColumns() bool {
+	for _, i := range m.displayed {
+		if m.columns[i].wrap {
+			return true
+		}
+	}
+	return false
+}
+
+// cellStyle returns the base style of the cells of a column
+func (m *Model) cellStyle(col Column) lg.Style {
+	if col.isStyled {
+		return col.style
+	}
+	return m.Styles.RowCell
+}
+
 // Init implements tea.Model
 func (m *Model) Init() tea.Cmd {
 	return nil
@@ -325,6 +565,36 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 					cmd = createCmd(RowSelectedMsg{Index: m.cursor})
 					cmds = append(cmds, cmd)
 				}
+			case key.Matches(msg, m.Keys.Left):
+				if m.columnOffset > 0 {
+					m.columnOffset--
+					m.calculateColumnWidths()
+					m.updateScrollOffset()
+				}
+			case key.Matches(msg, m.Keys.Right):
+				if m.columnOffset < m.scrollableColumns()-1 {
+					m.columnOffset++
+					m.calculateColumnWidths()
+					m.updateScrollOffset()
+				}
+			case key.Matches(msg, m.Keys.Mark):
+				m.toggleMark()
+				if m.cursor < len(m.rows)-1 { // move on to the next row to mark several in a row
+					m.cursor++
+					m.updateScrollOffset()
+				}
+				cmd = createCmd(RowsMarkedMsg{Count: len(m.MarkedRows())})
+				cmds = append(cmds, cmd)
+			case key.Matches(msg, m.Keys.MarkRange):
+				m.toggleRangeMarking()
+				cmd = createCmd(RowsMarkedMsg{Count: len(m.MarkedRows())})
+				cmds = append(cmds, cmd)
+			case key.Matches(msg, m.Keys.MarkInvert):
+				m.invertMarks()
+				cmd = createCmd(RowsMarkedMsg{Count: len(m.MarkedRows())})
+				cmds = append(cmds, cmd)
+			case key.Matches(msg, m.Keys.FilterClear) && m.rangeMarking:
+				m.rangeMarking = false
 			case key.Matches(msg, m.Keys.Filter) && m.filteringEnabled:
 				m.filterInputFocused = true
 				m.filterInput.Focus()
@@ -411,9 +681,14 @@ func (m *Model) renderHeader() string {
 	rowstyle := s.Header.Inherit(s.Base.UnsetBorderStyle())
 	cellstyle := s.HeaderCell.Inherit(rowstyle.UnsetBorderStyle())
 
-	for _, col := range m.columns {
+	for idx, i := range m.displayed {
+		col := m.columns[i]
 		width := col.width
-		title := truncate(col.Title(), width, s.HeaderCell)
+		title := col.Title()
+		if m.columnOffset > 0 && idx == m.frozenColumns { // columns scrolled out to the left
+			title = "‹ " + title
+		}
+		title = truncate(title, width, s.HeaderCell)
 		cell := cellstyle.Width(width).Render(title)
 		cells = append(cells, cell)
 	}
@@ -422,6 +697,9 @@ func (m *Model) renderHeader() string {
 }
 
 func (m *Model) isFooterActive() bool {
+	if m.rangeMarking || len(m.MarkedRows()) > 0 {
+		return true
+	}
 	if m.filteringEnabled {
 		return m.filterInputFocused || m.filterText != ""
 	}
@@ -444,35 +722,51 @@ func (m *Model) renderFooter() string {
 		footer = style.Render("/ " + m.filterText)
 	}
 
+	// Marked rows count on the right
+	var status string
+	if m.rangeMarking {
+		start, end := m.markingRange()
+		status = fmt.Sprintf("-- RANGE (%d) -- ", end-start+1)
+	}
+	if count := len(m.MarkedRows()); count > 0 {
+		status += fmt.Sprintf("%d marked", count)
+	}
+	if status != "" {
+		status = s.FilterInputBlurred.Inherit(rowstyle.UnsetBorderStyle()).Render(status)
+		spacer := lg.NewStyle().Width(max(width-lg.Width(footer)-lg.Width(status), 1)).Render("")
+		footer = lg.JoinHorizontal(lg.Top, footer, spacer, status)
+	}
+
 	return rowstyle.Width(width).Render(footer)
 }
 
 // renderRows renders the data rows
 func (m *Model) renderRows() string {
 	var rows []string
+	var lines int
 
-	startIdx := 0
-	endIdx := len(m.rows)
-
-	if m.innerHeight > 0 && len(m.rows) > m.innerHeight {
-		startIdx = m.scrollOffset
-		endIdx = startIdx + m.innerHeight
-		if endIdx > len(m.rows) {
-			endIdx = len(m.rows)
-		}
-	}
-
-	for i := startIdx; i < endIdx; i++ {
+	for i := m.scrollOffset; i < len(m.rows); i++ {
 		row := m.rows[i]
-		rowStr := m.renderRow(row, i == m.cursor)
+		rowStr := m.renderRow(row, m.rowHeight(i), i == m.cursor, m.IsMarked(row))
+
+		height := lg.Height(rowStr)
+		if m.innerHeight > 0 && lines+height > m.innerHeight {
+			// Only show the first lines of the last (wrapped) row
+			if remaining := m.innerHeight - lines; remaining > 0 {
+				rowStr = strings.Join(strings.Split(rowStr, "\n")[:remaining], "\n")
+				rows = append(rows, rowStr)
+			}
+			break
+		}
 		rows = append(rows, rowStr)
+		lines += height
 	}
 
 	return strings.Join(rows, "\n")
 }
 
-// renderRow renders a single row
-func (m *Model) renderRow(row Row, isSelected bool) string {
+// renderRow renders a single row on height lines
+func (m *Model) renderRow(row Row, height int, isSelected bool, isMarked bool) string {
 	var s = &m.Styles
 	var cells []string
 
@@ -480,17 +774,23 @@ func (m *Model) renderRow(row Row, isSelected bool) string {
 	// future margins and paddings found in the cell and column styles.
 	// So we Inherit without Borders to get the style stacking to work
 	rowstyle := s.Row.Inherit(s.Base.UnsetBorderStyle())
+	if m.rowStyle != nil {
+		rowstyle = m.rowStyle(row).Inherit(rowstyle)
+	}
 	if isSelected {
 		rowstyle = s.Selected.Inherit(rowstyle)
 	}
+	if isMarked {
+		rowstyle = s.Marked.Inherit(rowstyle)
+	}
 
-	for _, col := range m.columns {
-		style := s.RowCell.Inherit(rowstyle)
-		if col.isStyled {
-			style = col.style.Inherit(rowstyle)
-		}
+	for _, i := range m.displayed {
+		col := m.columns[i]
+		style := m.cellStyle(col).Inherit(rowstyle).Height(height)
 		value := row.GetString(col.Key())
-		value = truncate(value, col.width, style)
+		if !col.wrap {
+			value = truncate(value, col.width, style)
+		}
 
 		// Check if this cell has a cached filter match
 		if row.MatchCache.HasMatch {
@@ -505,7 +805,7 @@ func (m *Model) renderRow(row Row, isSelected bool) string {
 		cells = append(cells, cell)
 	}
 
-	return rowstyle.Render(strings.Join(cells, ""))
+	return rowstyle.Render(lg.JoinHorizontal(lg.Top, cells...))
 }
 
 // renderRow renders a single empty row (for padding)
@@ -516,7 +816,8 @@ func (m *Model) renderEmptyRows(num int) string {
 
 	rowstyle := lg.NewStyle().Inherit(s.Base.UnsetBorderStyle())
 
-	for _, col := range m.columns {
+	for _, i := range m.displayed {
+		col := m.columns[i]
 		cell := rowstyle.Width(col.width).Render("")
 		cells = append(cells, cell)
 	}
@@ -529,9 +830,39 @@ func (m *Model) renderEmptyRows(num int) string {
 	return strings.Join(rows, "\n")
 }
 
-// calculateColumnWidths calculates widths for each column
+// scrollableColumns returns the number of rendered columns that can be scrolled horizontally
+func (m *Model) scrollableColumns() int {
+	count := 0
+	for _, col := range m.columns {
+		if !col.hidden {
+			count++
+		}
+	}
+	return max(count-m.frozenColumns, 0)
+}
+
+// updateDisplayedColumns computes which columns are rendered based on the horizontal scroll
+func (m *Model) updateDisplayedColumns() {
+	m.columnOffset = max(min(m.columnOffset, m.scrollableColumns()-1), 0)
+
+	m.displayed = []int{}
+	position := 0
+	for i, col := range m.columns {
+		if col.hidden {
+			continue
+		}
+		if position < m.frozenColumns || position >= m.frozenColumns+m.columnOffset {
+			m.displayed = append(m.displayed, i)
+		}
+		position++
+	}
+}
+
+// calculateColumnWidths calculates widths for each displayed column, and the row heights depending on them
 func (m *Model) calculateColumnWidths() {
-	if len(m.columns) == 0 || m.width == 0 {
+	defer m.updateRowHeights()
+	m.updateDisplayedColumns()
+	if len(m.displayed) == 0 || m.width == 0 {
 		return
 	}
 
@@ -542,7 +873,8 @@ func (m *Model) calculateColumnWidths() {
 	totalFlex := 0
 	fixedWidth := 0
 
-	for _, col := range m.columns {
+	for _, i := range m.displayed {
+		col := m.columns[i]
 		if col.IsFlex() {
 			totalFlex += col.FlexFactor()
 		} else {
@@ -550,6 +882,11 @@ func (m *Model) calculateColumnWidths() {
 		}
 	}
 
+	// Nothing to distribute if only fixed columns are shown
+	if totalFlex == 0 {
+		return
+	}
+
 	// Calculate widths
 	remainingWidth := availableWidth - fixedWidth
 	leftOverWidth := remainingWidth % totalFlex
@@ -557,7 +894,8 @@ func (m *Model) calculateColumnWidths() {
 	var totalCalculatedWidth int
 	flexColumnIndices := []int{}
 
-	for i, col := range m.columns {
+	for _, i := range m.displayed {
+		col := m.columns[i]
 		if col.IsFlex() {
 			flexColumnIndices = append(flexColumnIndices, i)
 			width := (col.FlexFactor() * remainingWidth) / totalFlex
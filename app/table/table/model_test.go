@@ -0,0 +1,48 @@
+package table
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRowHeights(t *testing.T) {
+	columns := []Column{
+		NewColumn("name", "Name", 8),
+		NewFlexColumn("info", "Info", 1).WithWrap(true),
+	}
+	rows := []Row{
+		NewRow(RowData{"name": "a", "info": "short"}).WithID("a"),
+		NewRow(RowData{"name": "b", "info": "a much longer text wrapped over several lines"}).WithID("b"),
+		NewRow(RowData{"name": "c", "info": "short"}).WithID("c"),
+	}
+	m := New(columns).WithTargetWidth(40).WithRows(rows)
+
+	if m.heights[0] != 1 || m.heights[2] != 1 || m.heights[1] < 2 {
+		t.Fatalf("heights = %v, want the second row wrapped", m.heights)
+	}
+	if got, want := m.rowsHeight(0, 3), m.heights[1]+2; got != want {
+		t.Errorf("rowsHeight() = %d, want %d", got, want)
+	}
+	if len(m.cellHeights) != 2 {
+		t.Errorf("%d cells cached, want 2 (same text rendered once)", len(m.cellHeights))
+	}
+
+	// Narrower columns wrap more
+	wide := m.heights[1]
+	m = m.WithTargetWidth(30)
+	if m.heights[1] <= wide {
+		t.Errorf("height %d at width 30, want more than %d", m.heights[1], wide)
+	}
+
+	// Only the cells still shown are kept
+	m = m.WithRows(rows[:1])
+	if !slices.Equal(m.heights, []int{1}) || len(m.cellHeights) != 1 {
+		t.Errorf("heights = %v with %d cells cached, want [1] with 1", m.heights, len(m.cellHeights))
+	}
+
+	// No heights without wrapped columns
+	m = m.WithColumns([]Column{columns[0], columns[1].WithWrap(false)})
+	if m.heights != nil || m.cellHeights != nil || m.rowsHeight(0, 1) != 1 {
+		t.Errorf("heights = %v, want nil", m.heights)
+	}
+}
@@ -16,6 +16,7 @@ type MatchInfo struct {
 
 // Row represents a table row
 type Row struct {
+	ID         string // identifies the row across updates (required to mark it)
 	Data       RowData
 	MatchCache MatchInfo // Stores filter match positions for this row
 }
@@ -25,6 +26,12 @@ func NewRow(data RowData) Row {
 	return Row{Data: data}
 }
 
+// WithID sets the row's identifier
+func (r Row) WithID(id string) Row {
+	r.ID = id
+	return r
+}
+
 // Get returns a value from the row by column key
 func (r Row) Get(key string) interface{} {
 	return r.Data[key]
@@ -32,7 +39,7 @@ func (r Row) Get(key string) interface{} {
 
 // GetString returns a string value from the row by column key
 func (r Row) GetString(key string) string {
-	if val, ok := r.Data[key]; ok {
+	if val, ok := r.Data[key]; ok && val != nil {
 		return fmt.Sprintf("%v", val)
 	}
 	return ""
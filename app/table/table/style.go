@@ -19,6 +19,8 @@ type Styles struct {
 	RowCell lg.Style
 	// Additional style applied to the selected row
 	Selected lg.Style
+	// Additional style applied to marked rows
+	Marked lg.Style
 
 	// Style applied to footer
 	Footer lg.Style
@@ -39,6 +41,7 @@ func DefaultStyles() (s Styles) {
 
 	s.Row = lg.NewStyle()
 	s.Selected = lg.NewStyle()
+	s.Marked = lg.NewStyle()
 	s.RowCell = lg.NewStyle()
 
 	s.Footer = lg.NewStyle()
@@ -0,0 +1,68 @@
+package table
+
+import (
+	"strings"
+
+	"gitlab.com/patopest/mdns-discovery/config"
+)
+
+// ApplyLayout applies the saved columns layout (visible columns, order and widths) to the table
+func (m *Model) ApplyLayout(layout []config.Column) {
+	keys := []string{}
+	for _, column := range layout {
+		m.findColumn(column.Key) // creates TXT columns not seen yet
+		if column.Width > 0 {
+			m.SetColumnWidth(column.Key, column.Width)
+		}
+		m.SetColumnWrap(column.Key, column.Wrap)
+		keys = append(keys, column.Key)
+	}
+	m.SetVisibleColumns(keys)
+}
+
+// Layout returns the current columns layout
+func (m *Model) Layout() []config.Column {
+	layout := []config.Column{}
+	for _, column := range m.columns {
+		layout = append(layout, config.Column{Key: column.Key(), Width: ColumnWidth(column), Wrap: column.IsWrapped()})
+	}
+	return layout
+}
+
+// ApplyView applies a saved view's filter, sort and visible columns to the table
+func (m *Model) ApplyView(view config.View) {
+	m.SetVisibleColumns(view.Columns)
+	m.SetFilter(view.Filter)
+
+	stack := []SortKey{}
+	for _, sort := range strings.Split(view.Sort, ",") {
+		sort = strings.TrimSpace(sort)
+		if strings.HasPrefix(sort, "-") {
+			stack = append(stack, SortKey{Column: sort[1:], Direction: SortedDesc})
+		} else {
+			stack = append(stack, SortKey{Column: sort, Direction: SortedAsc})
+		}
+	}
+	m.SetSortStack(stack)
+}
+
+// CurrentView captures the table's filter, sort and visible columns as a named view
+func (m *Model) CurrentView(name string) config.View {
+	view := config.View{
+		Name:    name,
+		Filter:  m.GetFilter(),
+		Columns: m.GetVisibleColumns(),
+	}
+
+	sorts := []string{}
+	for _, k := range m.sortStack {
+		if k.Direction == SortedDesc {
+			sorts = append(sorts, "-"+k.Column)
+		} else {
+			sorts = append(sorts, k.Column)
+		}
+	}
+	view.Sort = strings.Join(sorts, ",")
+
+	return view
+}
@@ -0,0 +1,72 @@
+package views
+
+import (
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/list"
+
+	"gitlab.com/patopest/mdns-discovery/app/common"
+)
+
+type keyMap struct {
+	list.KeyMap
+
+	Up   key.Binding
+	Down key.Binding
+
+	Select key.Binding
+	Save   key.Binding
+	Delete key.Binding
+	Close  key.Binding
+
+	// name input
+	Confirm key.Binding
+	Cancel  key.Binding
+}
+
+// Implements help.KeyMap interface
+func (m *Model) ShortHelp() []key.Binding {
+	if m.isInputFocused {
+		return []key.Binding{m.Keys.Confirm, m.Keys.Cancel}
+	}
+	return []key.Binding{m.Keys.Select, m.Keys.Save, m.Keys.Delete, m.Keys.Close}
+}
+
+// Implements help.KeyMap interface
+func (m *Model) FullHelp() [][]key.Binding {
+	if m.isInputFocused {
+		return [][]key.Binding{{m.Keys.Confirm, m.Keys.Cancel}}
+	}
+	return [][]key.Binding{
+		{m.Keys.Up, m.Keys.Down},     // first column
+		{m.Keys.Select, m.Keys.Save}, // second column
+		{m.Keys.Delete},              // ...
+		{m.Keys.Close},
+	}
+}
+
+// newKeyMap returns the key bindings of the pane, from the common ones (rebound by the config)
+func newKeyMap() keyMap {
+	return keyMap{
+		KeyMap: list.KeyMap{
+			CursorUp:   common.DefaultKeyMap.Up,
+			CursorDown: common.DefaultKeyMap.Down,
+		},
+
+		Up:   common.DefaultKeyMap.Up,
+		Down: common.DefaultKeyMap.Down,
+
+		Select: common.DefaultKeyMap.Select,
+		Save:   common.DefaultKeyMap.SaveView,
+		Delete: common.DefaultKeyMap.DeleteView,
+		Close:  common.DefaultKeyMap.Close,
+
+		Confirm: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "save"),
+		),
+		Cancel: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "cancel"),
+		),
+	}
+}
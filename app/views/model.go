@@ -0,0 +1,237 @@
+package views
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/list"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	lg "charm.land/lipgloss/v2"
+
+	"gitlab.com/patopest/mdns-discovery/app/common"
+	"gitlab.com/patopest/mdns-discovery/config"
+)
+
+// Styles
+type Styles struct {
+	Base  lg.Style
+	Title lg.Style
+	Input lg.Style
+	Empty lg.Style
+
+	Item list.DefaultItemStyles
+}
+
+func NewStyles() (s Styles) {
+	var c = &common.DefaultStyles.Color
+
+	s.Base = lg.NewStyle().
+		Padding(0, 2)
+
+	s.Title = lg.NewStyle().
+		Foreground(c.Mid)
+
+	s.Input = lg.NewStyle().
+		Padding(0, 0, 1, 2).
+		Foreground(lg.Color("255"))
+
+	s.Empty = lg.NewStyle().
+		Padding(0, 0, 0, 2).
+		Foreground(c.Grey50)
+
+	s.Item.NormalTitle = lg.NewStyle().
+		Foreground(c.Text).
+		Padding(0, 0, 0, 2)
+
+	s.Item.NormalDesc = s.Item.NormalTitle.
+		Foreground(lg.Darken(c.Text, 0.5))
+
+	s.Item.SelectedTitle = lg.NewStyle().
+		Border(lg.NormalBorder(), false, false, false, true).
+		BorderForeground(lg.Darken(c.MidLow, 0.30)).
+		Foreground(c.MidLow).
+		Padding(0, 0, 0, 1)
+
+	s.Item.SelectedDesc = s.Item.SelectedTitle.
+		Foreground(lg.Darken(c.MidLow, 0.30))
+
+	s.Item.DimmedTitle = s.Item.NormalTitle
+	s.Item.DimmedDesc = s.Item.NormalDesc
+	s.Item.FilterMatch = lg.NewStyle()
+
+	return s
+}
+
+// Item represents a saved view in the list
+type Item struct {
+	view config.View
+}
+
+// Implements list.Item interface
+func (i Item) FilterValue() string { return i.view.Name }
+func (i Item) Title() string       { return i.view.Name }
+func (i Item) Description() string {
+	var parts []string
+	if i.view.Filter != "" {
+		parts = append(parts, fmt.Sprintf("filter: %s", i.view.Filter))
+	}
+	if i.view.Sort != "" {
+		parts = append(parts, fmt.Sprintf("sort: %s", i.view.Sort))
+	}
+	if len(i.view.Columns) > 0 {
+		parts = append(parts, fmt.Sprintf("columns: %s", strings.Join(i.view.Columns, ",")))
+	}
+	if len(parts) == 0 {
+		return "all entries"
+	}
+	return strings.Join(parts, " | ")
+}
+
+// Model is the picker listing saved views
+type Model struct {
+	list           list.Model
+	input          textinput.Model
+	isInputFocused bool
+
+	Keys   keyMap
+	Styles Styles
+}
+
+// New creates a new view picker
+func New() *Model {
+	styles := NewStyles()
+
+	delegate := list.NewDefaultDelegate()
+	delegate.Styles = styles.Item
+
+	l := list.New([]list.Item{}, delegate, 0, 0)
+	l.Title = "Saved views"
+	keys := newKeyMap()
+	l.KeyMap = keys.KeyMap
+	l.Styles.Title = styles.Title
+	l.SetShowHelp(false)
+	l.SetShowTitle(true)
+	l.SetShowFilter(false)
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.DisableQuitKeybindings()
+
+	input := textinput.New()
+	input.Prompt = "name: "
+	input.Placeholder = "my view"
+	input.CharLimit = 30
+	input.SetWidth(30)
+
+	return &Model{
+		list:   l,
+		input:  input,
+		Keys:   keys,
+		Styles: styles,
+	}
+}
+
+// ApplyViewMsg is sent when a view is picked
+type ApplyViewMsg struct {
+	View config.View
+}
+
+// SaveViewMsg is sent when the current table settings should be saved as a view
+type SaveViewMsg struct {
+	Name string
+}
+
+// DeleteViewMsg is sent when a view is deleted
+type DeleteViewMsg struct {
+	Name string
+}
+
+// SetViews updates the list of views displayed
+func (m *Model) SetViews(views []config.View) {
+	items := []list.Item{}
+	for _, view := range views {
+		items = append(items, Item{view: view})
+	}
+	m.list.SetItems(items)
+}
+
+// IsInputFocused returns whether the view name input is focused
+func (m *Model) IsInputFocused() bool {
+	return m.isInputFocused
+}
+
+func (m *Model) Update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	var cmds []tea.Cmd
+
+	if m.isInputFocused {
+		switch msg := msg.(type) {
+		case tea.KeyPressMsg:
+			switch {
+			case key.Matches(msg, m.Keys.Confirm):
+				name := strings.TrimSpace(m.input.Value())
+				m.blurInput()
+				if name != "" {
+					cmds = append(cmds, func() tea.Msg { return SaveViewMsg{Name: name} })
+				}
+			case key.Matches(msg, m.Keys.Cancel):
+				m.blurInput()
+			default:
+				m.input, cmd = m.input.Update(msg)
+				cmds = append(cmds, cmd)
+			}
+		}
+		return tea.Batch(cmds...)
+	}
+
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch {
+		case key.Matches(msg, m.Keys.Select):
+			if item, ok := m.list.SelectedItem().(Item); ok {
+				cmds = append(cmds, func() tea.Msg { return ApplyViewMsg{View: item.view} })
+			}
+		case key.Matches(msg, m.Keys.Save):
+			m.isInputFocused = true
+			cmds = append(cmds, m.input.Focus())
+			return tea.Batch(cmds...)
+		case key.Matches(msg, m.Keys.Delete):
+			if item, ok := m.list.SelectedItem().(Item); ok {
+				cmds = append(cmds, func() tea.Msg { return DeleteViewMsg{Name: item.view.Name} })
+			}
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	cmds = append(cmds, cmd)
+
+	return tea.Batch(cmds...)
+}
+
+func (m *Model) blurInput() {
+	m.isInputFocused = false
+	m.input.Blur()
+	m.input.Reset()
+}
+
+func (m *Model) SetSize(width, height int) {
+	m.list.SetSize(width, height)
+}
+
+func (m *Model) View() string {
+	var s = &m.Styles
+	var sections []string
+
+	if m.isInputFocused {
+		sections = append(sections, s.Input.Render(m.input.View()))
+	}
+	if len(m.list.Items()) == 0 {
+		sections = append(sections, s.Base.Render(s.Title.Render(m.list.Title)))
+		sections = append(sections, "", s.Empty.Render(fmt.Sprintf("no saved views, press '%s' to save the current one", m.Keys.Save.Help().Key)))
+	} else {
+		sections = append(sections, m.list.View())
+	}
+
+	return lg.JoinVertical(lg.Left, sections...)
+}
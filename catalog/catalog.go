@@ -0,0 +1,96 @@
+package catalog
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"io"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+//go:generate go run gen.go
+
+// Generated from the IANA registry
+//
+//go:embed iana.yaml
+var ianaData []byte
+
+// De-facto types and friendlier descriptions, overriding the IANA ones
+//
+//go:embed overlay.yaml
+var overlayData []byte
+
+// Catalog describes DNS-SD service types in human-readable terms
+type Catalog struct {
+	descriptions map[string]string // by service name, ex: 'hap'
+}
+
+// New returns the embedded catalog, with the given descriptions added or overriding embedded ones
+func New(overrides map[string]string) *Catalog {
+	c := &Catalog{descriptions: map[string]string{}}
+	for _, data := range [][]byte{ianaData, overlayData} {
+		descriptions := map[string]string{}
+		if err := yaml.Unmarshal(data, &descriptions); err != nil {
+			log.Println("failed to parse service catalog:", err)
+		}
+		maps.Copy(c.descriptions, descriptions)
+	}
+	for service, description := range overrides {
+		c.descriptions[ServiceName(service)] = description
+	}
+	return c
+}
+
+// Describe returns the description of a service type, ex: '_hap._tcp', '_hap' or 'hap'
+func (c *Catalog) Describe(service string) (string, bool) {
+	description, ok := c.descriptions[ServiceName(service)]
+	return description, ok
+}
+
+// ServiceName returns the name of a service type without the protocol and leading underscore, ex: '_hap._tcp' -> 'hap'
+func ServiceName(service string) string {
+	name, _, _ := strings.Cut(service, "._")
+	return strings.ToLower(strings.TrimPrefix(name, "_"))
+}
+
+// ParseIANA reads the descriptions of the service names of the IANA Service Name and Transport Protocol Port Number
+// Registry, from its CSV format. A name registered for several protocols keeps its first description.
+func ParseIANA(r io.Reader) (map[string]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	nameColumn, descriptionColumn := slices.Index(header, "Service Name"), slices.Index(header, "Description")
+	if nameColumn < 0 || descriptionColumn < 0 {
+		return nil, errors.New("missing 'Service Name' or 'Description' column")
+	}
+
+	descriptions := map[string]string{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(record) <= max(nameColumn, descriptionColumn) {
+			continue
+		}
+		name := ServiceName(record[nameColumn])
+		description := strings.Join(strings.Fields(record[descriptionColumn]), " ")
+		if name == "" || description == "" || descriptions[name] != "" {
+			continue
+		}
+		descriptions[name] = description
+	}
+	return descriptions, nil
+}
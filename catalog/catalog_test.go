@@ -0,0 +1,54 @@
+package catalog
+
+import (
+	"maps"
+	"os"
+	"testing"
+)
+
+func TestParseIANA(t *testing.T) {
+	f, err := os.Open("testdata/service-names-port-numbers.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	descriptions, err := ParseIANA(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"http":      "World Wide Web HTTP",
+		"www":       "World Wide Web HTTP",
+		"ipp":       "IPP (Internet Printing Protocol)",
+		"printer":   "Spooler (lpd)",
+		"odd-quote": `A "quoted" name`,
+	}
+	if !maps.Equal(descriptions, want) {
+		t.Errorf("ParseIANA() = %v, want %v", descriptions, want)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	c := New(map[string]string{"_myapp._tcp": "My App", "_hap._tcp": "HomeKit"})
+
+	tests := []struct {
+		service     string
+		description string
+		ok          bool
+	}{
+		{"_myapp._tcp", "My App", true},
+		{"myapp", "My App", true},
+		{"_HAP._tcp", "HomeKit", true},
+		{"_googlecast._tcp", "Google Cast (Chromecast)", true},
+		{"_nothing-like-it._udp", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			description, ok := c.Describe(tt.service)
+			if description != tt.description || ok != tt.ok {
+				t.Errorf("Describe() = %q, %v, want %q, %v", description, ok, tt.description, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+//go:build ignore
+
+// Generates iana.yaml from the IANA Service Name and Transport Protocol Port Number Registry
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+
+	"gitlab.com/patopest/mdns-discovery/catalog"
+)
+
+const REGISTRY_URL = "https://www.iana.org/assignments/service-names-port-numbers/service-names-port-numbers.csv"
+
+func main() {
+	source := flag.String("csv", REGISTRY_URL, "URL or path of the registry in CSV format")
+	output := flag.String("o", "iana.yaml", "File to write")
+	flag.Parse()
+
+	registry, err := open(*source)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer registry.Close()
+
+	descriptions, err := catalog.ParseIANA(registry)
+	if err != nil {
+		log.Fatalf("%s: %v", *source, err)
+	}
+	data, err := yaml.Marshal(descriptions)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	header := "# Code generated by gen.go from the IANA Service Name and Transport Protocol Port Number Registry. DO NOT EDIT.\n" +
+		"# Source: " + *source + "\n"
+	if err := os.WriteFile(*output, append([]byte(header), data...), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: %d service names\n", *output, len(descriptions))
+}
+
+// open opens the registry from a URL or a file
+func open(source string) (io.ReadCloser, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.Open(source)
+	}
+	resp, err := http.Get(source)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s: %s", source, resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/patopest/mdns-discovery/check"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// Exit code of the check command when some expectations are not met, distinct from the 1 of the errors (invalid
+// flags, config or expectations) so that CI jobs can tell a missing service from a broken job
+const CHECK_FAILED_EXIT_CODE = 3
+
+func newCheckCmd() *cobra.Command {
+	var timeout time.Duration
+	var output string
+	var expects []string
+	var expectCounts []string
+	var files []string
+
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Assert that some services are announced, exits with 3 if not (headless)",
+		Long: `Assert that some services are announced, exits with 3 if not (headless).
+Errors, ex: an invalid expectation, exit with 1.
+
+Discovery stops as soon as all the expectations are met, or after the timeout.
+Expectations with an upper bound ('<', '<=' or '==') are only evaluated at the timeout.`,
+		Example: `  mdns-discovery check --expect '_esphomelib._tcp name=sensor-*' --expect-count '_ipp._tcp>=2' --timeout 30s
+  mdns-discovery check -f expectations.yaml -o junit > report.xml`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := loadConfig(); err != nil { // for the interfaces and domains of the profile
+				return err
+			}
+
+			expectations := []check.Expectation{}
+			for _, path := range files {
+				loaded, err := check.Load(path)
+				if err != nil {
+					return err
+				}
+				expectations = append(expectations, loaded...)
+			}
+			for _, s := range expects {
+				e, err := check.Parse(s)
+				if err != nil {
+					return err
+				}
+				expectations = append(expectations, e)
+			}
+			for _, s := range expectCounts {
+				e, err := check.ParseCount(s)
+				if err != nil {
+					return err
+				}
+				expectations = append(expectations, e)
+			}
+			if len(expectations) == 0 {
+				return errors.New("no expectations, use --expect, --expect-count or --file")
+			}
+			if output != "tap" && output != "junit" {
+				return fmt.Errorf("unknown output format '%s'", output)
+			}
+
+			start := time.Now()
+			entries := discoverEntriesUntil(timeout, func(entries []network.Entry) bool {
+				return check.Done(check.Evaluate(expectations, entries))
+			})
+			results := check.Evaluate(expectations, entries)
+
+			var err error
+			if output == "junit" {
+				err = check.WriteJUnit(os.Stdout, results, time.Since(start))
+			} else {
+				err = check.WriteTAP(os.Stdout, results)
+			}
+			if err != nil {
+				return err
+			}
+
+			if !check.Passed(results) {
+				os.Exit(CHECK_FAILED_EXIT_CODE)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 0, "How long to wait for the expectations to be met, the query timeout if 0")
+	cmd.Flags().StringVarP(&output, "output", "o", "tap", "Output format: 'tap' or 'junit'")
+	cmd.Flags().StringArrayVarP(&expects, "expect", "e", nil, "Expect at least one service, ex: '_esphomelib._tcp name=sensor-*' (fields: name, host, ip, port, txt.<key>)")
+	cmd.Flags().StringArrayVarP(&expectCounts, "expect-count", "c", nil, "Expect a number of services, ex: '_ipp._tcp>=2' (operators: >=, <=, ==, >, <)")
+	cmd.Flags().StringArrayVarP(&files, "file", "f", nil, "Read expectations from a YAML file")
+
+	return cmd
+}
@@ -0,0 +1,342 @@
+package check
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+
+	"gitlab.com/patopest/mdns-discovery/api"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// Count of an expectation without one
+const DEFAULT_COUNT = ">=1"
+
+// Expectation count at the end of an --expect-count value, ex: '_ipp._tcp>=2'
+var countSuffix = regexp.MustCompile(`^(.*?)\s*(>=|<=|==|>|<)\s*(\d+)$`)
+
+// Expectation is a number of services matching some fields that should be announced
+type Expectation struct {
+	Name     string            `yaml:"name,omitempty"`     // shown in the reports (default: the expectation itself)
+	Service  string            `yaml:"service"`            // service type, ex: '_esphomelib._tcp'
+	Instance string            `yaml:"instance,omitempty"` // instance name, ex: 'sensor-*'
+	Hostname string            `yaml:"hostname,omitempty"`
+	IP       string            `yaml:"ip,omitempty"`
+	Port     string            `yaml:"port,omitempty"`
+	TXT      map[string]string `yaml:"txt,omitempty"`   // values of TXT record keys
+	Count    string            `yaml:"count,omitempty"` // ex: '>=2', '==1' or '<1' (default: '>=1')
+}
+
+// File is an expectations file
+type File struct {
+	Expectations []Expectation `yaml:"expectations"`
+}
+
+// Parse parses an expectation from a service type (glob) followed by 'field=glob' filters on the 'name'
+// (or 'instance'), 'host' (or 'hostname'), 'ip', 'port' and 'txt.<key>' fields,
+// ex: '_esphomelib._tcp name=sensor-* txt.version=2024.*'
+func Parse(s string) (Expectation, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return Expectation{}, errors.New("empty expectation")
+	}
+
+	e := Expectation{Service: fields[0]}
+	for _, field := range fields[1:] {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return e, fmt.Errorf("invalid filter '%s' in '%s', expected 'field=value'", field, s)
+		}
+		switch {
+		case key == "name" || key == "instance":
+			e.Instance = value
+		case key == "host" || key == "hostname":
+			e.Hostname = value
+		case key == "ip":
+			e.IP = value
+		case key == "port":
+			e.Port = value
+		case strings.HasPrefix(key, "txt."):
+			if e.TXT == nil {
+				e.TXT = map[string]string{}
+			}
+			e.TXT[strings.TrimPrefix(key, "txt.")] = value
+		default:
+			return e, fmt.Errorf("unknown field '%s' in '%s'", key, s)
+		}
+	}
+	return e, e.Validate()
+}
+
+// ParseCount parses an expectation followed by a count, ex: '_ipp._tcp>=2' or '_ipp._tcp name=office-* == 1'
+func ParseCount(s string) (Expectation, error) {
+	match := countSuffix.FindStringSubmatch(strings.TrimSpace(s))
+	if match == nil {
+		return Expectation{}, fmt.Errorf("missing count in '%s', ex: '_ipp._tcp>=2'", s)
+	}
+	e, err := Parse(match[1])
+	e.Count = match[2] + match[3]
+	if err != nil {
+		return e, err
+	}
+	return e, e.Validate()
+}
+
+// Load reads an expectations file
+func Load(path string) ([]Expectation, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file File
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	for i, e := range file.Expectations {
+		if err := e.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: expectation %d: %w", path, i+1, err)
+		}
+	}
+	return file.Expectations, nil
+}
+
+// Validate checks the service type, globs and count of the expectation
+func (e Expectation) Validate() error {
+	if e.Service == "" {
+		return errors.New("missing service type")
+	}
+	globs := []string{e.Service, e.Instance, e.Hostname, e.IP, e.Port}
+	globs = append(globs, slices.Collect(maps.Values(e.TXT))...)
+	for _, glob := range globs {
+		if _, err := path.Match(glob, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", glob, err)
+		}
+	}
+	_, _, err := e.bounds()
+	return err
+}
+
+// String returns the expectation in the --expect-count syntax
+func (e Expectation) String() string {
+	fields := []string{e.Service}
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, key+"="+value)
+		}
+	}
+	add("name", e.Instance)
+	add("host", e.Hostname)
+	add("ip", e.IP)
+	add("port", e.Port)
+	for _, key := range slices.Sorted(maps.Keys(e.TXT)) {
+		add("txt."+key, e.TXT[key])
+	}
+	return strings.Join(fields, " ") + " " + e.count()
+}
+
+// Title returns the name of the expectation, or the expectation itself if it has none
+func (e Expectation) Title() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	return e.String()
+}
+
+// Matches returns whether a service matches all the fields of the expectation
+func (e Expectation) Matches(service api.Service) bool {
+	if !glob(e.Service, service.Service) || !glob(e.Instance, service.Instance) || !glob(e.Hostname, service.Hostname) ||
+		!glob(e.Port, strconv.Itoa(service.Port)) {
+		return false
+	}
+	if e.IP != "" && !glob(e.IP, service.IPv4) && !glob(e.IP, service.IPv6) {
+		return false
+	}
+	for key, pattern := range e.TXT {
+		value, ok := service.TXT[key]
+		if !ok || !glob(pattern, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e Expectation) count() string {
+	if e.Count == "" {
+		return DEFAULT_COUNT
+	}
+	return strings.ReplaceAll(e.Count, " ", "")
+}
+
+// bounds parses the count of the expectation as an operator and a number
+func (e Expectation) bounds() (op string, n int, err error) {
+	match := countSuffix.FindStringSubmatch(e.count())
+	if match == nil || match[1] != "" {
+		return "", 0, fmt.Errorf("invalid count '%s', expected an operator (>=, <=, ==, >, <) and a number", e.Count)
+	}
+	n, err = strconv.Atoi(match[3])
+	return match[2], n, err
+}
+
+// glob matches a value against a pattern, ignoring case. An empty pattern matches everything.
+func glob(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return ok
+}
+
+// Result is the outcome of an expectation
+type Result struct {
+	Expectation Expectation
+	Found       []string // names of the matching instances
+	OK          bool
+}
+
+// Message describes a result, ex: 'found 1 service, expected >=2'
+func (r Result) Message() string {
+	noun := "services"
+	if len(r.Found) == 1 {
+		noun = "service"
+	}
+	return fmt.Sprintf("found %d %s, expected %s", len(r.Found), noun, r.Expectation.count())
+}
+
+// Evaluate counts the instances matching each expectation
+func Evaluate(expectations []Expectation, entries []network.Entry) []Result {
+	results := []Result{}
+	for _, e := range expectations {
+		result := Result{Expectation: e, Found: []string{}}
+		for _, entry := range entries {
+			service := api.NewService(entry)
+			if e.Matches(service) && !slices.Contains(result.Found, service.Name) {
+				result.Found = append(result.Found, service.Name)
+			}
+		}
+		slices.Sort(result.Found)
+
+		op, n, _ := e.bounds()
+		count := len(result.Found)
+		switch op {
+		case ">=":
+			result.OK = count >= n
+		case ">":
+			result.OK = count > n
+		case "<=":
+			result.OK = count <= n
+		case "<":
+			result.OK = count < n
+		case "==":
+			result.OK = count == n
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Done returns whether discovery can stop: all the expectations are met and more services can't fail them
+// (none has an upper bound)
+func Done(results []Result) bool {
+	for _, result := range results {
+		op, _, _ := result.Expectation.bounds()
+		if !result.OK || (op != ">=" && op != ">") {
+			return false
+		}
+	}
+	return true
+}
+
+// Passed returns whether all the expectations are met
+func Passed(results []Result) bool {
+	return !slices.ContainsFunc(results, func(r Result) bool { return !r.OK })
+}
+
+// WriteTAP writes the results in the Test Anything Protocol (version 13) format
+func WriteTAP(w io.Writer, results []Result) error {
+	fmt.Fprintln(w, "TAP version 13")
+	fmt.Fprintf(w, "1..%d\n", len(results))
+	for i, result := range results {
+		status := "ok"
+		if !result.OK {
+			status = "not ok"
+		}
+		fmt.Fprintf(w, "%s %d - %s\n", status, i+1, result.Expectation.Title())
+		fmt.Fprintln(w, "  ---")
+		fmt.Fprintf(w, "  message: %s\n", strconv.Quote(result.Message()))
+		fmt.Fprintf(w, "  expectation: %s\n", strconv.Quote(result.Expectation.String()))
+		if len(result.Found) > 0 {
+			fmt.Fprintln(w, "  found:")
+			for _, name := range result.Found {
+				fmt.Fprintf(w, "    - %s\n", strconv.Quote(name))
+			}
+		}
+		fmt.Fprintln(w, "  ...")
+	}
+	return nil
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Output    string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the results as a JUnit XML report, elapsed being the time spent discovering
+func WriteJUnit(w io.Writer, results []Result, elapsed time.Duration) error {
+	suite := junitSuite{
+		Name:  "mdns-discovery",
+		Tests: len(results),
+		Time:  strconv.FormatFloat(elapsed.Seconds(), 'f', 3, 64),
+	}
+	for _, result := range results {
+		testCase := junitTestCase{
+			Name:      result.Expectation.Title(),
+			ClassName: result.Expectation.Service,
+			Output:    strings.Join(result.Found, "\n"),
+		}
+		if !result.OK {
+			suite.Failures++
+			testCase.Failure = &junitFailure{Message: result.Message(), Text: result.Expectation.String()}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	fmt.Fprint(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitTestSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,237 @@
+package check
+
+import (
+	"bytes"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s    string
+		want Expectation
+		err  string
+	}{
+		{"_ipp._tcp", Expectation{Service: "_ipp._tcp"}, ""},
+		{"_esphomelib._tcp name=sensor-* host=sensor-*.local ip=192.168.1.* port=6053 txt.version=2024.*",
+			Expectation{Service: "_esphomelib._tcp", Instance: "sensor-*", Hostname: "sensor-*.local", IP: "192.168.1.*", Port: "6053",
+				TXT: map[string]string{"version": "2024.*"}}, ""},
+		{"_ssh._tcp  instance=obelix   hostname=obelix.local", Expectation{Service: "_ssh._tcp", Instance: "obelix", Hostname: "obelix.local"}, ""},
+		{"_http._tcp txt.path= txt.vendor=acme", Expectation{Service: "_http._tcp", TXT: map[string]string{"path": "", "vendor": "acme"}}, ""},
+		{"", Expectation{}, "empty expectation"},
+		{"_ipp._tcp office", Expectation{}, "invalid filter 'office'"},
+		{"_ipp._tcp color=true", Expectation{}, "unknown field 'color'"},
+		{"_ipp._tcp name=[office", Expectation{}, "invalid pattern '[office'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := Parse(tt.s)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equal(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCount(t *testing.T) {
+	tests := []struct {
+		s      string
+		want   Expectation
+		String string
+		err    string
+	}{
+		{"_ipp._tcp>=2", Expectation{Service: "_ipp._tcp", Count: ">=2"}, "_ipp._tcp >=2", ""},
+		{"_ipp._tcp name=office-* == 1", Expectation{Service: "_ipp._tcp", Instance: "office-*", Count: "==1"}, "_ipp._tcp name=office-* ==1", ""},
+		{"_ssh._tcp<1", Expectation{Service: "_ssh._tcp", Count: "<1"}, "_ssh._tcp <1", ""},
+		{"_http._tcp txt.b=2 txt.a=1 >0", Expectation{Service: "_http._tcp", TXT: map[string]string{"a": "1", "b": "2"}, Count: ">0"},
+			"_http._tcp txt.a=1 txt.b=2 >0", ""},
+		{"_ipp._tcp", Expectation{}, "", "missing count"},
+		{"_ipp._tcp>=two", Expectation{}, "", "missing count"},
+		{">=2", Expectation{}, "", "empty expectation"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseCount(tt.s)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equal(got, tt.want) {
+				t.Errorf("ParseCount() = %+v, want %+v", got, tt.want)
+			}
+			if got.String() != tt.String {
+				t.Errorf("String() = %q, want %q", got.String(), tt.String)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		e    Expectation
+		err  string
+	}{
+		{"default count", Expectation{Service: "_ipp._tcp"}, ""},
+		{"count with spaces", Expectation{Service: "_ipp._tcp", Count: ">= 2"}, ""},
+		{"missing service", Expectation{Instance: "office"}, "missing service type"},
+		{"invalid count", Expectation{Service: "_ipp._tcp", Count: "2"}, "invalid count '2'"},
+		{"invalid TXT pattern", Expectation{Service: "_ipp._tcp", TXT: map[string]string{"rp": "[ipp"}}, "invalid pattern '[ipp'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.e.Validate()
+			if (err == nil) != (tt.err == "") || (err != nil && !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("Validate() = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+// entries are the services found in the tests
+var entries = []network.Entry{
+	entry("Office Printer._ipp._tcp.local.", "printer.local.", "192.168.1.2", 631, "rp=ipp/print", "ty=Brother HL-L2350DW"),
+	entry("Office Printer._ipp._tcp.local.", "printer.local.", "192.168.1.2", 631, "rp=ipp/print", "ty=Brother HL-L2350DW"), // on another interface
+	entry(`Lab\ Printer._ipp._tcp.local.`, "lab-printer.local.", "10.0.0.5", 631, "rp=ipp/print"),
+	entry("sensor-kitchen._esphomelib._tcp.local.", "sensor-kitchen.local.", "192.168.1.20", 6053, "version=2024.6.1"),
+	entry("sensor-garage._esphomelib._tcp.local.", "sensor-garage.local.", "192.168.1.21", 6053, "version=2023.12.0"),
+}
+
+func entry(name, host, ip string, port int, txt ...string) network.Entry {
+	return network.Entry{ServiceEntry: network.ServiceEntry{Name: name, Host: host, AddrV4: net.ParseIP(ip), Port: port, InfoFields: txt}}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		expectation string
+		found       []string
+		ok          bool
+	}{
+		{"_ipp._tcp>=2", []string{`Lab\ Printer._ipp._tcp.local.`, "Office Printer._ipp._tcp.local."}, true},
+		{"_ipp._tcp>2", []string{`Lab\ Printer._ipp._tcp.local.`, "Office Printer._ipp._tcp.local."}, false},
+		{"_IPP._tcp name=lab* ==1", []string{`Lab\ Printer._ipp._tcp.local.`}, true}, // ignoring case, unescaped
+		{"_ipp._tcp txt.ty=Brother* ip=192.168.1.* ==1", []string{"Office Printer._ipp._tcp.local."}, true},
+		{"_esphomelib._tcp txt.version=2024.* >=2", []string{"sensor-kitchen._esphomelib._tcp.local."}, false},
+		{"_esphomelib._tcp port=6053 host=sensor-*.local <=2", []string{"sensor-garage._esphomelib._tcp.local.", "sensor-kitchen._esphomelib._tcp.local."}, true},
+		{"_ssh._tcp<1", []string{}, true},
+		{"_ssh._tcp>=1", []string{}, false},
+		{"_*._tcp txt.missing=* >=0", []string{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expectation, func(t *testing.T) {
+			e, err := ParseCount(tt.expectation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results := Evaluate([]Expectation{e}, entries)
+			if len(results) != 1 {
+				t.Fatalf("%d results, want 1", len(results))
+			}
+			if !slices.Equal(results[0].Found, tt.found) || results[0].OK != tt.ok {
+				t.Errorf("found %v (ok: %v), want %v (ok: %v)", results[0].Found, results[0].OK, tt.found, tt.ok)
+			}
+		})
+	}
+}
+
+func TestDone(t *testing.T) {
+	tests := []struct {
+		name         string
+		expectations []string
+		done         bool
+	}{
+		{"all met", []string{"_ipp._tcp>=2", "_esphomelib._tcp>0"}, true},
+		{"one not met", []string{"_ipp._tcp>=2", "_ssh._tcp>=1"}, false},
+		{"upper bound met", []string{"_ipp._tcp>=2", "_ssh._tcp<1"}, false}, // can still be failed by a new service
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectations := []Expectation{}
+			for _, s := range tt.expectations {
+				e, err := ParseCount(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expectations = append(expectations, e)
+			}
+			if got := Done(Evaluate(expectations, entries)); got != tt.done {
+				t.Errorf("Done() = %v, want %v", got, tt.done)
+			}
+		})
+	}
+}
+
+// results are evaluated from expectations met or not, with and without a name
+func results(t *testing.T) []Result {
+	t.Helper()
+	expectations := []Expectation{}
+	for _, s := range []string{"_ipp._tcp>=2", `_esphomelib._tcp txt.version=2024.* >=2`} {
+		e, err := ParseCount(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectations = append(expectations, e)
+	}
+	expectations = append(expectations, Expectation{Name: `SSH on "obelix" & co`, Service: "_ssh._tcp", Instance: "obelix"})
+	return Evaluate(expectations, entries)
+}
+
+func TestWriteTAP(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteTAP(&b, results(t)); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, b.String(), "testdata/results.tap")
+}
+
+func TestWriteJUnit(t *testing.T) {
+	var b bytes.Buffer
+	if err := WriteJUnit(&b, results(t), 1234*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, b.String(), "testdata/results.xml")
+}
+
+// golden compares the output of a test to the content of a file
+func golden(t *testing.T, got, path string) {
+	t.Helper()
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("got:\n%s\nwant (%s):\n%s", got, path, want)
+	}
+}
+
+// equal returns whether two expectations have the same fields
+func equal(a, b Expectation) bool {
+	return a.Name == b.Name && a.Service == b.Service && a.Instance == b.Instance && a.Hostname == b.Hostname && a.IP == b.IP &&
+		a.Port == b.Port && a.Count == b.Count && maps.Equal(a.TXT, b.TXT)
+}
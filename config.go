@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"gitlab.com/patopest/mdns-discovery/app"
+	"gitlab.com/patopest/mdns-discovery/config"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// loadConfig reads the config file given with --config (or the default one), selects the profile given with
+// --profile (or the default one of the file) and uses its settings as defaults of the flags
+func loadConfig() (*config.Config, error) {
+	path := viper.GetString("config")
+	if path == "" {
+		path = config.DefaultPath()
+	}
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to load config file %s: %w", path, err)
+	}
+	if err := cfg.UseProfile(viper.GetString("profile")); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+
+	s := cfg.Current()
+	for name, value := range map[string]string{"interval": s.Interval, "timeout": s.Timeout} {
+		if _, err := time.ParseDuration(value); value != "" && err != nil {
+			return cfg, fmt.Errorf("%s: invalid %s '%s': %w", path, name, value, err)
+		}
+	}
+
+	setFlagDefault("interface", s.Interfaces, len(s.Interfaces) > 0)
+	setFlagDefault("domain", s.Domains, len(s.Domains) > 0)
+	setFlagDefault("services", s.Services, len(s.Services) > 0)
+	setFlagDefault("interval", s.Interval, s.Interval != "")
+	setFlagDefault("query-timeout", s.Timeout, s.Timeout != "")
+	setFlagDefault("backoff", s.Backoff != nil && *s.Backoff, s.Backoff != nil)
+	setFlagDefault("passive", s.Passive != nil && *s.Passive, s.Passive != nil)
+	return cfg, nil
+}
+
+// setFlagDefault overrides the default value of a flag, or restores it if the setting is not set
+func setFlagDefault(key string, value any, set bool) {
+	if !set {
+		viper.SetDefault(key, nil) // falls back to the default of the flag
+		return
+	}
+	viper.SetDefault(key, value)
+}
+
+// discoveryOptions returns the options of the queries, from the flags or the config
+func discoveryOptions() network.Options {
+	return network.Options{
+		Services: viper.GetStringSlice("services"),
+		Interval: viper.GetDuration("interval"),
+		Timeout:  viper.GetDuration("query-timeout"),
+		Backoff:  viper.GetBool("backoff"),
+		Passive:  viper.GetBool("passive"),
+	}
+}
+
+// discoveryDomains returns the domains to query, a fake one with fake data
+func discoveryDomains() []string {
+	if viper.GetBool("fake") {
+		return []string{"test.com"}
+	}
+	return viper.GetStringSlice("domain")
+}
+
+// reloadConfig reads the config file again, for the TUI to apply it
+func reloadConfig() app.ConfigMsg {
+	cfg, err := loadConfig()
+	if err != nil {
+		return app.ConfigMsg{Err: err}
+	}
+	return app.ConfigMsg{
+		Config:     cfg,
+		Interfaces: viper.GetStringSlice("interface"),
+		Domains:    discoveryDomains(),
+		Options:    discoveryOptions(),
+	}
+}
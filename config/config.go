@@ -0,0 +1,361 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	APP_NAME         = "mdns-discovery"
+	CONFIG_FILE_NAME = "config.yaml"
+)
+
+// Config is the user configuration persisted to disk
+type Config struct {
+	Settings `yaml:",inline"` // used without profile, and as defaults of the profiles
+
+	Profile  string    `yaml:"profile,omitempty"` // profile used when none is given with --profile
+	Profiles []Profile `yaml:"profiles,omitempty"`
+
+	Views     []View            `yaml:"views,omitempty"`
+	Actions   []Action          `yaml:"actions,omitempty"`
+	Catalog   map[string]string `yaml:"catalog,omitempty"`   // service type descriptions, ex: '_hap._tcp: HomeKit'
+	Templates map[string]string `yaml:"templates,omitempty"` // export template files by name, ex: 'inventory: ~/inventory.tmpl'
+
+	Prometheus []PrometheusTarget `yaml:"prometheus,omitempty"`
+	Hooks      []Hook             `yaml:"hooks,omitempty"`
+
+	path   string     // file the config was loaded from and is saved to
+	doc    *yaml.Node // file as loaded, edited by Save to keep its comments, order and unknown keys
+	active string     // name of the profile in use, empty if none
+}
+
+// Settings are the discovery and display settings, set at the top level of the config or by a profile
+type Settings struct {
+	Interfaces []string            `yaml:"interfaces,omitempty"`
+	Domains    []string            `yaml:"domains,omitempty"`
+	Services   []string            `yaml:"services,omitempty"` // service types browsed instead of all of them, ex: '_ipp._tcp'
+	Interval   string              `yaml:"interval,omitempty"` // between queries, ex: '30s'
+	Timeout    string              `yaml:"timeout,omitempty"`  // how long to wait for the answers of a query, see --query-timeout, ex: '5s'
+	Backoff    *bool               `yaml:"backoff,omitempty"`  // query with an exponential backoff (1s doubling up to 60min) instead of at interval
+	Passive    *bool               `yaml:"passive,omitempty"`  // never query, only listen to the announcements and other hosts' responses
+	Columns    []Column            `yaml:"columns,omitempty"`
+	Theme      string              `yaml:"theme,omitempty"`  // built-in theme: 'default', 'light', 'ocean' or 'mono'
+	Colors     map[string]string   `yaml:"colors,omitempty"` // overrides the colors of the theme, ex: 'top: "#ff8700"'
+	Keys       map[string][]string `yaml:"keys,omitempty"`   // rebinds actions, ex: 'quit: [q, ctrl+c]'
+}
+
+// Profile is a named set of settings, selected with --profile. Unset settings are taken from the top level.
+type Profile struct {
+	Name     string `yaml:"name"`
+	Settings `yaml:",inline"`
+}
+
+// Column is the layout of a visible table column
+type Column struct {
+	Key   string `yaml:"key"`
+	Width int    `yaml:"width,omitempty"` // width of fixed columns, flex factor of flexible ones
+	Wrap  bool   `yaml:"wrap,omitempty"`  // wrap long cells over multiple lines instead of truncating them
+}
+
+// View is a named set of table settings (filter, sort and visible columns)
+type View struct {
+	Name    string   `yaml:"name"`
+	Filter  string   `yaml:"filter,omitempty"`
+	Sort    string   `yaml:"sort,omitempty"` // comma separated column keys by priority, prefixed with '-' for descending order
+	Columns []string `yaml:"columns,omitempty"`
+}
+
+// Action is a command opening the services of a given type, added to (or replacing) the built-in ones
+type Action struct {
+	Service  string `yaml:"service"` // service type, ex: '_ssh._tcp'
+	Name     string `yaml:"name"`
+	Command  string `yaml:"command"`            // each argument is a text/template, ex: 'ssh {{.Host}} -p {{.Port}}'
+	Terminal bool   `yaml:"terminal,omitempty"` // run in the terminal (suspending the UI) instead of in the background
+}
+
+// PrometheusTarget maps the services of a given type to Prometheus scrape targets
+type PrometheusTarget struct {
+	Service string            `yaml:"service"`          // service type, ex: '_prometheus-http._tcp'
+	Labels  map[string]string `yaml:"labels,omitempty"` // each value is a text/template, ex: '__metrics_path__: {{.TXT.path}}'
+}
+
+// Hook is a webhook or a command run when services matching it appear, change or disappear
+type Hook struct {
+	Name     string   `yaml:"name"`
+	On       []string `yaml:"on,omitempty"`       // events firing the hook: 'added', 'updated' and/or 'removed' (default: all)
+	Service  string   `yaml:"service,omitempty"`  // service type, ex: '_esphomelib._tcp' (default: all)
+	Filter   string   `yaml:"filter,omitempty"`   // only services whose name, host, address or TXT record contains it
+	URL      string   `yaml:"url,omitempty"`      // POST the event as JSON to this URL...
+	Command  string   `yaml:"command,omitempty"`  // ...or run this command, with the service in MDNS_* environment variables
+	Debounce string   `yaml:"debounce,omitempty"` // wait for a service to settle and only fire for its last event, ex: '10s'
+	Attempts int      `yaml:"attempts,omitempty"` // tries before giving up, with exponential backoff (default: 4)
+}
+
+// DefaultPath returns the config file location following the XDG base directory spec
+func DefaultPath() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return CONFIG_FILE_NAME
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, APP_NAME, CONFIG_FILE_NAME)
+}
+
+// Load reads the config file at path. A missing file is not an error and returns an empty Config
+func Load(path string) (*Config, error) {
+	cfg := &Config{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cfg, nil
+	} else if err != nil {
+		return cfg, err
+	}
+
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return cfg, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err == nil && len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+		cfg.doc = &doc
+	}
+	return cfg, nil
+}
+
+// Path returns the file the config is loaded from and saved to
+func (c *Config) Path() string {
+	return c.path
+}
+
+// Save writes the views and the columns layout back to its file, creating parent directories if needed. The rest of
+// the file is kept as written, with its comments, order and keys unknown to this version.
+func (c *Config) Save() error {
+	doc := c.doc
+	if doc == nil {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+
+	if err := setKey(root, "views", c.Views, len(c.Views) == 0); err != nil {
+		return err
+	}
+	parent, columns := root, c.Columns
+	if profile := c.profile(c.active); profile != nil { // saved by SetColumns in the profile in use
+		if parent = profileNode(root, c.active); parent == nil {
+			return fmt.Errorf("profile '%s' not found in %s", c.active, c.path)
+		}
+		columns = profile.Columns
+	}
+	if err := setKey(parent, "columns", columns, len(columns) == 0); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	c.doc = doc
+	data := buf.Bytes()
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o644)
+}
+
+// UseProfile selects the profile whose settings are returned by Current, the default one if name is empty
+func (c *Config) UseProfile(name string) error {
+	if name == "" {
+		name = c.Profile
+	}
+	if name != "" && c.profile(name) == nil {
+		return fmt.Errorf("unknown profile '%s'", name)
+	}
+	c.active = name
+	return nil
+}
+
+// ActiveProfile returns the name of the profile in use, empty if none
+func (c *Config) ActiveProfile() string {
+	return c.active
+}
+
+// Current returns the settings of the profile in use, completed by the top level ones
+func (c *Config) Current() Settings {
+	s := c.Settings
+	profile := c.profile(c.active)
+	if profile == nil {
+		return s
+	}
+
+	if len(profile.Interfaces) > 0 {
+		s.Interfaces = profile.Interfaces
+	}
+	if len(profile.Domains) > 0 {
+		s.Domains = profile.Domains
+	}
+	if len(profile.Services) > 0 {
+		s.Services = profile.Services
+	}
+	if profile.Interval != "" {
+		s.Interval = profile.Interval
+	}
+	if profile.Timeout != "" {
+		s.Timeout = profile.Timeout
+	}
+	if profile.Backoff != nil {
+		s.Backoff = profile.Backoff
+	}
+	if profile.Passive != nil {
+		s.Passive = profile.Passive
+	}
+	if len(profile.Columns) > 0 {
+		s.Columns = profile.Columns
+	}
+	if profile.Theme != "" {
+		s.Theme = profile.Theme
+	}
+	if len(profile.Colors) > 0 {
+		s.Colors = profile.Colors
+	}
+	if len(profile.Keys) > 0 {
+		s.Keys = profile.Keys
+	}
+	return s
+}
+
+// SetColumns saves the columns layout in the profile in use, or at the top level if none
+func (c *Config) SetColumns(columns []Column) {
+	if profile := c.profile(c.active); profile != nil {
+		profile.Columns = columns
+		return
+	}
+	c.Columns = columns
+}
+
+// setKey sets the value of key in a mapping node, or removes the key if empty. An equal value is left as is, with
+// its comments and style, and a replaced one keeps the comments around it.
+func setKey(mapping *yaml.Node, key string, value any, empty bool) error {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return err
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+		if empty {
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+			return nil
+		}
+
+		old := mapping.Content[i+1]
+		var was, is any
+		if old.Decode(&was) == nil && node.Decode(&is) == nil && reflect.DeepEqual(was, is) {
+			return nil
+		}
+		node.HeadComment, node.LineComment, node.FootComment = old.HeadComment, old.LineComment, old.FootComment
+		node.Style |= old.Style & yaml.FlowStyle
+		if old.Kind == yaml.SequenceNode && node.Kind == yaml.SequenceNode {
+			keepItems(old, &node)
+		}
+		mapping.Content[i+1] = &node
+		return nil
+	}
+
+	if !empty {
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, &node)
+	}
+	return nil
+}
+
+// keepItems replaces the items of a new sequence node by the equal ones of the old one, with their comments
+func keepItems(old, node *yaml.Node) {
+	for i, item := range node.Content {
+		var is any
+		if item.Decode(&is) != nil {
+			continue
+		}
+		for _, kept := range old.Content {
+			var was any
+			if kept.Decode(&was) == nil && reflect.DeepEqual(was, is) {
+				node.Content[i] = kept
+				break
+			}
+		}
+	}
+}
+
+// profileNode returns the mapping node of the profile with the given name, nil if none
+func profileNode(root *yaml.Node, name string) *yaml.Node {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "profiles" || root.Content[i+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, profile := range root.Content[i+1].Content {
+			var p Profile
+			if profile.Kind == yaml.MappingNode && profile.Decode(&p) == nil && p.Name == name {
+				return profile
+			}
+		}
+	}
+	return nil
+}
+
+// profile returns the profile with the given name, nil if none
+func (c *Config) profile(name string) *Profile {
+	for i := range c.Profiles {
+		if c.Profiles[i].Name == name {
+			return &c.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// GetView returns the view with the given name
+func (c *Config) GetView(name string) (View, bool) {
+	for _, view := range c.Views {
+		if view.Name == name {
+			return view, true
+		}
+	}
+	return View{}, false
+}
+
+// SetView adds a view or replaces an existing one with the same name
+func (c *Config) SetView(view View) {
+	for i, existing := range c.Views {
+		if existing.Name == view.Name {
+			c.Views[i] = view
+			return
+		}
+	}
+	c.Views = append(c.Views, view)
+}
+
+// DeleteView removes the view with the given name
+func (c *Config) DeleteView(name string) {
+	for i, view := range c.Views {
+		if view.Name == name {
+			c.Views = append(c.Views[:i], c.Views[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCurrent(t *testing.T) {
+	on, off := true, false
+	cfg := &Config{
+		Settings: Settings{Interfaces: []string{"eth0"}, Interval: "30s", Backoff: &on, Passive: &on},
+		Profiles: []Profile{
+			{Name: "quiet", Settings: Settings{Interval: "1m", Backoff: &off}},
+			{Name: "empty"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		profile string
+		want    Settings
+	}{
+		{"top level", "", cfg.Settings},
+		{"unset settings", "empty", cfg.Settings},
+		{"false overrides true", "quiet", Settings{Interfaces: []string{"eth0"}, Interval: "1m", Backoff: &off, Passive: &on}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := cfg.UseProfile(tt.profile); err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.Current(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Current() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		edit    func(*Config)
+		golden  string
+	}{
+		{"unchanged", "", func(*Config) {}, "testdata/config.yaml"},
+		{"view added", "", func(c *Config) { c.SetView(View{Name: "web", Filter: "_http"}) }, "testdata/save-view.yaml"},
+		{"views deleted", "", func(c *Config) { c.DeleteView("printers") }, "testdata/save-no-view.yaml"},
+		{"profile columns", "lab", func(c *Config) { c.SetColumns([]Column{{Key: "name"}, {Key: "mac", Width: 17}}) }, "testdata/save-profile-columns.yaml"},
+		{"top level columns", "home", func(c *Config) {
+			c.active = "" // no profile in use, the file has a default one
+			c.SetColumns([]Column{{Key: "host"}})
+		}, "testdata/save-columns.yaml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/config.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := cfg.UseProfile(tt.profile); err != nil {
+				t.Fatal(err)
+			}
+			tt.edit(cfg)
+			if err := cfg.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(tt.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("saved:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestSaveNewFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), APP_NAME, CONFIG_FILE_NAME)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.SetView(View{Name: "web", Filter: "_http"})
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(saved.Views, cfg.Views) {
+		t.Errorf("saved views = %+v, want %+v", saved.Views, cfg.Views)
+	}
+}
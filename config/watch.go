@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// How long to wait for the writes to a file to settle before reloading it
+const RELOAD_DELAY = 200 * time.Millisecond
+
+// Watch calls onChange when the config file at path is written, created or removed, until ctx is cancelled.
+// The parent directory is watched, as editors often replace files instead of writing them. If it doesn't exist yet,
+// its nearest existing ancestor is watched until it is created.
+func Watch(ctx context.Context, path string, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	watched := nearestDir(dir)
+	if err := watcher.Add(watched); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		var timer *time.Timer
+		reload := func() {
+			if timer != nil {
+				timer.Stop()
+			}
+			timer = time.AfterFunc(RELOAD_DELAY, onChange)
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if watched != dir && event.Has(fsnotify.Create) {
+					// a directory on the way to the config file was created
+					if nearest := nearestDir(dir); nearest != watched && watcher.Add(nearest) == nil {
+						watcher.Remove(watched)
+						watched = nearest
+						if _, err := os.Stat(path); err == nil {
+							reload()
+						}
+					}
+					continue
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(path) || event.Op == fsnotify.Chmod {
+					continue
+				}
+				reload()
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// nearestDir returns dir if it exists, or its nearest existing ancestor
+func nearestDir(dir string) string {
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
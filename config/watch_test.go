@@ -0,0 +1,39 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchMissingDir(t *testing.T) {
+	base := t.TempDir()
+	path := filepath.Join(base, "mdns-discovery", "config.yaml")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 10)
+	if err := Watch(ctx, path, func() { changes <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); err == nil {
+		t.Fatal("Watch created the config directory")
+	}
+
+	if err := os.Mkdir(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond) // for the new directory to be watched
+	for i := range 2 {
+		if err := os.WriteFile(path, []byte("theme: light\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-changes:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("change %d not notified", i+1)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"gitlab.com/patopest/mdns-discovery/history"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// runDiscovery runs the discovery in the background until ctx is cancelled, keeping the registry up to date.
+// Subscribe to the registry before, not to miss the first entries. Entries are recorded in store if not nil.
+func runDiscovery(ctx context.Context, registry *network.Registry, observer network.Observer, store *history.Store) *network.Discovery {
+	entriesCh := make(chan network.Entry, 30)
+
+	var discovery *network.Discovery
+	if viper.GetBool("fake") {
+		// no domains, so that toggling interfaces doesn't start real queries
+		discovery = network.NewDiscovery(viper.GetStringSlice("interface"), nil, entriesCh)
+		go announceFakeEntries(ctx, entriesCh)
+	} else {
+		discovery = network.NewDiscovery(viper.GetStringSlice("interface"), viper.GetStringSlice("domain"), entriesCh)
+		discovery.Options = discoveryOptions()
+		if observer != nil {
+			discovery.Observer = observer
+		}
+		discovery.Start()
+	}
+
+	var entries <-chan network.Entry = entriesCh
+	if store != nil {
+		entries = store.Feed(ctx, entriesCh)
+	}
+	go registry.Run(ctx, entries)
+	return discovery
+}
+
+// announceFakeEntries sends the fake entries at every query interval, as if they were answering queries
+func announceFakeEntries(ctx context.Context, entriesCh chan<- network.Entry) {
+	ticker := time.NewTicker(network.QUERY_INTERVAL * time.Second)
+	defer ticker.Stop()
+
+	for {
+		for _, entry := range network.FakeEntries(network.FakeDataLong) {
+			select {
+			case <-ctx.Done():
+				return
+			case entriesCh <- entry:
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package device
+
+import (
+	"net"
+	"regexp"
+	"strings"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// Info is what is known about the device behind a host
+type Info struct {
+	Vendor string
+	Model  string
+}
+
+// String returns the vendor and model, ex: 'Espressif ESP32-C3 (ESPHome)'
+func (i Info) String() string {
+	return strings.TrimSpace(i.Vendor + " " + i.Model)
+}
+
+// hostnamePattern is a hint about a device from its (default) hostname
+type hostnamePattern struct {
+	pattern *regexp.Regexp
+	vendor  string
+	model   string // may reference the pattern's groups, ex: '$1'
+}
+
+var hostnamePatterns = []hostnamePattern{
+	{regexp.MustCompile(`(?i)^(iphone|ipad|macbook(-?air|-?pro)?|imac|mac-?mini|mac-?studio|apple-?tv|homepod)`), "Apple", "$1"},
+	{regexp.MustCompile(`(?i)^raspberrypi`), "Raspberry Pi", ""},
+	{regexp.MustCompile(`(?i)^octopi`), "Raspberry Pi", "OctoPrint"},
+	{regexp.MustCompile(`(?i)^esp[-_]?(32|8266)`), "Espressif", "ESP$1"},
+	{regexp.MustCompile(`(?i)^tasmota`), "Espressif", "Tasmota"},
+	{regexp.MustCompile(`(?i)^shelly([a-z0-9]+)?`), "Shelly", "$1"},
+	{regexp.MustCompile(`(?i)^sonos`), "Sonos", ""},
+	{regexp.MustCompile(`(?i)^(chromecast|google-home|google-nest)`), "Google", "$1"},
+	{regexp.MustCompile(`(?i)^(brw|brn)[0-9a-f]{12}`), "Brother", "printer"},
+	{regexp.MustCompile(`(?i)^(hp|npi)[0-9a-f]{6}`), "HP", "printer"},
+	{regexp.MustCompile(`(?i)^epson`), "Epson", "printer"},
+	{regexp.MustCompile(`(?i)^synology|^ds[0-9]{3,4}`), "Synology", ""},
+}
+
+// TXT keys holding a vendor name, by order of preference
+var vendorKeys = []string{"manufacturer", "vendor", "usb_MFG", "mfg"}
+
+// TXT keys holding a model name, by order of preference
+var modelKeys = []string{"model", "md", "am", "ty", "usb_MDL", "mdl"}
+
+// Vendors of the models starting with a given word (lowercase)
+var modelVendors = map[string]string{
+	"hp":             "HP",
+	"canon":          "Canon",
+	"epson":          "Epson",
+	"brother":        "Brother",
+	"xerox":          "Xerox",
+	"lexmark":        "Lexmark",
+	"kyocera":        "Kyocera",
+	"ricoh":          "Ricoh",
+	"samsung":        "Samsung",
+	"sonos":          "Sonos",
+	"synology":       "Synology",
+	"google":         "Google",
+	"chromecast":     "Google",
+	"nest":           "Google",
+	"appletv":        "Apple",
+	"macbookpro":     "Apple",
+	"macbookair":     "Apple",
+	"imac":           "Apple",
+	"macmini":        "Apple",
+	"iphone":         "Apple",
+	"ipad":           "Apple",
+	"audioaccessory": "Apple", // HomePod
+}
+
+// Identify combines the hints about a host (the TXT records of all its entries, its hostname and
+// MAC address) to guess its vendor and model
+func Identify(entries []network.Entry, mac net.HardwareAddr) Info {
+	var info Info
+	var hostname string
+
+	for _, entry := range entries {
+		hostname = entry.Hostname()
+		txt := entry.TXT()
+
+		// ESPHome devices advertise their platform and chip
+		if entry.ServiceType() == "_esphomelib._tcp" {
+			info.Vendor = first(info.Vendor, "Espressif")
+			chip := strings.ToUpper(first(txt["platform"], "ESP32"))
+			if variant, ok := txt["esp"]; ok && variant != "" {
+				chip = "ESP32-" + strings.ToUpper(variant)
+			}
+			info.Model = first(info.Model, chip+" (ESPHome)")
+		}
+
+		for _, key := range vendorKeys {
+			if value, ok := entry.TXTValue(key); ok && value != "" {
+				info.Vendor = first(info.Vendor, value)
+			}
+		}
+		for _, key := range modelKeys {
+			if value, ok := entry.TXTValue(key); ok && value != "" {
+				info.Model = first(info.Model, value)
+			}
+		}
+		// Apple devices advertise their model identifier in _device-info._tcp, ex: 'MacBookPro18,3'
+		if entry.ServiceType() == "_device-info._tcp" && info.Model != "" {
+			info.Vendor = first(info.Vendor, "Apple")
+		}
+	}
+
+	if vendor, ok := VendorFromMAC(mac); ok {
+		info.Vendor = first(info.Vendor, vendor)
+	}
+
+	for _, p := range hostnamePatterns {
+		match := p.pattern.FindStringSubmatchIndex(hostname)
+		if match == nil {
+			continue
+		}
+		info.Vendor = first(info.Vendor, p.vendor)
+		model := string(p.pattern.ExpandString(nil, p.model, hostname, match))
+		info.Model = first(info.Model, model)
+		break
+	}
+
+	// printers often advertise their vendor in their model, ex: 'HP LaserJet Pro M404'
+	if info.Vendor == "" {
+		info.Vendor = modelVendors[modelBrand(info.Model)]
+	}
+	if info.Vendor != "" && strings.HasPrefix(strings.ToLower(info.Model), strings.ToLower(info.Vendor)+" ") {
+		info.Model = strings.TrimSpace(info.Model[len(info.Vendor):])
+	}
+
+	return info
+}
+
+// modelBrand returns the first word of a model name in lowercase, ex: 'MacBookPro18,3' -> 'macbookpro'
+func modelBrand(model string) string {
+	words := strings.FieldsFunc(model, func(r rune) bool {
+		return r == ' ' || r == '-' || (r >= '0' && r <= '9')
+	})
+	if len(words) == 0 {
+		return ""
+	}
+	return strings.ToLower(words[0])
+}
+
+// first returns the first non-empty value
+func first(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
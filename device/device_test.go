@@ -0,0 +1,123 @@
+package device
+
+import (
+	"net"
+	"testing"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+func entry(name, host string, txt ...string) network.Entry {
+	return network.Entry{ServiceEntry: network.ServiceEntry{Name: name, Host: host, InfoFields: txt}}
+}
+
+func mac(s string) net.HardwareAddr {
+	addr, err := net.ParseMAC(s)
+	if err != nil {
+		panic(err)
+	}
+	return addr
+}
+
+func TestIdentify(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []network.Entry
+		mac     net.HardwareAddr
+		want    Info
+	}{
+		{"ESPHome ESP32 variant", []network.Entry{
+			entry("kitchen._esphomelib._tcp.local.", "kitchen.local.", "version=2024.6.1", "platform=esp32", "board=esp32-c3-devkitm-1", "esp=c3"),
+		}, mac("24:0a:c4:12:34:56"), Info{"Espressif", "ESP32-C3 (ESPHome)"}},
+		{"ESPHome ESP8266", []network.Entry{
+			entry("garage._esphomelib._tcp.local.", "garage.local.", "version=2023.12.9", "platform=ESP8266", "board=d1_mini"),
+		}, nil, Info{"Espressif", "ESP8266 (ESPHome)"}},
+		{"printer vendor in TXT", []network.Entry{
+			entry("Brother HL-L2350DW series._ipp._tcp.local.", "BRW3C2AF4A1B2C3.local.",
+				"ty=Brother HL-L2350DW series", "usb_MFG=Brother", "usb_MDL=HL-L2350DW series", "rp=ipp/print"),
+		}, nil, Info{"Brother", "HL-L2350DW series"}},
+		{"printer vendor in model", []network.Entry{
+			entry("Office._ipp._tcp.local.", "office-printer.local.", "ty=HP LaserJet Pro M404n", "rp=ipp/print"),
+		}, nil, Info{"HP", "LaserJet Pro M404n"}},
+		{"Apple device-info", []network.Entry{
+			entry("MacBook Pro._ssh._tcp.local.", "Alexs-MacBook-Pro.local."),
+			entry("MacBook Pro._device-info._tcp.local.", "Alexs-MacBook-Pro.local.", "model=MacBookPro18,3", "osxvers=23"),
+		}, nil, Info{"Apple", "MacBookPro18,3"}},
+		{"HomePod model identifier", []network.Entry{
+			entry("Living Room._airplay._tcp.local.", "Living-Room.local.", "model=AudioAccessory5,1", "features=0x4A7FDFD5,0xBC157FDE"),
+		}, nil, Info{"Apple", "AudioAccessory5,1"}},
+		{"Google Cast", []network.Entry{
+			entry("Chromecast-Ultra-a1b2c3._googlecast._tcp.local.", "a1b2c3d4-e5f6-0718-293a-4b5c6d7e8f90.local.",
+				"md=Google Nest Mini", "fn=Kitchen speaker", "ca=2052"),
+		}, nil, Info{"Google", "Nest Mini"}},
+		{"Sonos from MAC and RAOP", []network.Entry{
+			entry("Bedroom._sonos._tcp.local.", "sonos5CAAFD0A1B2C.local."),
+			entry("5CAAFD0A1B2C@Bedroom._raop._tcp.local.", "sonos5CAAFD0A1B2C.local.", "am=One", "vs=366.0"),
+		}, mac("5c:aa:fd:0a:1b:2c"), Info{"Sonos", "One"}},
+		{"MAC only", []network.Entry{
+			entry("pihole._ssh._tcp.local.", "pihole.local."),
+		}, mac("b8:27:eb:12:34:56"), Info{"Raspberry Pi", ""}},
+		{"hostname only", []network.Entry{
+			entry("raspberrypi._workstation._tcp.local.", "raspberrypi.local."),
+		}, nil, Info{"Raspberry Pi", ""}},
+		{"hostname with model", []network.Entry{
+			entry("shellyplus1pm-a8032ab12345._http._tcp.local.", "shellyplus1pm-a8032ab12345.local.", "gen=2"),
+		}, nil, Info{"Shelly", "plus1pm"}},
+		{"Apple hostname", []network.Entry{
+			entry("MacBook-Air._smb._tcp.local.", "MacBook-Air.local."),
+		}, nil, Info{"Apple", "MacBook-Air"}},
+		{"randomized MAC", []network.Entry{
+			entry("laptop._ssh._tcp.local.", "laptop.local."),
+		}, mac("3a:1f:22:33:44:55"), Info{}},
+		{"nothing known", nil, nil, Info{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Identify(tt.entries, tt.mac); got != tt.want {
+				t.Errorf("Identify() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVendorFromMAC(t *testing.T) {
+	tests := []struct {
+		mac    net.HardwareAddr
+		vendor string
+		ok     bool
+	}{
+		{mac("b8:27:eb:00:00:01"), "Raspberry Pi", true},
+		{mac("00:0E:58:AA:BB:CC"), "Sonos", true},
+		{mac("00:00:5e:00:53:01"), "", false},
+		{net.HardwareAddr{0xb8, 0x27}, "", false},
+		{nil, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mac.String(), func(t *testing.T) {
+			vendor, ok := VendorFromMAC(tt.mac)
+			if vendor != tt.vendor || ok != tt.ok {
+				t.Errorf("VendorFromMAC() = %q, %v, want %q, %v", vendor, ok, tt.vendor, tt.ok)
+			}
+		})
+	}
+}
+
+func TestInfoString(t *testing.T) {
+	tests := []struct {
+		info Info
+		want string
+	}{
+		{Info{"Espressif", "ESP32-C3 (ESPHome)"}, "Espressif ESP32-C3 (ESPHome)"},
+		{Info{"Raspberry Pi", ""}, "Raspberry Pi"},
+		{Info{"", "One"}, "One"},
+		{Info{}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.info.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package device
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"net"
+	"strings"
+)
+
+//go:embed oui.txt
+var ouiData []byte
+
+// vendors by OUI (first 3 bytes of a MAC address, ex: 'B8:27:EB')
+var ouiVendors = parseOUI(ouiData)
+
+func parseOUI(data []byte) map[string]string {
+	vendors := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		prefix, vendor, ok := strings.Cut(line, " ")
+		if ok {
+			vendors[strings.ToUpper(prefix)] = strings.TrimSpace(vendor)
+		}
+	}
+	return vendors
+}
+
+// VendorFromMAC returns the vendor of a MAC address from its OUI
+func VendorFromMAC(mac net.HardwareAddr) (string, bool) {
+	if len(mac) < 3 {
+		return "", false
+	}
+	// locally administered addresses (ex: randomized for privacy) have no vendor, apart from a few well-known ones
+	vendor, ok := ouiVendors[strings.ToUpper(mac[:3].String())]
+	return vendor, ok
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gitlab.com/patopest/mdns-discovery/app/table"
+	"gitlab.com/patopest/mdns-discovery/export"
+)
+
+func newExportCmd() *cobra.Command {
+	var timeout time.Duration
+
+	formats := []string{}
+	for _, format := range export.Formats {
+		formats = append(formats, fmt.Sprintf("  %-9s %s", format.Name, format.Description))
+	}
+
+	cmd := &cobra.Command{
+		Use:   "export <format|template>",
+		Short: "Discover services for a while and render the hosts as config files (headless)",
+		Long: "Discover services for a while and render the hosts with a built-in format, a template from the config file " +
+			"or a Go text/template file.\n\nFormats:\n" + strings.Join(formats, "\n"),
+		Example: "  mdns-discovery export ssh >> ~/.ssh/config\n  mdns-discovery export ./inventory.tmpl --view lab",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			name := args[0]
+			if path, ok := cfg.Templates[name]; ok {
+				name = path
+			}
+			tmpl, err := export.Template(name)
+			if err != nil {
+				return err
+			}
+
+			t := table.New()
+			if name := viper.GetString("view"); name != "" {
+				view, ok := cfg.GetView(name)
+				if !ok {
+					return fmt.Errorf("unknown view '%s'", name)
+				}
+				t.ApplyView(view)
+			}
+			t.SetRows(discoverEntries(timeout))
+
+			return export.Render(os.Stdout, tmpl, t.VisibleEntries())
+		},
+	}
+
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 0, "How long to listen for services, the query timeout if 0")
+
+	return cmd
+}
@@ -0,0 +1,225 @@
+package export
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+//go:embed templates/*.tmpl
+var builtinFS embed.FS
+
+// Format is a built-in export template
+type Format struct {
+	Name        string // ex: 'ssh'
+	Title       string // ex: 'SSH config'
+	Description string
+}
+
+// Built-in formats, described by what they generate
+var Formats = []Format{
+	{"ssh", "SSH config", "~/.ssh/config Host blocks of the _ssh._tcp services"},
+	{"hosts", "hosts file", "/etc/hosts lines of the .local hosts"},
+	{"ansible", "Ansible inventory", "Ansible YAML inventory grouped by service type"},
+}
+
+// Data is passed to the export templates
+type Data struct {
+	Hosts     []Host // sorted by name
+	Generated time.Time
+}
+
+// Host is a discovered host and its services
+type Host struct {
+	Name     string // host name without the trailing dot, ex: 'Obelix.local'
+	IPv4     string
+	IPv6     string // with its zone if link-local, ex: 'fe80::1%eth0'
+	services []Service
+}
+
+// Service is a service instance of a host
+type Service struct {
+	Instance string // ex: 'Obelix'
+	Type     string // ex: '_ssh._tcp'
+	Host     string // ex: 'Obelix.local'
+	IP       string // IPv4 address, or IPv6 (with its zone if link-local) if it has none
+	Port     int
+	TXT      map[string]string
+}
+
+// NewData groups entries by host
+func NewData(entries []network.Entry) Data {
+	byName := map[string]*Host{}
+	names := []string{}
+	for _, entry := range entries {
+		name := entry.Hostname()
+		host, ok := byName[name]
+		if !ok {
+			host = &Host{Name: name}
+			byName[name] = host
+			names = append(names, name)
+		}
+		if host.IPv4 == "" && entry.AddrV4 != nil {
+			host.IPv4 = entry.AddrV4.String()
+		}
+		if host.IPv6 == "" && entry.AddrV6 != nil {
+			host.IPv6 = ipv6(entry)
+		}
+
+		service := Service{
+			Instance: entry.Instance(),
+			Type:     entry.ServiceType(),
+			Host:     name,
+			Port:     entry.Port,
+			TXT:      entry.TXT(),
+		}
+		if entry.AddrV4 != nil {
+			service.IP = entry.AddrV4.String()
+		} else if entry.AddrV6 != nil {
+			service.IP = ipv6(entry)
+		}
+		host.services = append(host.services, service)
+	}
+
+	slices.SortFunc(names, func(a, b string) int { return strings.Compare(strings.ToLower(a), strings.ToLower(b)) })
+	data := Data{Generated: time.Now()}
+	for _, name := range names {
+		data.Hosts = append(data.Hosts, *byName[name])
+	}
+	return data
+}
+
+// ipv6 returns the entry's IPv6 address, with the zone of the interface it was received on if it is link-local
+// (without it, the address can't be reached), ex: 'fe80::1%eth0'
+func ipv6(entry network.Entry) string {
+	ip := entry.AddrV6.String()
+	if !entry.AddrV6.IsLinkLocalUnicast() {
+		return ip
+	}
+	zone := entry.Interface
+	if entry.AddrV6IPAddr != nil && entry.AddrV6IPAddr.Zone != "" {
+		zone = entry.AddrV6IPAddr.Zone
+	}
+	if zone == "" {
+		return ip
+	}
+	return ip + "%" + zone
+}
+
+// Services returns the host's services of the given types, all of them if none is given
+func (h Host) Services(types ...string) []Service {
+	if len(types) == 0 {
+		return h.services
+	}
+	services := []Service{}
+	for _, service := range h.services {
+		if slices.Contains(types, service.Type) {
+			services = append(services, service)
+		}
+	}
+	return services
+}
+
+// ServiceTypes returns the sorted service types of all hosts
+func (d Data) ServiceTypes() []string {
+	types := []string{}
+	for _, host := range d.Hosts {
+		for _, service := range host.services {
+			if service.Type != "" && !slices.Contains(types, service.Type) {
+				types = append(types, service.Type)
+			}
+		}
+	}
+	slices.Sort(types)
+	return types
+}
+
+// HostsOf returns the hosts having a service of the given type
+func (d Data) HostsOf(serviceType string) []Host {
+	hosts := []Host{}
+	for _, host := range d.Hosts {
+		if len(host.Services(serviceType)) > 0 {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+var (
+	nonIdentifier = regexp.MustCompile(`[^a-z0-9_]+`)
+	nonHostname   = regexp.MustCompile(`[^\p{Ll}\p{N}_-]+`)
+)
+
+// funcs are the helpers available in templates in addition to the text/template built-in ones
+var funcs = template.FuncMap{
+	"alias":      alias,
+	"group":      group,
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"join":       strings.Join,
+	"replace":    strings.ReplaceAll,
+	"hasPrefix":  strings.HasPrefix,
+	"hasSuffix":  strings.HasSuffix,
+	"trimSuffix": strings.TrimSuffix,
+}
+
+// alias returns a short lowercase name of a host, ex: 'Obelix.local' -> 'obelix'
+func alias(host string) string {
+	name, _, _ := strings.Cut(host, ".")
+	return strings.Trim(nonHostname.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// group returns an identifier (ex: an Ansible group name) for a service type, ex: '_device-info._tcp' -> 'device_info'
+func group(serviceType string) string {
+	name := strings.TrimSuffix(strings.TrimSuffix(serviceType, "._tcp"), "._udp")
+	name = strings.Trim(nonIdentifier.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "service_" + name
+	}
+	return name
+}
+
+// Template returns the template of a built-in format, or parses the template file at path otherwise
+func Template(nameOrPath string) (*template.Template, error) {
+	if slices.ContainsFunc(Formats, func(f Format) bool { return f.Name == nameOrPath }) {
+		return template.New(nameOrPath+".tmpl").Funcs(funcs).ParseFS(builtinFS, "templates/"+nameOrPath+".tmpl")
+	}
+
+	path := expandHome(nameOrPath)
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unknown format or template file '%s': %w", nameOrPath, err)
+	}
+	return template.New(filepath.Base(path)).Funcs(funcs).Parse(string(text))
+}
+
+// Render writes the entries with a template
+func Render(w io.Writer, tmpl *template.Template, entries []network.Entry) error {
+	return tmpl.Execute(w, NewData(entries))
+}
+
+// RenderString returns the entries rendered with a template
+func RenderString(tmpl *template.Template, entries []network.Entry) (string, error) {
+	var buf strings.Builder
+	err := Render(&buf, tmpl, entries)
+	return buf.String(), err
+}
+
+// expandHome replaces a leading '~/' by the user's home directory
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -0,0 +1,109 @@
+package export
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// entries are the services found in the tests
+var entries = []network.Entry{
+	entry("Obelix._ssh._tcp.local.", "Obelix.local.", "eth0", "192.168.1.10", "fe80::1c2d:3eff:fe4f:5a6b", 22),
+	entry("Obelix._smb._tcp.local.", "Obelix.local.", "eth0", "192.168.1.10", "fe80::1c2d:3eff:fe4f:5a6b", 445),
+	entry("Asterix._ssh._tcp.local.", "asterix.local.", "eth0", "192.168.1.11", "", 2222),
+	entry("Asterix._device-info._tcp.local.", "asterix.local.", "eth0", "", "", 0, "model=MacBookPro18,3"),
+	entry("Office Printer._ipp._tcp.local.", "printer.local.", "wlan0", "", "fe80::be:4ff:fe12:3456", 631, "rp=ipp/print"),
+	entry("nas._ssh._tcp.local.", "nas.home.arpa.", "eth0", "", "2001:db8::20", 22),
+	entry("Living Room._airplay._tcp.local.", "Living-Room.local.", "eth0", "192.168.1.30", "2001:db8::30", 7000),
+}
+
+func entry(name, host, iface, ipv4, ipv6 string, port int, txt ...string) network.Entry {
+	e := network.Entry{ServiceEntry: network.ServiceEntry{Name: name, Host: host, Port: port, InfoFields: txt}, Interface: iface}
+	if ipv4 != "" {
+		e.AddrV4 = net.ParseIP(ipv4)
+	}
+	if ipv6 != "" {
+		e.AddrV6 = net.ParseIP(ipv6)
+	}
+	return e
+}
+
+func TestTemplates(t *testing.T) {
+	for _, format := range Formats {
+		t.Run(format.Name, func(t *testing.T) {
+			tmpl, err := Template(format.Name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := RenderString(tmpl, entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden(t, got, "testdata/"+format.Name+".golden")
+		})
+	}
+}
+
+func TestTemplateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "names.tmpl")
+	text := "{{range .Hosts}}{{alias .Name}}: {{range .Services}}{{group .Type}} {{end}}\n{{end}}"
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := Template(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := RenderString(tmpl, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "asterix: ssh device_info \nliving-room: airplay \nnas: ssh \nobelix: ssh smb \nprinter: ipp \n"
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := Template(filepath.Join(t.TempDir(), "missing.tmpl")); err == nil {
+		t.Error("no error for a missing template file")
+	}
+}
+
+func TestIPv6Zone(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry network.Entry
+		want  string
+	}{
+		{"global", entry("a._ssh._tcp.local.", "a.local.", "eth0", "", "2001:db8::1", 22), "2001:db8::1"},
+		{"link-local", entry("a._ssh._tcp.local.", "a.local.", "eth0", "", "fe80::1", 22), "fe80::1%eth0"},
+		{"link-local without interface", entry("a._ssh._tcp.local.", "a.local.", "", "", "fe80::1", 22), "fe80::1"},
+		{"zone of the address", func() network.Entry {
+			e := entry("a._ssh._tcp.local.", "a.local.", "", "", "fe80::1", 22)
+			e.AddrV6IPAddr = &net.IPAddr{IP: e.AddrV6, Zone: "en0"}
+			return e
+		}(), "fe80::1%en0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ipv6(tt.entry); got != tt.want {
+				t.Errorf("ipv6() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// golden compares the output of a test to the content of a file
+func golden(t *testing.T, got, path string) {
+	t.Helper()
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("got:\n%s\nwant (%s):\n%s", got, path, want)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gitlab.com/patopest/mdns-discovery/history"
+)
+
+func newHistoryCmd() *cobra.Command {
+	var since string
+	var until string
+	var query history.Query
+	var details bool
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "history [filter]",
+		Short: "Query the history of the services seen while recording with --history",
+		Example: `  mdns-discovery history --since 24h --new          # what joined the network in the last day
+  mdns-discovery history obelix --details           # when was Obelix online
+  mdns-discovery history --service _ipp._tcp --since 2026-10-01 --until 2026-10-07`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			now := time.Now()
+			var err error
+			if query.Since, err = parseTime(since, now); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			if query.Until, err = parseTime(until, now); err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+			if len(args) > 0 {
+				query.Filter = args[0]
+			}
+
+			store, err := history.Open(history.DefaultPath(), discoveryOptions().EntryTTL())
+			if err != nil {
+				return fmt.Errorf("failed to read history database %s: %w", store.Path(), err)
+			}
+			instances := store.Instances(query)
+
+			switch output {
+			case "table":
+				if details {
+					return printHistoryDetails(os.Stdout, instances, now, store.Gap)
+				}
+				return printHistoryTable(os.Stdout, instances, now, store.Gap)
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(instances)
+			default:
+				return fmt.Errorf("unknown output format '%s'", output)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&since, "since", "", "", "Only services seen since this time, ex: '24h' (ago), '2026-10-18' or '2026-10-18T08:00'")
+	cmd.Flags().StringVarP(&until, "until", "", "", "Only services seen until this time, same formats as --since")
+	cmd.Flags().BoolVarP(&query.New, "new", "", false, "Only services first seen between --since and --until")
+	cmd.Flags().StringVarP(&query.Service, "service", "s", "", "Only services of this type, ex: '_ssh._tcp'")
+	cmd.Flags().BoolVarP(&details, "details", "", false, "Print the presence intervals, addresses and TXT record changes of each service")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format: 'table' or 'json'")
+
+	return cmd
+}
+
+// openHistory opens the history store if recording is enabled with --history, returns nil otherwise
+func openHistory() (*history.Store, error) {
+	if !viper.GetBool("history") {
+		return nil, nil
+	}
+	store, err := history.Open(history.DefaultPath(), discoveryOptions().EntryTTL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history database %s: %w", store.Path(), err)
+	}
+	return store, nil
+}
+
+// recordHistory opens the history store if recording is enabled with --history and saves it periodically
+// until ctx is cancelled. Call save before exiting to save it a last time.
+func recordHistory(ctx context.Context, w io.Writer) (store *history.Store, save func(), err error) {
+	store, err = openHistory()
+	if err != nil || store == nil {
+		return nil, func() {}, err
+	}
+
+	logError := func(err error) { fmt.Fprintf(w, "failed to save history database %s: %v\n", store.Path(), err) }
+	go store.AutoSave(ctx, logError)
+	return store, func() {
+		if err := store.Save(); err != nil {
+			logError(err)
+		}
+	}, nil
+}
+
+// parseTime parses a duration before now, a date or a date and time in the local timezone. Empty is the zero time.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{"2006-01-02", "2006-01-02T15:04", "2006-01-02 15:04", time.RFC3339} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("'%s' is neither a duration nor a date", s)
+}
+
+// printHistoryTable writes one line per instance, online if seen within gap
+func printHistoryTable(w io.Writer, instances []history.Instance, now time.Time, gap time.Duration) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSERVICE\tHOSTNAME\tADDRESSES\tFIRST SEEN\tLAST SEEN")
+	for _, instance := range instances {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			instance.Name,
+			instance.Service,
+			instance.Hostname,
+			strings.Join(instance.IPs(), ","),
+			history.FormatTime(instance.FirstSeen, now),
+			lastSeen(instance, now, gap),
+		)
+	}
+	return tw.Flush()
+}
+
+// printHistoryDetails writes everything recorded about each instance, online if seen within gap
+func printHistoryDetails(w io.Writer, instances []history.Instance, now time.Time, gap time.Duration) error {
+	for i, instance := range instances {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, instance.Name)
+		fmt.Fprintf(w, "  hostname:   %s:%d\n", instance.Hostname, instance.Port)
+		fmt.Fprintf(w, "  interfaces: %s\n", strings.Join(instance.Interfaces, ", "))
+		fmt.Fprintf(w, "  first seen: %s\n", history.FormatTime(instance.FirstSeen, now))
+		fmt.Fprintf(w, "  last seen:  %s\n", lastSeen(instance, now, gap))
+
+		fmt.Fprintln(w, "  presence:")
+		for _, interval := range instance.Presence {
+			fmt.Fprintf(w, "    %s → %s (%s)\n", history.FormatTime(interval.Start, now), history.FormatTime(interval.End, now),
+				history.FormatDuration(interval.End.Sub(interval.Start)))
+		}
+		fmt.Fprintln(w, "  addresses:")
+		for _, address := range instance.Addresses {
+			fmt.Fprintf(w, "    %s (%s → %s)\n", address.IP, history.FormatTime(address.FirstSeen, now), history.FormatTime(address.LastSeen, now))
+		}
+		fmt.Fprintln(w, "  txt:")
+		for _, record := range instance.TXT {
+			fields := []string{}
+			for _, key := range slices.Sorted(maps.Keys(record.TXT)) {
+				fields = append(fields, key+"="+record.TXT[key])
+			}
+			fmt.Fprintf(w, "    %s: %s\n", history.FormatTime(record.Time, now), strings.Join(fields, " "))
+		}
+	}
+	return nil
+}
+
+// lastSeen formats the last time an instance was seen, 'online' if it is still announced
+func lastSeen(instance history.Instance, now time.Time, gap time.Duration) string {
+	if instance.IsOnline(now, gap) {
+		return "online"
+	}
+	return history.FormatTime(instance.LastSeen, now)
+}
@@ -0,0 +1,393 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"gitlab.com/patopest/mdns-discovery/config"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+const (
+	HISTORY_FILE_NAME = "history.db"
+	// Written by the previous versions, imported when the database doesn't exist yet
+	LEGACY_HISTORY_FILE_NAME = "history.json"
+
+	// How often the store is written to disk while recording
+	SAVE_INTERVAL = time.Minute
+	// How long to wait for another process reading or saving the database
+	LOCK_TIMEOUT = 5 * time.Second
+)
+
+// Bucket of the database holding the instances as JSON, by name
+var instancesBucket = []byte("instances")
+
+// Interval is a period an instance was continuously announced
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"` // last time it was seen
+}
+
+// Address is an address an instance was announced with
+type Address struct {
+	IP        string    `json:"ip"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// TXTRecord is the TXT record of an instance from a given time on
+type TXTRecord struct {
+	Time time.Time         `json:"time"`
+	TXT  map[string]string `json:"txt"`
+}
+
+// Instance is everything recorded about a service instance
+type Instance struct {
+	Name       string      `json:"name"` // full instance name, ex: 'Obelix._ssh._tcp.local.'
+	Service    string      `json:"service"`
+	Hostname   string      `json:"hostname"`
+	Port       int         `json:"port"`
+	Interfaces []string    `json:"interfaces"`
+	FirstSeen  time.Time   `json:"first_seen"`
+	LastSeen   time.Time   `json:"last_seen"`
+	Addresses  []Address   `json:"addresses"`
+	TXT        []TXTRecord `json:"txt"`      // every change of the TXT record, oldest first
+	Presence   []Interval  `json:"presence"` // oldest first
+}
+
+// IsOnline returns whether the instance was seen less than gap (see Store.Gap) before now
+func (i Instance) IsOnline(now time.Time, gap time.Duration) bool {
+	return now.Sub(i.LastSeen) <= gap
+}
+
+// SeenBetween returns whether the instance was present at any time between since and until (zero for unbounded)
+func (i Instance) SeenBetween(since, until time.Time) bool {
+	return slices.ContainsFunc(i.Presence, func(interval Interval) bool {
+		return (since.IsZero() || !interval.End.Before(since)) && (until.IsZero() || !interval.Start.After(until))
+	})
+}
+
+// IPs returns the addresses the instance was announced with, most recently seen first
+func (i Instance) IPs() []string {
+	addresses := slices.Clone(i.Addresses)
+	slices.SortStableFunc(addresses, func(a, b Address) int { return b.LastSeen.Compare(a.LastSeen) })
+	ips := []string{}
+	for _, address := range addresses {
+		ips = append(ips, address.IP)
+	}
+	return ips
+}
+
+// Contains returns whether the name, hostname, an address, an interface or a TXT record ('key=value')
+// of the instance contains s, ignoring case
+func (i Instance) Contains(s string) bool {
+	fields := []string{i.Name, i.Hostname}
+	fields = append(fields, i.IPs()...)
+	fields = append(fields, i.Interfaces...)
+	for _, record := range i.TXT {
+		for key, value := range record.TXT {
+			fields = append(fields, key+"="+value)
+		}
+	}
+	s = strings.ToLower(s)
+	return slices.ContainsFunc(fields, func(field string) bool { return strings.Contains(strings.ToLower(field), s) })
+}
+
+// Query selects instances of the store
+type Query struct {
+	Since   time.Time // seen (or first seen if New) since, zero for unbounded
+	Until   time.Time // seen (or first seen if New) until, zero for unbounded
+	New     bool      // only instances first seen in the time range
+	Service string    // service type, ex: '_ssh._tcp'
+	Filter  string    // substring of the name, host, an address, an interface or a TXT record
+}
+
+// Matches returns whether an instance is selected by the query
+func (q Query) Matches(i Instance) bool {
+	if q.Service != "" && !strings.EqualFold(q.Service, i.Service) {
+		return false
+	}
+	if q.Filter != "" && !i.Contains(q.Filter) {
+		return false
+	}
+	if q.New {
+		return (q.Since.IsZero() || !i.FirstSeen.Before(q.Since)) && (q.Until.IsZero() || !i.FirstSeen.After(q.Until))
+	}
+	return i.SeenBetween(q.Since, q.Until)
+}
+
+// Store records every instance ever seen. It is kept in memory and saved to a bbolt database, where only the
+// instances changed since the last save are written.
+type Store struct {
+	Gap       time.Duration // an instance not seen for longer went offline, the next sighting starts a new presence interval
+	path      string
+	instances map[string]*Instance // by name
+	dirty     map[string]bool      // names of the instances changed since last saved
+	mu        sync.RWMutex
+}
+
+// DefaultPath returns the history database location following the XDG base directory spec
+func DefaultPath() string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return HISTORY_FILE_NAME
+		}
+		dir = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dir, config.APP_NAME, HISTORY_FILE_NAME)
+}
+
+// Open reads the store at path, with the presence gap of the discovery recording it (its entries' TTL). A missing
+// database is not an error and returns an empty store, or the one of the LEGACY_HISTORY_FILE_NAME next to it.
+func Open(path string, gap time.Duration) (*Store, error) {
+	s := &Store{Gap: gap, path: path, instances: map[string]*Instance{}, dirty: map[string]bool{}}
+
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return s, s.importLegacy(filepath.Join(filepath.Dir(path), LEGACY_HISTORY_FILE_NAME))
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: LOCK_TIMEOUT, ReadOnly: true})
+	if err != nil {
+		return s, err
+	}
+	defer db.Close()
+
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(instancesBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(name, data []byte) error {
+			instance := &Instance{}
+			if err := json.Unmarshal(data, instance); err != nil {
+				return err
+			}
+			s.instances[instance.Name] = instance
+			return nil
+		})
+	})
+	return s, err
+}
+
+// importLegacy reads the instances of a JSON history file, to be written to the database at the next Save.
+// A missing file is not an error.
+func (s *Store) importLegacy(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var instances []*Instance
+	if err := json.Unmarshal(data, &instances); err != nil {
+		return err
+	}
+	for _, instance := range instances {
+		s.instances[instance.Name] = instance
+		s.dirty[instance.Name] = true
+	}
+	return nil
+}
+
+// Path returns the database the store is read from and saved to
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Record adds a sighting of an entry, at its last seen time. A gone entry is not a sighting and is ignored.
+func (s *Store) Record(entry network.Entry) {
+	if entry.Gone {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := entry.LastSeen
+	instance, ok := s.instances[entry.Name]
+	if !ok {
+		instance = &Instance{Name: entry.Name, FirstSeen: now}
+		s.instances[entry.Name] = instance
+	}
+	s.dirty[entry.Name] = true
+
+	instance.Service = entry.ServiceType()
+	instance.Hostname = entry.Hostname()
+	instance.Port = entry.Port
+	if entry.Interface != "" && !slices.Contains(instance.Interfaces, entry.Interface) {
+		instance.Interfaces = append(instance.Interfaces, entry.Interface)
+	}
+	instance.LastSeen = now
+
+	// Presence
+	if n := len(instance.Presence); n > 0 && now.Sub(instance.Presence[n-1].End) <= s.Gap {
+		instance.Presence[n-1].End = now
+	} else {
+		instance.Presence = append(instance.Presence, Interval{Start: now, End: now})
+	}
+
+	// Addresses
+	for _, ip := range []string{ipString(entry.AddrV4), ipString(entry.AddrV6)} {
+		if ip == "" {
+			continue
+		}
+		idx := slices.IndexFunc(instance.Addresses, func(address Address) bool { return address.IP == ip })
+		if idx < 0 {
+			instance.Addresses = append(instance.Addresses, Address{IP: ip, FirstSeen: now, LastSeen: now})
+		} else {
+			instance.Addresses[idx].LastSeen = now
+		}
+	}
+
+	// TXT
+	txt := entry.TXT()
+	if n := len(instance.TXT); n == 0 || !maps.Equal(instance.TXT[n-1].TXT, txt) {
+		instance.TXT = append(instance.TXT, TXTRecord{Time: now, TXT: txt})
+	}
+}
+
+// Feed records the entries received on entries and passes them on to the returned channel, until ctx is cancelled
+func (s *Store) Feed(ctx context.Context, entries <-chan network.Entry) <-chan network.Entry {
+	out := make(chan network.Entry, cap(entries))
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case entry := <-entries:
+				s.Record(entry)
+				select {
+				case out <- entry:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// Instances returns the instances selected by the query, most recently seen first
+func (s *Store) Instances(q Query) []Instance {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	instances := []Instance{}
+	for _, instance := range s.instances {
+		if q.Matches(*instance) {
+			instances = append(instances, *instance)
+		}
+	}
+	slices.SortFunc(instances, func(a, b Instance) int {
+		if c := b.LastSeen.Compare(a.LastSeen); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return instances
+}
+
+// Save writes the instances changed since the last save to the database, creating it and its parent directories
+// if needed
+func (s *Store) Save() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.dirty) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	// Only readable by the user, opened for the time of the save to let the history command read it meanwhile
+	db, err := bolt.Open(s.path, 0o600, &bolt.Options{Timeout: LOCK_TIMEOUT})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(instancesBucket)
+		if err != nil {
+			return err
+		}
+		for name := range s.dirty {
+			data, err := json.Marshal(s.instances[name])
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(name), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	clear(s.dirty)
+	return nil
+}
+
+// AutoSave saves the store every SAVE_INTERVAL until ctx is cancelled. Save it a last time before exiting.
+func (s *Store) AutoSave(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(SAVE_INTERVAL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Save(); err != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// FormatTime formats a time relative to the day of now, ex: 'today 14:03', 'yesterday 09:12' or '2026-10-12 08:00'
+func FormatTime(t, now time.Time) string {
+	t = t.Local()
+	y, m, d := now.Local().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	switch {
+	case !t.Before(today):
+		return "today " + t.Format("15:04")
+	case !t.Before(today.AddDate(0, 0, -1)):
+		return "yesterday " + t.Format("15:04")
+	default:
+		return t.Format("2006-01-02 15:04")
+	}
+}
+
+// FormatDuration formats a duration to the minute, ex: '2h13m' or '<1m'
+func FormatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	return strings.TrimSuffix(d.Truncate(time.Minute).String(), "0s")
+}
+
+func ipString(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	return ip.String()
+}
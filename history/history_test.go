@@ -0,0 +1,125 @@
+package history
+
+import (
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+func sighting(name, ip string, at time.Time) network.Entry {
+	return network.Entry{
+		ServiceEntry: network.ServiceEntry{Name: name, Host: "printer.local.", Port: 631, AddrV4: net.ParseIP(ip), InfoFields: []string{"rp=ipp/print"}},
+		Interface:    "eth0",
+		LastSeen:     at,
+	}
+}
+
+func TestPresence(t *testing.T) {
+	start := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		gap       time.Duration
+		sightings []time.Duration // after start
+		want      int             // presence intervals
+	}{
+		{"continuous", 30 * time.Second, []time.Duration{0, 20 * time.Second, 40 * time.Second}, 1},
+		{"gap", 30 * time.Second, []time.Duration{0, 20 * time.Second, time.Minute}, 2},
+		{"gap of the backoff", 3 * time.Hour, []time.Duration{0, time.Hour, 3 * time.Hour}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Open(filepath.Join(t.TempDir(), HISTORY_FILE_NAME), tt.gap)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range tt.sightings {
+				s.Record(sighting("Printer._ipp._tcp.local.", "192.168.1.2", start.Add(d)))
+			}
+			instance := s.Instances(Query{})[0]
+			if len(instance.Presence) != tt.want {
+				t.Errorf("%d presence intervals, want %d: %v", len(instance.Presence), tt.want, instance.Presence)
+			}
+			last := start.Add(tt.sightings[len(tt.sightings)-1])
+			if !instance.IsOnline(last.Add(tt.gap), s.Gap) || instance.IsOnline(last.Add(tt.gap+time.Second), s.Gap) {
+				t.Errorf("online until %v after the last sighting, want %v", tt.gap, tt.gap)
+			}
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mdns-discovery", HISTORY_FILE_NAME)
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+
+	s, err := Open(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("database written without changes: %v", err)
+	}
+
+	s.Record(sighting("Printer._ipp._tcp.local.", "192.168.1.2", now))
+	s.Record(sighting("Scanner._uscan._tcp.local.", "192.168.1.3", now))
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s.Record(sighting("Printer._ipp._tcp.local.", "192.168.1.4", now.Add(time.Minute)))
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := Open(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, want := reopened.Instances(Query{}), s.Instances(Query{})
+	if len(got) != 2 {
+		t.Fatalf("%d instances saved, want 2", len(got))
+	}
+	for i := range got {
+		gotJSON, _ := json.Marshal(got[i])
+		wantJSON, _ := json.Marshal(want[i])
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("instance saved as\n%s\nwant\n%s", gotJSON, wantJSON)
+		}
+	}
+}
+
+func TestImportLegacy(t *testing.T) {
+	dir := t.TempDir()
+	seen := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	instances := []Instance{{Name: "Printer._ipp._tcp.local.", Service: "_ipp._tcp", FirstSeen: seen, LastSeen: seen, Presence: []Interval{{seen, seen}}}}
+	data, err := json.Marshal(instances)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, LEGACY_HISTORY_FILE_NAME), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, HISTORY_FILE_NAME)
+	s, err := Open(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := Open(path, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reopened.Instances(Query{}); len(got) != 1 || got[0].Name != instances[0].Name {
+		t.Errorf("imported %v, want %v", got, instances)
+	}
+}
@@ -0,0 +1,315 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/api"
+	"gitlab.com/patopest/mdns-discovery/config"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+const (
+	DEFAULT_ATTEMPTS  = 4
+	RETRY_BACKOFF     = time.Second // delay before the first retry, doubled after each one
+	MAX_RETRY_BACKOFF = time.Minute
+	HOOK_TIMEOUT      = 30 * time.Second // of each webhook request or command run
+	ENV_PREFIX        = "MDNS_"
+)
+
+// Characters not allowed in environment variable names
+var nonEnvName = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// hook is a validated config.Hook
+type hook struct {
+	config.Hook
+	on       []network.EventType
+	debounce time.Duration
+	attempts int
+}
+
+// pending is a debounced event waiting for a service to settle
+type pending struct {
+	timer timer
+	event network.Event
+}
+
+// clock schedules the debounced events and the retries, replaced in tests
+type clock interface {
+	AfterFunc(d time.Duration, f func()) timer
+	After(d time.Duration) <-chan time.Time
+}
+
+// timer is the part of *time.Timer used
+type timer interface {
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// realClock is the clock of the time package
+type realClock struct{}
+
+func (realClock) AfterFunc(d time.Duration, f func()) timer { return time.AfterFunc(d, f) }
+func (realClock) After(d time.Duration) <-chan time.Time    { return time.After(d) }
+
+// Runner fires the hooks matching the events of a registry
+type Runner struct {
+	Logger *log.Logger // logs the hooks fired and failed, the standard logger by default
+
+	hooks  []hook
+	client *http.Client
+	clock  clock
+
+	pending map[string]*pending // by hook and entry ID
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+}
+
+// New validates the hooks and returns a Runner for them
+func New(hooks []config.Hook) (*Runner, error) {
+	r := &Runner{
+		Logger:  log.Default(),
+		client:  &http.Client{Timeout: HOOK_TIMEOUT},
+		clock:   realClock{},
+		pending: map[string]*pending{},
+	}
+
+	for _, h := range hooks {
+		parsed, err := parseHook(h)
+		if err != nil {
+			return nil, fmt.Errorf("hook '%s': %w", h.Name, err)
+		}
+		r.hooks = append(r.hooks, parsed)
+	}
+	return r, nil
+}
+
+func parseHook(h config.Hook) (hook, error) {
+	parsed := hook{Hook: h, attempts: h.Attempts}
+
+	if (h.URL == "") == (strings.TrimSpace(h.Command) == "") {
+		return parsed, fmt.Errorf("one of url or command is required")
+	}
+	if parsed.attempts <= 0 {
+		parsed.attempts = DEFAULT_ATTEMPTS
+	}
+	if h.Debounce != "" {
+		debounce, err := time.ParseDuration(h.Debounce)
+		if err != nil {
+			return parsed, fmt.Errorf("invalid debounce: %w", err)
+		}
+		parsed.debounce = debounce
+	}
+
+	types := []network.EventType{network.EVENT_ADDED, network.EVENT_UPDATED, network.EVENT_REMOVED}
+	if len(h.On) == 0 {
+		parsed.on = types
+	}
+	for _, name := range h.On {
+		idx := slices.IndexFunc(types, func(t network.EventType) bool { return t.String() == name })
+		if idx < 0 {
+			return parsed, fmt.Errorf("unknown event '%s', expected added, updated or removed", name)
+		}
+		parsed.on = append(parsed.on, types[idx])
+	}
+	return parsed, nil
+}
+
+// Run fires the hooks for the events until ctx is cancelled or events is closed, then waits for the
+// hooks running. Debounced events not fired yet are dropped.
+func (r *Runner) Run(ctx context.Context, events <-chan network.Event) {
+	defer r.wg.Wait()
+	defer r.stopPending()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			r.Handle(ctx, event)
+		}
+	}
+}
+
+// Handle fires (or debounces) the hooks matching an event
+func (r *Runner) Handle(ctx context.Context, event network.Event) {
+	service := api.NewService(event.Entry)
+	for i, h := range r.hooks {
+		if !h.matches(event, service) {
+			continue
+		}
+		if h.debounce == 0 {
+			r.fire(ctx, h, event)
+			continue
+		}
+
+		key := fmt.Sprintf("%d/%s", i, event.Entry.ID())
+		r.mu.Lock()
+		if p, ok := r.pending[key]; ok && p.timer.Stop() {
+			p.event = event
+			p.timer.Reset(h.debounce)
+		} else {
+			p := &pending{event: event}
+			p.timer = r.clock.AfterFunc(h.debounce, func() {
+				r.mu.Lock()
+				if r.pending[key] == p {
+					delete(r.pending, key)
+				}
+				event := p.event
+				r.mu.Unlock()
+				r.fire(ctx, h, event)
+			})
+			r.pending[key] = p
+		}
+		r.mu.Unlock()
+	}
+}
+
+// stopPending drops the debounced events
+func (r *Runner) stopPending() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key, p := range r.pending {
+		p.timer.Stop()
+		delete(r.pending, key)
+	}
+}
+
+// matches returns whether an event is of the hook's types and its service passes the hook's filters
+func (h hook) matches(event network.Event, service api.Service) bool {
+	if !slices.Contains(h.on, event.Type) {
+		return false
+	}
+	if h.Service != "" && !strings.EqualFold(h.Service, service.Service) {
+		return false
+	}
+	return h.Filter == "" || service.Contains(h.Filter)
+}
+
+// fire runs a hook in the background, retrying with exponential backoff until it succeeds,
+// runs out of attempts or ctx is cancelled
+func (r *Runner) fire(ctx context.Context, h hook, event network.Event) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		backoff := RETRY_BACKOFF
+		for attempt := 1; ; attempt++ {
+			err := r.run(ctx, h, event)
+			if err == nil {
+				r.Logger.Printf("hooks: '%s' fired for %s %s", h.Name, event.Type, event.Entry.Name)
+				return
+			}
+			r.Logger.Printf("hooks: '%s' failed for %s %s (attempt %d/%d): %v", h.Name, event.Type, event.Entry.Name, attempt, h.attempts, err)
+			if attempt >= h.attempts {
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.clock.After(backoff):
+			}
+			backoff = min(2*backoff, MAX_RETRY_BACKOFF)
+		}
+	}()
+}
+
+// run fires a hook once
+func (r *Runner) run(ctx context.Context, h hook, event network.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, HOOK_TIMEOUT)
+	defer cancel()
+
+	payload := api.Event{Type: event.Type.String(), Service: api.NewService(event.Entry), Time: event.Time}
+	if h.URL != "" {
+		return r.post(ctx, h.URL, payload)
+	}
+	return runCommand(ctx, h.Command, payload)
+}
+
+// post sends the event as JSON to a webhook, failing on non-2xx responses
+func (r *Runner) post(ctx context.Context, url string, payload api.Event) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s responded %s", url, resp.Status)
+	}
+	return nil
+}
+
+// runCommand runs a command with the event in environment variables, failing on non-zero exit codes.
+// The command is not passed to a shell so values advertised on the network can't inject commands.
+func runCommand(ctx context.Context, command string, payload api.Event) error {
+	args := strings.Fields(command)
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), Environ(payload)...)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// Environ returns the environment variables describing an event: MDNS_EVENT, MDNS_NAME, MDNS_INSTANCE,
+// MDNS_SERVICE, MDNS_DOMAIN, MDNS_HOST, MDNS_IP (IPv4 if any, IPv6 otherwise), MDNS_IPV4, MDNS_IPV6,
+// MDNS_PORT, MDNS_INTERFACE and MDNS_TXT_<KEY> for each TXT record
+func Environ(payload api.Event) []string {
+	service := payload.Service
+	ip := service.IPv4
+	if ip == "" {
+		ip = service.IPv6
+	}
+
+	env := []string{
+		ENV_PREFIX + "EVENT=" + payload.Type,
+		ENV_PREFIX + "NAME=" + service.Name,
+		ENV_PREFIX + "INSTANCE=" + service.Instance,
+		ENV_PREFIX + "SERVICE=" + service.Service,
+		ENV_PREFIX + "DOMAIN=" + service.Domain,
+		ENV_PREFIX + "HOST=" + service.Hostname,
+		ENV_PREFIX + "IP=" + ip,
+		ENV_PREFIX + "IPV4=" + service.IPv4,
+		ENV_PREFIX + "IPV6=" + service.IPv6,
+		ENV_PREFIX + "PORT=" + strconv.Itoa(service.Port),
+		ENV_PREFIX + "INTERFACE=" + service.Interface,
+	}
+	for _, key := range slices.Sorted(maps.Keys(service.TXT)) {
+		name := nonEnvName.ReplaceAllString(strings.ToUpper(key), "_")
+		env = append(env, ENV_PREFIX+"TXT_"+name+"="+service.TXT[key])
+	}
+	return env
+}
@@ -0,0 +1,425 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/api"
+	"gitlab.com/patopest/mdns-discovery/config"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// fakeClock fires its timers when advanced, and its After channels right away, recording the delays
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Duration
+	timers []*fakeTimer
+	waits  []time.Duration // of After
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	at     time.Duration
+	f      func()
+	active bool
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, at: c.now + d, f: f, active: true}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- time.Time{}
+	return ch
+}
+
+// Advance moves the clock forward, calling the functions of the timers due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now += d
+	due := []func(){}
+	for _, t := range c.timers {
+		if t.active && t.at <= c.now {
+			t.active = false
+			due = append(due, t.f)
+		}
+	}
+	c.mu.Unlock()
+
+	for _, f := range due {
+		f()
+	}
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.active = false
+	return active
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.active
+	t.at = t.clock.now + d
+	t.active = true
+	return active
+}
+
+// webhook records the events posted to it, responding with the given status codes in turn (200 after them)
+type webhook struct {
+	*httptest.Server
+	mu       sync.Mutex
+	events   []api.Event
+	statuses []int
+}
+
+func newWebhook(t *testing.T, statuses ...int) *webhook {
+	w := &webhook{statuses: statuses}
+	w.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		var event api.Event
+		if err := json.NewDecoder(req.Body).Decode(&event); err != nil {
+			t.Errorf("invalid payload: %v", err)
+		}
+		if ct := req.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type %q, want application/json", ct)
+		}
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.events = append(w.events, event)
+		status := http.StatusOK
+		if len(w.statuses) > 0 {
+			status, w.statuses = w.statuses[0], w.statuses[1:]
+		}
+		rw.WriteHeader(status)
+	}))
+	t.Cleanup(w.Close)
+	return w
+}
+
+// received returns the event types and instances posted so far, ex: 'added Printer'
+func (w *webhook) received() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	received := []string{}
+	for _, event := range w.events {
+		received = append(received, event.Type+" "+event.Service.Instance)
+	}
+	return received
+}
+
+// newRunner returns a runner of the hooks with a fake clock, not logging
+func newRunner(t *testing.T, hooks ...config.Hook) (*Runner, *fakeClock) {
+	t.Helper()
+	r, err := New(hooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &fakeClock{}
+	r.clock = c
+	r.Logger = log.New(io.Discard, "", 0)
+	return r, c
+}
+
+func event(eventType network.EventType, instance string, txt ...string) network.Event {
+	return network.Event{
+		Type: eventType,
+		Entry: network.Entry{
+			ServiceEntry: network.ServiceEntry{
+				Name:       instance + "._http._tcp.local.",
+				Host:       strings.ToLower(instance) + ".local.",
+				Port:       80,
+				AddrV4:     net.IPv4(192, 168, 1, 2),
+				InfoFields: txt,
+			},
+			Interface: "eth0",
+		},
+		Time: time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestParseHook(t *testing.T) {
+	tests := []struct {
+		name     string
+		hook     config.Hook
+		on       []network.EventType
+		attempts int
+		debounce time.Duration
+		err      string
+	}{
+		{"defaults", config.Hook{URL: "http://localhost"},
+			[]network.EventType{network.EVENT_ADDED, network.EVENT_UPDATED, network.EVENT_REMOVED}, DEFAULT_ATTEMPTS, 0, ""},
+		{"all set", config.Hook{Command: "notify-send", On: []string{"removed", "added"}, Attempts: 1, Debounce: "10s"},
+			[]network.EventType{network.EVENT_REMOVED, network.EVENT_ADDED}, 1, 10 * time.Second, ""},
+		{"neither url nor command", config.Hook{Command: "  "}, nil, 0, 0, "one of url or command is required"},
+		{"both url and command", config.Hook{URL: "http://localhost", Command: "true"}, nil, 0, 0, "one of url or command is required"},
+		{"invalid debounce", config.Hook{URL: "http://localhost", Debounce: "10"}, nil, 0, 0, "invalid debounce"},
+		{"unknown event", config.Hook{URL: "http://localhost", On: []string{"changed"}}, nil, 0, 0, "unknown event 'changed'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := parseHook(tt.hook)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(h.on, tt.on) || h.attempts != tt.attempts || h.debounce != tt.debounce {
+				t.Errorf("on %v, attempts %d, debounce %v, want %v, %d, %v", h.on, h.attempts, h.debounce, tt.on, tt.attempts, tt.debounce)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		name  string
+		hook  config.Hook
+		event network.Event
+		want  bool
+	}{
+		{"any", config.Hook{}, event(network.EVENT_ADDED, "Printer"), true},
+		{"event type", config.Hook{On: []string{"removed"}}, event(network.EVENT_REMOVED, "Printer"), true},
+		{"other event type", config.Hook{On: []string{"removed"}}, event(network.EVENT_ADDED, "Printer"), false},
+		{"service", config.Hook{Service: "_HTTP._tcp"}, event(network.EVENT_ADDED, "Printer"), true},
+		{"other service", config.Hook{Service: "_ipp._tcp"}, event(network.EVENT_ADDED, "Printer"), false},
+		{"filter on name", config.Hook{Filter: "print"}, event(network.EVENT_ADDED, "Printer"), true},
+		{"filter on address", config.Hook{Filter: "192.168.1."}, event(network.EVENT_ADDED, "Printer"), true},
+		{"filter on TXT", config.Hook{Filter: "path=/admin"}, event(network.EVENT_ADDED, "Printer", "path=/admin"), true},
+		{"filter not matching", config.Hook{Filter: "scanner"}, event(network.EVENT_ADDED, "Printer"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.hook.URL = "http://localhost"
+			h, err := parseHook(tt.hook)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := h.matches(tt.event, api.NewService(tt.event.Entry)); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	service := api.Service{
+		Name:      `Living\ Room._googlecast._tcp.local.`,
+		Instance:  "Living Room",
+		Service:   "_googlecast._tcp",
+		Domain:    "local",
+		Hostname:  "chromecast.local",
+		IPv6:      "fe80::1",
+		Port:      8009,
+		TXT:       map[string]string{"fn": "Living Room", "rs-id": "", "md": "Chromecast"},
+		Interface: "eth0",
+	}
+	want := []string{
+		"MDNS_EVENT=added",
+		`MDNS_NAME=Living\ Room._googlecast._tcp.local.`,
+		"MDNS_INSTANCE=Living Room",
+		"MDNS_SERVICE=_googlecast._tcp",
+		"MDNS_DOMAIN=local",
+		"MDNS_HOST=chromecast.local",
+		"MDNS_IP=fe80::1", // no IPv4
+		"MDNS_IPV4=",
+		"MDNS_IPV6=fe80::1",
+		"MDNS_PORT=8009",
+		"MDNS_INTERFACE=eth0",
+		"MDNS_TXT_FN=Living Room",
+		"MDNS_TXT_MD=Chromecast",
+		"MDNS_TXT_RS_ID=",
+	}
+	if got := Environ(api.Event{Type: "added", Service: service}); !slices.Equal(got, want) {
+		t.Errorf("Environ() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		statuses []int
+		requests int
+		waits    []time.Duration
+	}{
+		{"success", 0, nil, 1, []time.Duration{}},
+		{"retried", 0, []int{http.StatusInternalServerError, http.StatusBadGateway}, 3, []time.Duration{time.Second, 2 * time.Second}},
+		{"out of attempts", 3, []int{500, 500, 500, 500}, 3, []time.Duration{time.Second, 2 * time.Second}},
+		{"backoff capped", 10, []int{500, 500, 500, 500, 500, 500, 500, 500}, 9,
+			[]time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second, 32 * time.Second,
+				MAX_RETRY_BACKOFF, MAX_RETRY_BACKOFF}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newWebhook(t, tt.statuses...)
+			r, c := newRunner(t, config.Hook{Name: "webhook", URL: server.URL, Attempts: tt.attempts})
+
+			r.Handle(context.Background(), event(network.EVENT_ADDED, "Printer"))
+			r.wg.Wait()
+
+			if got := server.received(); len(got) != tt.requests {
+				t.Errorf("%d requests, want %d", len(got), tt.requests)
+			}
+			if !slices.Equal(c.waits, tt.waits) {
+				t.Errorf("waited %v between attempts, want %v", c.waits, tt.waits)
+			}
+		})
+	}
+}
+
+func TestWebhookCancelled(t *testing.T) {
+	server := newWebhook(t, 500, 500)
+	r, _ := newRunner(t, config.Hook{Name: "webhook", URL: server.URL})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.clock = cancellingClock{cancel}
+
+	r.Handle(ctx, event(network.EVENT_ADDED, "Printer"))
+	r.wg.Wait()
+
+	if got := server.received(); len(got) != 1 {
+		t.Errorf("%d requests after being cancelled, want 1", len(got))
+	}
+}
+
+// cancellingClock cancels a context while waiting to retry, never ending the wait
+type cancellingClock struct{ cancel context.CancelFunc }
+
+func (c cancellingClock) AfterFunc(d time.Duration, f func()) timer { return time.AfterFunc(d, f) }
+func (c cancellingClock) After(d time.Duration) <-chan time.Time {
+	c.cancel()
+	return nil
+}
+
+func TestDebounce(t *testing.T) {
+	server := newWebhook(t)
+	r, c := newRunner(t, config.Hook{Name: "webhook", URL: server.URL, Debounce: "10s"})
+	ctx := context.Background()
+
+	r.Handle(ctx, event(network.EVENT_ADDED, "Printer"))
+	c.Advance(5 * time.Second)
+	r.Handle(ctx, event(network.EVENT_UPDATED, "Printer", "rp=ipp/print"))
+	r.Handle(ctx, event(network.EVENT_ADDED, "Scanner"))
+	c.Advance(5 * time.Second)
+	r.wg.Wait()
+	if got := server.received(); len(got) > 0 {
+		t.Fatalf("fired %v before the services settled", got)
+	}
+
+	c.Advance(5 * time.Second)
+	r.wg.Wait()
+	got := server.received()
+	slices.Sort(got)
+	if want := []string{"added Scanner", "updated Printer"}; !slices.Equal(got, want) {
+		t.Errorf("fired %v, want only the last event of each service %v", got, want)
+	}
+
+	// settled, fired again after another debounce
+	r.Handle(ctx, event(network.EVENT_REMOVED, "Printer"))
+	c.Advance(10 * time.Second)
+	r.wg.Wait()
+	if got := server.received(); len(got) != 3 || got[2] != "removed Printer" {
+		t.Errorf("fired %v, want the removal last", got)
+	}
+}
+
+func TestDebounceDroppedOnStop(t *testing.T) {
+	server := newWebhook(t)
+	r, c := newRunner(t, config.Hook{Name: "webhook", URL: server.URL, Debounce: "10s"})
+
+	events := make(chan network.Event, 1)
+	events <- event(network.EVENT_ADDED, "Printer")
+	close(events)
+	r.Run(context.Background(), events)
+
+	c.Advance(time.Minute)
+	r.wg.Wait()
+	if got := server.received(); len(got) > 0 {
+		t.Errorf("fired %v after the runner stopped", got)
+	}
+}
+
+// TestHelperProcess is the command run by the hooks of TestCommand, not a test
+func TestHelperProcess(t *testing.T) {
+	out := os.Getenv("HOOKS_TEST_OUTPUT")
+	if out == "" {
+		return
+	}
+	if os.Getenv("MDNS_EVENT") == "removed" {
+		fmt.Fprintln(os.Stderr, "cannot handle removals")
+		os.Exit(3)
+	}
+	env := []string{}
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, ENV_PREFIX) {
+			env = append(env, kv)
+		}
+	}
+	slices.Sort(env)
+	if err := os.WriteFile(out, []byte(strings.Join(append(env, strings.Join(os.Args[2:], " ")), "\n")), 0o644); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func TestCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "env")
+	t.Setenv("HOOKS_TEST_OUTPUT", out)
+	command := os.Args[0] + " -test.run=^TestHelperProcess$ $MDNS_NAME"
+
+	payload := api.Event{Type: "added", Service: api.NewService(event(network.EVENT_ADDED, "Printer").Entry)}
+	if err := runCommand(context.Background(), command, payload); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(string(data), "\n")
+	for _, want := range []string{"MDNS_EVENT=added", "MDNS_INSTANCE=Printer", "MDNS_IPV4=192.168.1.2", "MDNS_PORT=80"} {
+		if !slices.Contains(lines, want) {
+			t.Errorf("%s not in the environment of the command: %v", want, lines)
+		}
+	}
+	if args := lines[len(lines)-1]; args != "$MDNS_NAME" {
+		t.Errorf("arguments %q, want '$MDNS_NAME' as is without a shell", args)
+	}
+
+	payload.Type = "removed"
+	err = runCommand(context.Background(), command, payload)
+	if err == nil || !strings.Contains(err.Error(), "exit status 3: cannot handle removals") {
+		t.Errorf("error %v, want the exit status and output of the command", err)
+	}
+}
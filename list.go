@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gitlab.com/patopest/mdns-discovery/app"
+	"gitlab.com/patopest/mdns-discovery/app/table"
+	"gitlab.com/patopest/mdns-discovery/catalog"
+	"gitlab.com/patopest/mdns-discovery/network"
+	"gitlab.com/patopest/mdns-discovery/probe"
+	"gitlab.com/patopest/mdns-discovery/txt"
+)
+
+func newListCmd() *cobra.Command {
+	var timeout time.Duration
+	var output string
+	var probing bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Discover services for a while and print them (headless)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+
+			t := table.New()
+			t.SetCatalog(catalog.New(cfg.Catalog))
+			neighbors := neighborTable()
+			t.SetNeighborTable(neighbors)
+			t.ApplyLayout(cfg.Current().Columns)
+			if name := viper.GetString("view"); name != "" {
+				view, ok := cfg.GetView(name)
+				if !ok {
+					return fmt.Errorf("unknown view '%s'", name)
+				}
+				t.ApplyView(view)
+			}
+
+			baseline, err := loadBaseline()
+			if err != nil {
+				return err
+			}
+			if baseline != nil {
+				t.SetBaseline(*baseline)
+			}
+
+			entries := discoverEntries(timeout)
+			neighbors.Refresh()
+			t.SetRows(entries)
+			if probing {
+				probeEntries(&t, entries)
+			}
+			if viper.GetBool("http-probe") {
+				fetchHTTPInfo(&t, entries)
+			}
+
+			switch output {
+			case "table":
+				return printTable(os.Stdout, &t)
+			case "json":
+				return printJSON(os.Stdout, &t)
+			default:
+				return fmt.Errorf("unknown output format '%s'", output)
+			}
+		},
+	}
+
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 0, "How long to listen for services, the query timeout if 0")
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format: 'table' or 'json'")
+	cmd.Flags().BoolVarP(&probing, "probe", "", false, "Check that the services are reachable (status column)")
+
+	return cmd
+}
+
+// discoverEntries runs a discovery for the given duration and returns all unique entries found
+func discoverEntries(timeout time.Duration) []network.Entry {
+	return discoverEntriesUntil(timeout, nil)
+}
+
+// discoverEntriesUntil runs a discovery for the given duration (the query timeout if 0), or until done (if not nil)
+// returns true for the entries found so far, and returns all unique entries found
+func discoverEntriesUntil(timeout time.Duration, done func([]network.Entry) bool) []network.Entry {
+	if viper.GetBool("fake") {
+		return network.FakeEntries(network.FakeDataLong)
+	}
+	if timeout <= 0 { // of the profile in use, as the config is loaded after the flags are defined
+		timeout = discoveryOptions().Timeout
+	}
+
+	entriesCh := make(chan network.Entry, 30)
+	network.InitDiscovery(viper.GetStringSlice("interface"), viper.GetStringSlice("domain"), discoveryOptions(), entriesCh)
+
+	entries := []network.Entry{}
+	deadline := time.After(timeout)
+	for {
+		select {
+		case entry := <-entriesCh:
+			if entry.Gone {
+				entries, _ = network.RemoveEntry(entries, entry)
+				continue
+			}
+			entries, _ = network.MergeEntry(entries, entry)
+			if done != nil && done(entries) {
+				return entries
+			}
+		case <-deadline:
+			return entries
+		}
+	}
+}
+
+// probeEntries checks the reachability of all entries and sets the results in the table
+func probeEntries(t *table.Model, entries []network.Entry) {
+	targets := []probe.Target{}
+	for _, entry := range entries {
+		if target, ok := probe.TargetOf(entry); ok {
+			targets = append(targets, target)
+		}
+	}
+
+	results := make(chan probe.TargetResult)
+	go probe.New().ProbeAll(context.Background(), targets, results)
+	for result := range results {
+		t.SetProbeResult(result.ID, result.Result)
+	}
+}
+
+// fetchHTTPInfo fetches the metadata of all web services and sets it in the table
+func fetchHTTPInfo(t *table.Model, entries []network.Entry) {
+	prober := probe.NewHTTPProber()
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	slots := make(chan struct{}, app.HTTP_PROBE_CONCURRENCY)
+
+	for _, entry := range entries {
+		target, ok := probe.HTTPTargetOf(entry)
+		if !ok {
+			continue
+		}
+		wg.Go(func() {
+			slots <- struct{}{}
+			info := prober.Fetch(context.Background(), target)
+			<-slots
+			mu.Lock()
+			t.SetHTTPInfo(target.ID, info)
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+}
+
+// printTable writes the visible rows and columns as an aligned text table
+func printTable(w io.Writer, t *table.Model) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	columns := t.Columns()
+	titles := []string{}
+	for _, col := range columns {
+		titles = append(titles, strings.ToUpper(col.Title()))
+	}
+	fmt.Fprintln(tw, strings.Join(titles, "\t"))
+
+	for _, row := range t.Rows() {
+		values := []string{}
+		for _, col := range columns {
+			values = append(values, row.GetString(col.Key()))
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// printJSON writes the visible rows as a JSON array of objects keyed by column, with their decoded TXT attributes
+// and HTTP metadata
+func printJSON(w io.Writer, t *table.Model) error {
+	objects := []map[string]any{}
+	for _, row := range t.Rows() {
+		object := map[string]any{}
+		for _, col := range t.Columns() {
+			object[col.Key()] = row.Get(col.Key())
+		}
+		if entry, ok := t.Entry(row); ok {
+			if info, ok := t.HTTPInfo(entry); ok {
+				object["http"] = info
+			}
+			decoded := map[string]string{}
+			for _, field := range txt.Decode(entry.ServiceType(), entry.TXT()) {
+				decoded[field.Key] = field.Value
+			}
+			if len(decoded) > 0 {
+				object["decoded"] = decoded
+			}
+		}
+		objects = append(objects, object)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(objects)
+}
@@ -11,6 +11,7 @@ import (
 	"runtime/debug"
 	"strings"
 	"text/template"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/fang/v2"
@@ -18,6 +19,8 @@ import (
 	"github.com/spf13/viper"
 
 	"gitlab.com/patopest/mdns-discovery/app"
+	"gitlab.com/patopest/mdns-discovery/config"
+	"gitlab.com/patopest/mdns-discovery/hooks"
 	"gitlab.com/patopest/mdns-discovery/network"
 )
 
@@ -69,40 +72,117 @@ func GetVersion() string {
 	return buf.String()
 }
 
+// neighborTable returns the neighbor table resolving MAC addresses: a file given on the command line,
+// fake MACs with fake data, the system's one otherwise
+func neighborTable() network.NeighborTable {
+	if path := viper.GetString("neighbors"); path != "" {
+		return network.NewARPFileTable(path)
+	}
+	if viper.GetBool("fake") {
+		return network.FakeNeighbors
+	}
+	return network.NewNeighborTable()
+}
+
 func main() {
 
+	var logFile *os.File // with --debug, closed on exit
+
 	var cmd = &cobra.Command{
 		Use:   "mdns-discovery",
 		Short: "A TUI for discovering mDNS services",
-		Run: func(cmd *cobra.Command, args []string) {
-
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
 			if viper.GetBool("debug") {
-				f, err := tea.LogToFile("debug.log", "")
+				var err error
+				logFile, err = tea.LogToFile("debug.log", "")
 				if err != nil {
 					log.Fatal("fatal:", err)
 					os.Exit(1)
 				}
-				defer f.Close()
 			} else {
 				log.SetOutput(io.Discard)
 			}
+		},
+		PersistentPostRun: func(cmd *cobra.Command, args []string) {
+			if logFile != nil {
+				logFile.Close()
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
 
 			log.Println("Hello! Starting up...")
 
-			var m *app.App
-			itfs := viper.GetStringSlice("interface")
-			domains := viper.GetStringSlice("domain")
+			cfg, err := loadConfig()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if err := app.SetTheme(cfg.Current()); err != nil {
+				fmt.Printf("%s: %v\n", cfg.Path(), err)
+				os.Exit(1)
+			}
 
+			m := app.NewApp(viper.GetStringSlice("interface"), discoveryDomains(), discoveryOptions(), cfg)
+			m.SetNeighborTable(neighborTable())
 			if viper.GetBool("fake") {
-				m = app.NewApp(itfs, []string{"test.com"})
 				m.InjectFakeData(network.FakeDataLong)
 				// m.InjectFakeData(network.FakeData)
-			} else {
-				m = app.NewApp(itfs, domains)
+			}
+
+			if viper.GetBool("http-probe") {
+				m.EnableHTTPProbe()
+			}
+
+			if len(cfg.Hooks) > 0 && !viper.GetBool("no-hooks") {
+				runner, err := hooks.New(cfg.Hooks)
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+				m.EnableHooks(runner)
+			}
+
+			if view := viper.GetString("view"); view != "" {
+				if err := m.ApplyView(view); err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+			}
+
+			baseline, err := loadBaseline()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if baseline != nil {
+				m.SetBaseline(*baseline)
+			}
+
+			store, err := openHistory()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if store != nil {
+				m.EnableHistory(store)
 			}
 
 			p := tea.NewProgram(m)
-			if _, err := p.Run(); err != nil {
+
+			// Apply the changes to the config file live
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if err := config.Watch(ctx, cfg.Path(), func() { p.Send(app.ConfigChangedMsg{Reload: reloadConfig}) }); err != nil {
+				log.Printf("failed to watch config file %s: %v", cfg.Path(), err)
+			}
+
+			_, err = p.Run()
+			if store != nil {
+				if err := store.Save(); err != nil {
+					fmt.Printf("Failed to save history database %s: %v\n", store.Path(), err)
+				}
+			}
+			if err != nil {
 				fmt.Printf("Alas, there's been an error: %v", err)
 				os.Exit(1)
 			}
@@ -111,26 +191,63 @@ func main() {
 
 	var ifaces []string
 	var domain []string
+	var view string
 	var debugFile bool
 	var fake bool
-
-	cmd.Flags().StringSliceVarP(&ifaces, "interface", "i", nil, "Use specified interface(s). ex: '-i eth0,wlan0' (default: all available interfaces)")
-	cmd.Flags().StringSliceVarP(&domain, "domain", "d", []string{network.DEFAULT_DOMAIN}, "Domain(s) to use, usually '.local' !!! Do not change unless you know what you're doing !!!")
-	cmd.Flags().BoolVarP(&debugFile, "debug", "", false, "Write logs to file")
-	cmd.Flags().BoolVarP(&fake, "fake", "", false, "Use fake data instead")
-
-	cmd.Flags().MarkHidden("debug")
-	cmd.Flags().MarkHidden("fake")
+	var neighbors string
+	var httpProbe bool
+	var noHooks bool
+	var recordHistory bool
+	var baseline string
+	var configFile string
+	var profile string
+	var interval time.Duration
+	var queryTimeout time.Duration
+	var backoff bool
+	var passive bool
+
+	cmd.PersistentFlags().StringSliceVarP(&ifaces, "interface", "i", nil, "Use specified interface(s). ex: '-i eth0,wlan0' (default: all available interfaces)")
+	cmd.PersistentFlags().StringSliceVarP(&domain, "domain", "d", []string{network.DEFAULT_DOMAIN}, "Domain(s) to use, usually '.local' !!! Do not change unless you know what you're doing !!!")
+	cmd.PersistentFlags().DurationVarP(&interval, "interval", "", network.QUERY_INTERVAL*time.Second, "Interval between queries")
+	cmd.PersistentFlags().DurationVarP(&queryTimeout, "query-timeout", "", network.QUERY_TIMEOUT*time.Second, "How long to wait for the answers of a query: default --timeout of the headless commands, and answers window of the metrics")
+	cmd.PersistentFlags().BoolVarP(&backoff, "backoff", "", false, "Query with an exponential backoff (1s doubling up to 60min, RFC 6762) instead of at interval, to spare congested networks")
+	cmd.PersistentFlags().BoolVarP(&passive, "passive", "", false, "Never query, only listen to the announcements and the responses to other hosts' queries, for networks forbidding active probing")
+	cmd.PersistentFlags().StringVarP(&configFile, "config", "", "", "Config file to use (default: $XDG_CONFIG_HOME/mdns-discovery/config.yaml)")
+	cmd.PersistentFlags().StringVarP(&profile, "profile", "", "", "Use the settings of a profile of the config file (interfaces, domains, services, interval, columns, theme and keys)")
+	cmd.PersistentFlags().StringVarP(&view, "view", "", "", "Apply a saved view (filter, sort and columns) from the config file")
+	cmd.PersistentFlags().BoolVarP(&debugFile, "debug", "", false, "Write logs to file")
+	cmd.PersistentFlags().BoolVarP(&fake, "fake", "", false, "Use fake data instead")
+	cmd.PersistentFlags().BoolVarP(&httpProbe, "http-probe", "", false, "Fetch the page of HTTP(S) services for their status, server, title and certificate")
+	cmd.PersistentFlags().StringVarP(&baseline, "baseline", "", "", "Compare the services to a snapshot, see the snapshot command (diff column)")
+	cmd.PersistentFlags().BoolVarP(&recordHistory, "history", "", false, "Record the services seen in the history file, see the history command")
+	cmd.PersistentFlags().BoolVarP(&noHooks, "no-hooks", "", false, "Don't fire the hooks of the config file")
+	cmd.PersistentFlags().StringVarP(&neighbors, "neighbors", "", "", "Read MAC addresses from a neighbor table file in the /proc/net/arp format")
+
+	cmd.PersistentFlags().MarkHidden("debug")
+	cmd.PersistentFlags().MarkHidden("fake")
+	cmd.PersistentFlags().MarkHidden("neighbors")
 
 	cmd.SetVersionTemplate(GetVersion())
 
+	cmd.AddCommand(newListCmd())
+	cmd.AddCommand(newExportCmd())
+	cmd.AddCommand(newSnapshotCmd())
+	cmd.AddCommand(newDiffCmd())
+	cmd.AddCommand(newPrometheusCmd())
+	cmd.AddCommand(newServeCmd())
+	cmd.AddCommand(newHistoryCmd())
+	cmd.AddCommand(newCheckCmd())
+
 	// env variable bindings
-	viper.BindPFlags(cmd.Flags())
+	viper.BindPFlags(cmd.PersistentFlags())
 	viper.SetEnvPrefix("mdns")
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	viper.AutomaticEnv()
 
 	if err := fang.Execute(context.Background(), cmd); err != nil {
+		if logFile != nil {
+			logFile.Close()
+		}
 		os.Exit(1)
 	}
 }
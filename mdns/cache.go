@@ -0,0 +1,272 @@
+package mdns
+
+import (
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// Set in the class of the records replacing all the previous ones with the same name and type, RFC 6762 section 10.2
+	CACHE_FLUSH_BIT = 1 << 15
+	// Records older than this are flushed by a record with the cache-flush bit
+	CACHE_FLUSH_DELAY = time.Second
+)
+
+// cachedRecord is a record received in a response, valid until it expires
+type cachedRecord struct {
+	rr       dns.RR
+	received time.Time
+	expires  time.Time
+}
+
+// remaining returns the part of the TTL left, between 0 and 1
+func (r cachedRecord) remaining(now time.Time) float64 {
+	ttl := r.expires.Sub(r.received)
+	if ttl <= 0 {
+		return 0
+	}
+	return float64(r.expires.Sub(now)) / float64(ttl)
+}
+
+// A Cache keeps the records of the mDNS responses received, to build the service entries from them and to tell the
+// responders which answers are already known (RFC 6762 section 7.1). The zero value is an empty cache.
+type Cache struct {
+	mu      sync.Mutex
+	records []cachedRecord
+}
+
+// Add adds the records of a response, replacing the same ones received before. It returns the entries of the
+// instances removed by a goodbye record (RFC 6762 section 10.1) of their PTR or SRV record.
+func (c *Cache) Add(records []dns.RR, now time.Time) []*ServiceEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	goodbyes := []*ServiceEntry{}
+	for _, rr := range records {
+		h := rr.Header()
+		if instance := goodbyeInstance(rr); h.Ttl == 0 && instance != "" { // entry built before its record is removed
+			seen := slices.ContainsFunc(goodbyes, func(e *ServiceEntry) bool { return strings.EqualFold(e.Name, instance) })
+			if entry, _ := c.entry(instance, nil); entry != nil && !seen {
+				goodbyes = append(goodbyes, entry)
+			}
+		}
+		if h.Class&CACHE_FLUSH_BIT != 0 {
+			c.records = slices.DeleteFunc(c.records, func(r cachedRecord) bool {
+				return sameRRset(r.rr, rr) && now.Sub(r.received) > CACHE_FLUSH_DELAY
+			})
+		}
+		c.records = slices.DeleteFunc(c.records, func(r cachedRecord) bool { return sameRecord(r.rr, rr) })
+		if h.Ttl == 0 { // goodbye, RFC 6762 section 10.1
+			continue
+		}
+		c.records = append(c.records, cachedRecord{rr: rr, received: now, expires: now.Add(time.Duration(h.Ttl) * time.Second)})
+	}
+
+	// An instance is gone once it has no SRV record or no PTR record pointing to it anymore
+	return slices.DeleteFunc(goodbyes, func(e *ServiceEntry) bool {
+		return len(c.lookup(e.Name, dns.TypeSRV)) > 0 && slices.ContainsFunc(c.records, func(r cachedRecord) bool {
+			ptr, ok := r.rr.(*dns.PTR)
+			return ok && strings.EqualFold(ptr.Ptr, e.Name)
+		})
+	})
+}
+
+// goodbyeInstance returns the instance a goodbye record removes the PTR or SRV record of, "" for other records
+func goodbyeInstance(rr dns.RR) string {
+	switch rr := rr.(type) {
+	case *dns.PTR:
+		return rr.Ptr // a service type for the META_QUERY, without an entry
+	case *dns.SRV:
+		return rr.Hdr.Name
+	}
+	return ""
+}
+
+// Expire removes the expired records
+func (c *Cache) Expire(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.records = slices.DeleteFunc(c.records, func(r cachedRecord) bool { return !now.Before(r.expires) })
+}
+
+// Clear removes all the records
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.records = nil
+}
+
+// lookup returns the records with the given name and type. Must be called with the lock held.
+func (c *Cache) lookup(name string, rrtype uint16) []cachedRecord {
+	found := []cachedRecord{}
+	for _, r := range c.records {
+		if r.rr.Header().Rrtype == rrtype && strings.EqualFold(r.rr.Header().Name, name) {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+// ServiceTypes returns the service types enumerated in the domain, ex: '_http._tcp.local.'
+func (c *Cache) ServiceTypes(domain string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	types := []string{}
+	for _, r := range c.lookup(Fqdn(META_QUERY, domain), dns.TypePTR) {
+		types = append(types, r.rr.(*dns.PTR).Ptr)
+	}
+	return types
+}
+
+// Known returns the PTR records with the given name with more than half their TTL left, which don't need to be
+// answered again, ex: the service types of META_QUERY
+func (c *Cache) Known(name string, now time.Time) []dns.RR {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	known := []dns.RR{}
+	for _, r := range c.lookup(name, dns.TypePTR) {
+		if r.remaining(now) > 0.5 {
+			known = append(known, withTTL(r.rr, r.expires.Sub(now)))
+		}
+	}
+	return known
+}
+
+// instances returns the PTR records of the service instances of a type (all types if META_QUERY) in the domain.
+// Must be called with the lock held.
+func (c *Cache) instances(service, domain string) []cachedRecord {
+	if service != META_QUERY {
+		return c.lookup(Fqdn(service, domain), dns.TypePTR)
+	}
+
+	meta := Fqdn(META_QUERY, domain)
+	found := []cachedRecord{}
+	for _, r := range c.records {
+		ptr, ok := r.rr.(*dns.PTR)
+		if !ok {
+			continue
+		}
+		name := strings.ToLower(ptr.Hdr.Name)
+		if name == meta || !strings.HasPrefix(name, "_") || !strings.HasSuffix(name, "."+strings.ToLower(domain)+".") {
+			continue
+		}
+		if !strings.HasSuffix(strings.ToLower(ptr.Ptr), "."+name) { // subtype, ex: '_printer._sub._http._tcp.local.'
+			continue
+		}
+		found = append(found, r)
+	}
+	return found
+}
+
+// Entries returns the complete entries (with an address, a port and a TXT record) of the instances of a service type
+// (all types if META_QUERY), and the PTR records of the ones whose records have more than half their TTL left,
+// which don't need to be answered again
+func (c *Cache) Entries(service, domain string, iface *net.Interface, now time.Time) ([]*ServiceEntry, []dns.RR) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries := []*ServiceEntry{}
+	known := []dns.RR{}
+	seen := map[string]bool{}
+	for _, ptr := range c.instances(service, domain) {
+		instance := ptr.rr.(*dns.PTR).Ptr
+		if seen[strings.ToLower(instance)] {
+			continue
+		}
+		entry, records := c.entry(instance, iface)
+		if entry == nil {
+			continue
+		}
+		seen[strings.ToLower(instance)] = true
+		records = append(records, ptr)
+		entry.TTL = ptr.expires.Sub(ptr.received)
+		for _, r := range records {
+			entry.TTL = min(entry.TTL, r.expires.Sub(r.received))
+		}
+		entries = append(entries, entry)
+
+		if !slices.ContainsFunc(records, func(r cachedRecord) bool { return r.remaining(now) <= 0.5 }) {
+			known = append(known, withTTL(ptr.rr, ptr.expires.Sub(now)))
+		}
+	}
+	return entries, known
+}
+
+// entry builds the entry of an instance from its SRV, TXT and address records, nil if one is missing.
+// It also returns the records used. Must be called with the lock held.
+func (c *Cache) entry(instance string, iface *net.Interface) (*ServiceEntry, []cachedRecord) {
+	srvs := c.lookup(instance, dns.TypeSRV)
+	txts := c.lookup(instance, dns.TypeTXT)
+	if len(srvs) == 0 || len(txts) == 0 {
+		return nil, nil
+	}
+	srv := srvs[len(srvs)-1].rr.(*dns.SRV)
+	txt := txts[len(txts)-1].rr.(*dns.TXT)
+	records := []cachedRecord{srvs[len(srvs)-1], txts[len(txts)-1]}
+
+	entry := &ServiceEntry{
+		Name:       instance,
+		Host:       srv.Target,
+		Port:       int(srv.Port),
+		Info:       strings.Join(txt.Txt, "|"),
+		InfoFields: txt.Txt,
+	}
+	if as := c.lookup(srv.Target, dns.TypeA); len(as) > 0 {
+		entry.AddrV4 = as[len(as)-1].rr.(*dns.A).A
+		entry.Addr = entry.AddrV4
+		records = append(records, as[len(as)-1])
+	}
+	if aaaas := c.lookup(srv.Target, dns.TypeAAAA); len(aaaas) > 0 {
+		entry.AddrV6 = aaaas[len(aaaas)-1].rr.(*dns.AAAA).AAAA
+		entry.AddrV6IPAddr = &net.IPAddr{IP: entry.AddrV6}
+		if iface != nil && entry.AddrV6.IsLinkLocalUnicast() {
+			entry.AddrV6IPAddr.Zone = iface.Name
+		}
+		if entry.Addr == nil {
+			entry.Addr = entry.AddrV6
+		}
+		records = append(records, aaaas[len(aaaas)-1])
+	}
+	if entry.AddrV4 == nil && entry.AddrV6 == nil {
+		return nil, nil
+	}
+	return entry, records
+}
+
+// sameRRset returns whether two records have the same name, type and class
+func sameRRset(a, b dns.RR) bool {
+	ha, hb := a.Header(), b.Header()
+	return ha.Rrtype == hb.Rrtype && ha.Class&^CACHE_FLUSH_BIT == hb.Class&^CACHE_FLUSH_BIT && strings.EqualFold(ha.Name, hb.Name)
+}
+
+// sameRecord returns whether two records have the same name, type, class and data
+func sameRecord(a, b dns.RR) bool {
+	return sameRRset(a, b) && rdata(a) == rdata(b)
+}
+
+// rdata returns the presentation format of the data of a record, without its header
+func rdata(rr dns.RR) string {
+	return strings.TrimPrefix(rr.String(), rr.Header().String())
+}
+
+// withTTL returns a copy of the record with the given TTL left
+func withTTL(rr dns.RR, ttl time.Duration) dns.RR {
+	rr = dns.Copy(rr)
+	rr.Header().Ttl = uint32(ttl / time.Second)
+	rr.Header().Class &^= CACHE_FLUSH_BIT
+	return rr
+}
+
+// Fqdn returns the fully qualified name of a service type in a domain, ex: '_http._tcp.local.'
+func Fqdn(service, domain string) string {
+	return strings.ToLower(dns.Fqdn(strings.TrimSuffix(service, ".") + "." + strings.Trim(domain, ".")))
+}
@@ -0,0 +1,245 @@
+package mdns
+
+import (
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// record parses a record in presentation format
+func record(t *testing.T, s string) dns.RR {
+	t.Helper()
+	rr, err := dns.NewRR(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rr
+}
+
+// flushing sets the cache-flush bit of a record
+func flushing(rr dns.RR) dns.RR {
+	rr.Header().Class |= CACHE_FLUSH_BIT
+	return rr
+}
+
+func TestRecordCacheAdd(t *testing.T) {
+	type received struct {
+		age time.Duration // before now
+		rr  string
+		cf  bool // with the cache-flush bit
+	}
+	tests := []struct {
+		name     string
+		received []received
+		want     []string // addresses of printer.local.
+	}{
+		{"same record again", []received{
+			{10 * time.Second, "printer.local. 120 IN A 192.168.1.2", false},
+			{0, "printer.local. 120 IN A 192.168.1.2", false},
+		}, []string{"192.168.1.2"}},
+		{"other address", []received{
+			{10 * time.Second, "printer.local. 120 IN A 192.168.1.2", false},
+			{0, "printer.local. 120 IN A 192.168.1.3", false},
+		}, []string{"192.168.1.2", "192.168.1.3"}},
+		{"cache-flush", []received{
+			{10 * time.Second, "printer.local. 120 IN A 192.168.1.2", false},
+			{0, "printer.local. 120 IN A 192.168.1.3", true},
+		}, []string{"192.168.1.3"}},
+		{"cache-flush of the same response", []received{
+			{500 * time.Millisecond, "printer.local. 120 IN A 192.168.1.2", true},
+			{0, "printer.local. 120 IN A 192.168.1.3", true},
+		}, []string{"192.168.1.2", "192.168.1.3"}},
+		{"cache-flush of another name", []received{
+			{10 * time.Second, "printer.local. 120 IN A 192.168.1.2", false},
+			{0, "scanner.local. 120 IN A 192.168.1.3", true},
+		}, []string{"192.168.1.2"}},
+		{"goodbye", []received{
+			{10 * time.Second, "printer.local. 120 IN A 192.168.1.2", false},
+			{0, "printer.local. 0 IN A 192.168.1.2", false},
+		}, []string{}},
+		{"expired", []received{
+			{2 * time.Minute, "printer.local. 120 IN A 192.168.1.2", false},
+		}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			var c Cache
+			for _, r := range tt.received {
+				rr := record(t, r.rr)
+				if r.cf {
+					rr = flushing(rr)
+				}
+				c.Add([]dns.RR{rr}, now.Add(-r.age))
+			}
+			c.Expire(now)
+
+			got := []string{}
+			for _, r := range c.lookup("printer.local.", dns.TypeA) {
+				got = append(got, r.rr.(*dns.A).A.String())
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("addresses %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordCacheEntries(t *testing.T) {
+	printer := []string{
+		"_ipp._tcp.local. 4500 IN PTR Printer._ipp._tcp.local.",
+		"Printer._ipp._tcp.local. 120 IN SRV 0 0 631 printer.local.",
+		`Printer._ipp._tcp.local. 4500 IN TXT "rp=ipp/print"`,
+		"printer.local. 120 IN A 192.168.1.2",
+	}
+	web := []string{
+		"_http._tcp.local. 4500 IN PTR Web._http._tcp.local.",
+		"Web._http._tcp.local. 120 IN SRV 0 0 80 printer.local.",
+		`Web._http._tcp.local. 4500 IN TXT "path=/"`,
+	}
+	type received struct {
+		age     time.Duration // before now
+		records []string
+	}
+	tests := []struct {
+		name    string
+		service string
+		records []received
+		entries []string
+		ttls    []time.Duration
+		known   []string
+	}{
+		{"complete", "_ipp._tcp", []received{{0, printer}},
+			[]string{"Printer._ipp._tcp.local."}, []time.Duration{120 * time.Second}, []string{"Printer._ipp._tcp.local."}},
+		{"address missing", "_ipp._tcp", []received{{0, printer[:3]}},
+			[]string{}, []time.Duration{}, []string{}},
+		{"TXT missing", "_ipp._tcp", []received{{0, slices.Delete(slices.Clone(printer), 2, 3)}},
+			[]string{}, []time.Duration{}, []string{}},
+		{"less than half TTL left", "_ipp._tcp", []received{{70 * time.Second, printer}},
+			[]string{"Printer._ipp._tcp.local."}, []time.Duration{120 * time.Second}, []string{}},
+		{"address refreshed", "_ipp._tcp", []received{{70 * time.Second, printer}, {0, printer[1:2]}, {0, printer[3:]}},
+			[]string{"Printer._ipp._tcp.local."}, []time.Duration{120 * time.Second}, []string{"Printer._ipp._tcp.local."}},
+		{"shortest TTL", "_http._tcp", []received{{0, web}, {0, []string{"printer.local. 60 IN A 192.168.1.2"}}},
+			[]string{"Web._http._tcp.local."}, []time.Duration{60 * time.Second}, []string{"Web._http._tcp.local."}},
+		{"other type", "_http._tcp", []received{{0, printer}},
+			[]string{}, []time.Duration{}, []string{}},
+		{"all types", META_QUERY, []received{{0, printer}, {0, web}},
+			[]string{"Printer._ipp._tcp.local.", "Web._http._tcp.local."}, []time.Duration{120 * time.Second, 120 * time.Second},
+			[]string{"Printer._ipp._tcp.local.", "Web._http._tcp.local."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			var c Cache
+			for _, r := range tt.records {
+				rrs := []dns.RR{}
+				for _, s := range r.records {
+					rrs = append(rrs, record(t, s))
+				}
+				c.Add(rrs, now.Add(-r.age))
+			}
+
+			entries, known := c.Entries(tt.service, "local", nil, now)
+			names, ttls := []string{}, []time.Duration{}
+			for _, entry := range entries {
+				names = append(names, entry.Name)
+				ttls = append(ttls, entry.TTL)
+			}
+			if !slices.Equal(names, tt.entries) {
+				t.Errorf("entries %v, want %v", names, tt.entries)
+			}
+			if !slices.Equal(ttls, tt.ttls) {
+				t.Errorf("TTLs %v, want %v", ttls, tt.ttls)
+			}
+
+			knownNames := []string{}
+			for _, rr := range known {
+				knownNames = append(knownNames, rr.(*dns.PTR).Ptr)
+				if rr.Header().Ttl > 4500 || rr.Header().Class&CACHE_FLUSH_BIT != 0 {
+					t.Errorf("known answer %v: want the TTL left and no cache-flush bit", rr)
+				}
+			}
+			if !slices.Equal(knownNames, tt.known) {
+				t.Errorf("known answers %v, want %v", knownNames, tt.known)
+			}
+		})
+	}
+}
+
+func TestRecordCacheGoodbye(t *testing.T) {
+	printer := []string{
+		"_ipp._tcp.local. 4500 IN PTR Printer._ipp._tcp.local.",
+		"_printer._sub._ipp._tcp.local. 4500 IN PTR Printer._ipp._tcp.local.",
+		"Printer._ipp._tcp.local. 120 IN SRV 0 0 631 printer.local.",
+		`Printer._ipp._tcp.local. 4500 IN TXT "rp=ipp/print"`,
+		"printer.local. 120 IN A 192.168.1.2",
+	}
+	tests := []struct {
+		name     string
+		goodbyes []string
+		want     []string // instances gone
+	}{
+		{"PTR", []string{
+			"_ipp._tcp.local. 0 IN PTR Printer._ipp._tcp.local.",
+			"_printer._sub._ipp._tcp.local. 0 IN PTR Printer._ipp._tcp.local.",
+		}, []string{"Printer._ipp._tcp.local."}},
+		{"SRV", []string{"Printer._ipp._tcp.local. 0 IN SRV 0 0 631 printer.local."}, []string{"Printer._ipp._tcp.local."}},
+		{"all records", []string{
+			"_ipp._tcp.local. 0 IN PTR Printer._ipp._tcp.local.",
+			"_printer._sub._ipp._tcp.local. 0 IN PTR Printer._ipp._tcp.local.",
+			"Printer._ipp._tcp.local. 0 IN SRV 0 0 631 printer.local.",
+			`Printer._ipp._tcp.local. 0 IN TXT "rp=ipp/print"`,
+		}, []string{"Printer._ipp._tcp.local."}},
+		{"subtype PTR only", []string{"_printer._sub._ipp._tcp.local. 0 IN PTR Printer._ipp._tcp.local."}, []string{}},
+		{"address", []string{"printer.local. 0 IN A 192.168.1.2"}, []string{}},
+		{"unknown instance", []string{"_ipp._tcp.local. 0 IN PTR Scanner._ipp._tcp.local."}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			var c Cache
+			rrs := []dns.RR{}
+			for _, s := range printer {
+				rrs = append(rrs, record(t, s))
+			}
+			c.Add(rrs, now.Add(-10*time.Second))
+
+			rrs = []dns.RR{}
+			for _, s := range tt.goodbyes {
+				rrs = append(rrs, record(t, s))
+			}
+			got := []string{}
+			for _, entry := range c.Add(rrs, now) {
+				got = append(got, entry.Name)
+				if entry.Port != 631 || !entry.AddrV4.Equal(net.IPv4(192, 168, 1, 2)) {
+					t.Errorf("gone entry %+v, want the one before the goodbye", entry)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("gone %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecordCacheKnown(t *testing.T) {
+	now := time.Now()
+	var c Cache
+	c.Add([]dns.RR{record(t, "_services._dns-sd._udp.local. 4500 IN PTR _http._tcp.local.")}, now.Add(-time.Hour))
+	c.Add([]dns.RR{record(t, "_services._dns-sd._udp.local. 4500 IN PTR _ipp._tcp.local.")}, now.Add(-2*time.Minute))
+
+	known := c.Known(Fqdn(META_QUERY, "local"), now)
+	if len(known) != 1 || known[0].(*dns.PTR).Ptr != "_ipp._tcp.local." {
+		t.Fatalf("known answers %v, want the type with more than half its TTL left", known)
+	}
+	if ttl := known[0].Header().Ttl; ttl != 4380 {
+		t.Errorf("TTL %d, want the 4380s left", ttl)
+	}
+}
@@ -0,0 +1,225 @@
+package mdns
+
+import (
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+const (
+	PORT = 5353
+	// Largest mDNS packet, RFC 6762 section 17
+	MAX_PACKET_SIZE = 9000
+	// Queries with more known answers than fit in an Ethernet frame continue in other packets, RFC 6762 section 7.2
+	MAX_QUERY_SIZE = 1472
+)
+
+var (
+	IPV4_GROUP = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: PORT}
+	IPV6_GROUP = &net.UDPAddr{IP: net.ParseIP("ff02::fb"), Port: PORT}
+)
+
+// A PacketConn is a socket joined to the mDNS group of an address family, faked in tests
+type PacketConn interface {
+	// ReadFrom reads a packet, along with the index of the interface it was received on (0 if unknown)
+	ReadFrom(b []byte) (n int, ifindex int, err error)
+	// Send multicasts a packet to the group
+	Send(b []byte) error
+	Close() error
+}
+
+// ipv4Conn is a PacketConn joined to IPV4_GROUP
+type ipv4Conn struct {
+	*ipv4.PacketConn
+}
+
+func (c ipv4Conn) ReadFrom(b []byte) (int, int, error) {
+	n, cm, _, err := c.PacketConn.ReadFrom(b)
+	if cm == nil {
+		return n, 0, err
+	}
+	return n, cm.IfIndex, err
+}
+
+func (c ipv4Conn) Send(b []byte) error {
+	_, err := c.PacketConn.WriteTo(b, nil, IPV4_GROUP)
+	return err
+}
+
+// ipv6Conn is a PacketConn joined to IPV6_GROUP
+type ipv6Conn struct {
+	*ipv6.PacketConn
+}
+
+func (c ipv6Conn) ReadFrom(b []byte) (int, int, error) {
+	n, cm, _, err := c.PacketConn.ReadFrom(b)
+	if cm == nil {
+		return n, 0, err
+	}
+	return n, cm.IfIndex, err
+}
+
+func (c ipv6Conn) Send(b []byte) error {
+	_, err := c.PacketConn.WriteTo(b, nil, IPV6_GROUP)
+	return err
+}
+
+// listenIPv4 joins IPV4_GROUP on an interface (the default one if nil)
+func listenIPv4(iface *net.Interface) (PacketConn, error) {
+	conn, err := net.ListenMulticastUDP("udp4", iface, IPV4_GROUP)
+	if err != nil {
+		return nil, err
+	}
+	p := ipv4.NewPacketConn(conn)
+	// The queries reach the responder of this host too, ListenMulticastUDP turns it off
+	if err := p.SetMulticastLoopback(true); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	// The socket is bound to the port on all interfaces: their index tells the packets of the others apart. Not
+	// supported on all platforms, where they are all received.
+	p.SetControlMessage(ipv4.FlagInterface, true)
+	return ipv4Conn{p}, nil
+}
+
+// listenIPv6 joins IPV6_GROUP on an interface (the default one if nil)
+func listenIPv6(iface *net.Interface) (PacketConn, error) {
+	conn, err := net.ListenMulticastUDP("udp6", iface, IPV6_GROUP)
+	if err != nil {
+		return nil, err
+	}
+	p := ipv6.NewPacketConn(conn)
+	if err := p.SetMulticastLoopback(true); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	p.SetControlMessage(ipv6.FlagInterface, true)
+	return ipv6Conn{p}, nil
+}
+
+// subscriber is called for each message received by a Listener, and malformed for each packet that can't be unpacked
+type subscriber struct {
+	handle    func(msg *dns.Msg)
+	malformed func()
+}
+
+// A Listener receives all the mDNS packets multicast on an interface (queries, answers and announcements) and
+// dispatches them to its subscribers, the services of the interface. It sends their queries from the mDNS port so
+// that they are answered by multicast.
+type Listener struct {
+	iface       *net.Interface
+	conns       []PacketConn
+	mu          sync.RWMutex
+	subscribers map[int]subscriber
+	next        int // id of the next subscriber
+}
+
+// Listen joins the IPv4 and IPv6 mDNS groups on an interface (the default one if nil), with one socket for each, and
+// starts reading them. Fails only if both can't be joined.
+func Listen(iface *net.Interface) (*Listener, error) {
+	var conns []PacketConn
+	var errs []error
+	for _, listen := range []func(*net.Interface) (PacketConn, error){listenIPv4, listenIPv6} {
+		conn, err := listen(iface)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		conns = append(conns, conn)
+	}
+
+	if len(conns) == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return NewListener(iface, conns...), nil
+}
+
+// NewListener returns a Listener of the packets of conns, one per address family, and starts reading them until it
+// is closed. Listen uses sockets, tests fake ones.
+func NewListener(iface *net.Interface, conns ...PacketConn) *Listener {
+	l := &Listener{
+		iface:       iface,
+		conns:       conns,
+		subscribers: make(map[int]subscriber),
+	}
+	for _, conn := range conns {
+		go l.read(conn)
+	}
+	return l
+}
+
+// read dispatches the messages received on conn until it is closed
+func (l *Listener) read(conn PacketConn) {
+	buf := make([]byte, MAX_PACKET_SIZE)
+	for {
+		n, ifindex, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		} else if err != nil {
+			continue
+		}
+		if l.iface != nil && ifindex != 0 && ifindex != l.iface.Index { // joined on another interface
+			continue
+		}
+
+		msg := new(dns.Msg)
+		err = msg.Unpack(buf[:n])
+
+		l.mu.RLock()
+		for _, s := range l.subscribers {
+			if err != nil {
+				if s.malformed != nil {
+					s.malformed()
+				}
+			} else {
+				s.handle(msg)
+			}
+		}
+		l.mu.RUnlock()
+	}
+}
+
+// Subscribe calls handle for each message received, and malformed (if not nil) for each packet that can't be
+// unpacked, until the returned function is called. They must not modify the message, shared by all the subscribers.
+func (l *Listener) Subscribe(handle func(msg *dns.Msg), malformed func()) (unsubscribe func()) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	id := l.next
+	l.next++
+	l.subscribers[id] = subscriber{handle: handle, malformed: malformed}
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.subscribers, id)
+	}
+}
+
+// Send multicasts a message to the groups joined
+func (l *Listener) Send(msg *dns.Msg) error {
+	buf, err := msg.Pack()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, conn := range l.conns {
+		if err := conn.Send(buf); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close leaves the groups, stopping the reads
+func (l *Listener) Close() error {
+	var errs []error
+	for _, conn := range l.conns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,131 @@
+package mdns
+
+import (
+	"maps"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// fakePacket is a packet received by a fakeConn
+type fakePacket struct {
+	data    []byte
+	ifindex int
+}
+
+// fakeConn is a packetConn receiving the packets given to receive, and recording the ones sent
+type fakeConn struct {
+	in     chan fakePacket
+	closed chan struct{}
+	once   sync.Once
+	mu     sync.Mutex
+	sent   []*dns.Msg
+}
+
+func newFakeConn() *fakeConn {
+	return &fakeConn{in: make(chan fakePacket), closed: make(chan struct{})}
+}
+
+func (c *fakeConn) ReadFrom(b []byte) (int, int, error) {
+	select {
+	case p := <-c.in:
+		return copy(b, p.data), p.ifindex, nil
+	case <-c.closed:
+		return 0, 0, net.ErrClosed
+	}
+}
+
+func (c *fakeConn) Send(b []byte) error {
+	msg := new(dns.Msg)
+	if err := msg.Unpack(b); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+// receive makes the listener read a packet from the interface with the given index, and waits for it to be read
+func (c *fakeConn) receive(t *testing.T, data []byte, ifindex int) {
+	t.Helper()
+	select {
+	case c.in <- fakePacket{data: data, ifindex: ifindex}:
+	case <-time.After(time.Second):
+		t.Fatal("packet not read")
+	}
+}
+
+// packets returns the messages sent so far
+func (c *fakeConn) packets() []*dns.Msg {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*dns.Msg{}, c.sent...)
+}
+
+func TestListener(t *testing.T) {
+	conn := newFakeConn()
+	l := NewListener(&net.Interface{Index: 2, Name: "eth0"}, conn)
+	defer l.Close()
+
+	events := make(chan string, 16)
+	subscribe := func(name string) func() {
+		return l.Subscribe(func(*dns.Msg) { events <- name }, func() { events <- name + " malformed" })
+	}
+	unsubscribeHTTP := subscribe("http")
+	subscribe("ipp")
+
+	msg := new(dns.Msg)
+	msg.SetQuestion("_http._tcp.local.", dns.TypePTR)
+	packet, err := msg.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn.receive(t, packet, 2)
+	conn.receive(t, packet, 3) // joined on another interface, dropped
+	conn.receive(t, packet, 0) // unknown interface, kept
+	conn.receive(t, []byte{0xde, 0xad}, 2)
+	conn.receive(t, packet, 1) // dropped too, but once read the previous ones are dispatched
+	unsubscribeHTTP()
+	conn.receive(t, packet, 2)
+
+	received := map[string]int{}
+	for range 7 {
+		select {
+		case name := <-events:
+			received[name]++
+		case <-time.After(time.Second):
+			t.Fatalf("received %v, want more", received)
+		}
+	}
+	want := map[string]int{"http": 2, "http malformed": 1, "ipp": 3, "ipp malformed": 1}
+	if !maps.Equal(received, want) {
+		t.Errorf("received %v, want %v", received, want)
+	}
+}
+
+func TestListenerSend(t *testing.T) {
+	v4, v6 := newFakeConn(), newFakeConn()
+	l := NewListener(nil, v4, v6)
+	defer l.Close()
+
+	msg := new(dns.Msg)
+	msg.SetQuestion("_http._tcp.local.", dns.TypePTR)
+	if err := l.Send(msg); err != nil {
+		t.Fatal(err)
+	}
+	for _, conn := range []*fakeConn{v4, v6} {
+		if sent := conn.packets(); len(sent) != 1 || sent[0].Question[0].Name != "_http._tcp.local." {
+			t.Errorf("sent %v, want the query on each socket", sent)
+		}
+	}
+}
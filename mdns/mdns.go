@@ -0,0 +1,55 @@
+// Package mdns is a minimal mDNS client (RFC 6762 and RFC 6763): a Listener receiving all the mDNS packets multicast
+// on an interface, a Cache of the records they carry assembling them into service entries, and the queries listing
+// the answers already known.
+//
+// It replaces the fork of hashicorp/mdns used before, which only returns the entries answering its own queries: the
+// known-answer suppression and the passive mode need to send the known answers in the queries and to read the
+// responses to the queries of other hosts.
+package mdns
+
+import (
+	"net"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// Used to query all peers for their services
+// https://github.com/libp2p/specs/blob/master/discovery/mdns.md#dns-service-discovery
+const META_QUERY = "_services._dns-sd._udp"
+
+// ServiceEntry is a service instance, assembled from the PTR, SRV, TXT and address records of the responses
+type ServiceEntry struct {
+	Name         string // instance, ex: 'My Printer._ipp._tcp.local.'
+	Host         string
+	AddrV4       net.IP
+	AddrV6       net.IP
+	AddrV6IPAddr *net.IPAddr // AddrV6 with its zone
+	Port         int
+	Info         string        // TXT strings joined with '|'
+	InfoFields   []string      // TXT strings
+	Addr         net.IP        // AddrV4, or AddrV6 without one
+	TTL          time.Duration // shortest TTL of its records, as received
+}
+
+// QueryPackets returns the packets of a query for the PTR records of names, listing the known answers. The ones that
+// don't fit in MAX_QUERY_SIZE follow in packets without questions, all but the last with the TC bit set (RFC 6762
+// section 7.2).
+func QueryPackets(names []string, known []dns.RR) []*dns.Msg {
+	msg := &dns.Msg{Compress: true}
+	for _, name := range names {
+		msg.Question = append(msg.Question, dns.Question{Name: name, Qtype: dns.TypePTR, Qclass: dns.ClassINET})
+	}
+
+	packets := []*dns.Msg{msg}
+	for _, rr := range known {
+		msg.Answer = append(msg.Answer, rr)
+		if msg.Len() > MAX_QUERY_SIZE && len(msg.Answer) > 1 {
+			msg.Answer = msg.Answer[:len(msg.Answer)-1]
+			msg.Truncated = true
+			msg = &dns.Msg{Answer: []dns.RR{rr}, Compress: true}
+			packets = append(packets, msg)
+		}
+	}
+	return packets
+}
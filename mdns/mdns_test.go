@@ -0,0 +1,62 @@
+package mdns
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+func TestQueryPackets(t *testing.T) {
+	known := func(n int) []dns.RR {
+		rrs := []dns.RR{}
+		for i := range n {
+			rrs = append(rrs, &dns.PTR{
+				Hdr: dns.RR_Header{Name: "_http._tcp.local.", Rrtype: dns.TypePTR, Class: dns.ClassINET, Ttl: 4500},
+				Ptr: fmt.Sprintf("Web server with a rather long instance name %d._http._tcp.local.", i),
+			})
+		}
+		return rrs
+	}
+
+	tests := []struct {
+		name    string
+		known   []dns.RR
+		packets int
+	}{
+		{"no known answer", nil, 1},
+		{"fitting", known(10), 1},
+		{"truncated", known(40), 2},
+		{"truncated twice", known(60), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packets := QueryPackets([]string{"_http._tcp.local."}, tt.known)
+			if len(packets) != tt.packets {
+				t.Fatalf("%d packets, want %d", len(packets), tt.packets)
+			}
+
+			answers := []dns.RR{}
+			for i, msg := range packets {
+				if size := msg.Len(); size > MAX_QUERY_SIZE {
+					t.Errorf("packet %d: %d bytes, more than %d", i, size, MAX_QUERY_SIZE)
+				}
+				if last := i == len(packets)-1; msg.Truncated == last {
+					t.Errorf("packet %d: TC bit %v, want %v", i, msg.Truncated, !last)
+				}
+				if first := i == 0; (len(msg.Question) > 0) != first {
+					t.Errorf("packet %d: %d questions", i, len(msg.Question))
+				}
+				answers = append(answers, msg.Answer...)
+			}
+			if len(answers) != len(tt.known) {
+				t.Errorf("%d known answers sent, want %d", len(answers), len(tt.known))
+			}
+			for i := range min(len(answers), len(tt.known)) {
+				if answers[i] != tt.known[i] {
+					t.Errorf("answer %d: %v, want %v", i, answers[i], tt.known[i])
+				}
+			}
+		})
+	}
+}
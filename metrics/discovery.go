@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// Discovery exports the activity of a network.Discovery (as its Observer) and the changes of a
+// network.Registry as Prometheus metrics
+type Discovery struct {
+	queries   *CounterVec
+	responses *CounterVec
+	malformed *CounterVec
+	latency   *HistogramVec
+	added     *CounterVec
+	updated   *CounterVec
+	removed   *CounterVec
+	instances *GaugeVec
+}
+
+// NewDiscovery creates the discovery metrics and registers them
+func NewDiscovery(registry *Registry) *Discovery {
+	m := &Discovery{
+		queries:   NewCounterVec("mdns_queries_sent_total", "Number of mDNS queries sent.", "interface", "domain"),
+		responses: NewCounterVec("mdns_responses_received_total", "Number of mDNS response packets received.", "interface"),
+		malformed: NewCounterVec("mdns_malformed_packets_total", "Number of mDNS packets that could not be parsed.", "interface"),
+		latency: NewHistogramVec("mdns_response_latency_seconds", "Time between a query and each response received.",
+			DefaultBuckets, "interface", "domain"),
+		added:     NewCounterVec("mdns_entries_added_total", "Number of service instances discovered.", "interface", "domain", "service"),
+		updated:   NewCounterVec("mdns_entries_updated_total", "Number of service instances announced with a different record.", "interface", "domain", "service"),
+		removed:   NewCounterVec("mdns_entries_removed_total", "Number of service instances expired after not being seen.", "interface", "domain", "service"),
+		instances: NewGaugeVec("mdns_instances", "Number of service instances currently announced.", "interface", "domain", "service"),
+	}
+	registry.Register(m.queries, m.responses, m.malformed, m.latency, m.added, m.updated, m.removed, m.instances)
+	return m
+}
+
+// Implements network.Observer interface
+func (m *Discovery) QuerySent(iface, domain string) {
+	m.queries.Inc(iface, domain)
+}
+
+// Implements network.Observer interface
+func (m *Discovery) ResponseReceived(iface string) {
+	m.responses.Inc(iface)
+}
+
+// Implements network.Observer interface
+func (m *Discovery) ResponseLatency(iface, domain string, latency time.Duration) {
+	m.latency.Observe(latency.Seconds(), iface, domain)
+}
+
+// Implements network.Observer interface
+func (m *Discovery) MalformedPacket(iface string) {
+	m.malformed.Inc(iface)
+}
+
+// Watch counts the changes of a registry (from its Subscribe channel) until ctx is cancelled
+func (m *Discovery) Watch(ctx context.Context, events <-chan network.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			labels := []string{event.Entry.Interface, event.Entry.Domain(), event.Entry.ServiceType()}
+			switch event.Type {
+			case network.EVENT_ADDED:
+				m.added.Inc(labels...)
+				m.instances.Add(1, labels...)
+			case network.EVENT_UPDATED:
+				m.updated.Inc(labels...)
+			case network.EVENT_REMOVED:
+				m.removed.Inc(labels...)
+				m.instances.Add(-1, labels...)
+			}
+		}
+	}
+}
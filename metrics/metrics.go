@@ -0,0 +1,278 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Content type of the Prometheus text exposition format
+const CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+
+// Default buckets of latency histograms, in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metric is a family of samples written in the Prometheus text exposition format
+type Metric interface {
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// Registry is a set of metrics served together
+type Registry struct {
+	metrics []Metric
+	mu      sync.Mutex
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds metrics to the registry
+func (r *Registry) Register(metrics ...Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, metrics...)
+}
+
+// WriteTo writes all metrics in registration order
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var total int64
+	for _, metric := range r.metrics {
+		n, err := metric.WriteTo(w)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// Handler serves the metrics of the registry
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", CONTENT_TYPE)
+		r.WriteTo(w)
+	})
+}
+
+// vec holds the values of a metric by label values
+type vec[T any] struct {
+	name   string
+	help   string
+	labels []string
+	values map[string]*labeled[T]
+	mu     sync.Mutex
+}
+
+type labeled[T any] struct {
+	labelValues []string
+	value       T
+}
+
+func newVec[T any](name, help string, labels []string) vec[T] {
+	return vec[T]{name: name, help: help, labels: labels, values: map[string]*labeled[T]{}}
+}
+
+// get returns the value of the label values, creating it if needed. Must be called with the lock held.
+func (v *vec[T]) get(labelValues []string) *labeled[T] {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metric %s: %d label values for %d labels", v.name, len(labelValues), len(v.labels)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	value, ok := v.values[key]
+	if !ok {
+		value = &labeled[T]{labelValues: slices.Clone(labelValues)}
+		v.values[key] = value
+	}
+	return value
+}
+
+// sorted returns the values sorted by label values, for a stable output. Must be called with the lock held.
+func (v *vec[T]) sorted() []*labeled[T] {
+	values := make([]*labeled[T], 0, len(v.values))
+	for _, value := range v.values {
+		values = append(values, value)
+	}
+	slices.SortFunc(values, func(a, b *labeled[T]) int {
+		return slices.Compare(a.labelValues, b.labelValues)
+	})
+	return values
+}
+
+func (v *vec[T]) writeHeader(b *strings.Builder, kind string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", v.name, escapeHelp(v.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", v.name, kind)
+}
+
+// CounterVec is a counter partitioned by labels
+type CounterVec struct {
+	vec[float64]
+}
+
+// NewCounterVec returns a counter with the given label names
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newVec[float64](name, help, labels)}
+}
+
+// Add increases the counter of the label values by delta (must be positive)
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(labelValues).value += delta
+}
+
+// Inc increases the counter of the label values by 1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *CounterVec) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var b strings.Builder
+	c.writeHeader(&b, "counter")
+	for _, value := range c.sorted() {
+		writeSample(&b, c.name, c.labels, value.labelValues, value.value)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// GaugeVec is a gauge partitioned by labels
+type GaugeVec struct {
+	vec[float64]
+}
+
+// NewGaugeVec returns a gauge with the given label names
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{newVec[float64](name, help, labels)}
+}
+
+// Add changes the gauge of the label values by delta
+func (g *GaugeVec) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(labelValues).value += delta
+}
+
+// Set sets the gauge of the label values
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(labelValues).value = value
+}
+
+func (g *GaugeVec) WriteTo(w io.Writer) (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var b strings.Builder
+	g.writeHeader(&b, "gauge")
+	for _, value := range g.sorted() {
+		writeSample(&b, g.name, g.labels, value.labelValues, value.value)
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// HistogramVec is a histogram partitioned by labels
+type HistogramVec struct {
+	vec[*histogram]
+	buckets []float64
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec returns a histogram with the given upper bounds (sorted) and label names
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{vec: newVec[*histogram](name, help, labels), buckets: buckets}
+}
+
+// Observe adds an observation to the histogram of the label values
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	l := h.get(labelValues)
+	if l.value == nil {
+		l.value = &histogram{counts: make([]uint64, len(h.buckets))}
+	}
+	if idx, _ := slices.BinarySearch(h.buckets, value); idx < len(h.buckets) {
+		l.value.counts[idx]++
+	}
+	l.value.count++
+	l.value.sum += value
+}
+
+func (h *HistogramVec) WriteTo(w io.Writer) (int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var b strings.Builder
+	h.writeHeader(&b, "histogram")
+	labels := append(slices.Clone(h.labels), "le")
+	for _, value := range h.sorted() {
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += value.value.counts[i]
+			writeSample(&b, h.name+"_bucket", labels, append(slices.Clone(value.labelValues), formatFloat(bound)), float64(cumulative))
+		}
+		writeSample(&b, h.name+"_bucket", labels, append(slices.Clone(value.labelValues), "+Inf"), float64(value.value.count))
+		writeSample(&b, h.name+"_sum", h.labels, value.labelValues, value.value.sum)
+		writeSample(&b, h.name+"_count", h.labels, value.labelValues, float64(value.value.count))
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeSample writes a 'name{label="value",...} value' line
+func writeSample(b *strings.Builder, name string, labels []string, labelValues []string, value float64) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", label, escapeLabelValue(labelValues[i]))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatFloat(value))
+	b.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+var (
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabelValue(s string) string { return labelValueEscaper.Replace(s) }
+func escapeHelp(s string) string       { return helpEscaper.Replace(s) }
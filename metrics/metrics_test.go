@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExposition(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric func() Metric
+		want   string
+	}{
+		{"counter", func() Metric {
+			c := NewCounterVec("queries_total", "Number of queries.", "interface", "domain")
+			c.Inc("eth0", "local")
+			c.Add(2, "eth0", "local")
+			c.Inc("docker0", "local")
+			return c
+		}, `# HELP queries_total Number of queries.
+# TYPE queries_total counter
+queries_total{interface="docker0",domain="local"} 1
+queries_total{interface="eth0",domain="local"} 3
+`},
+		{"counter without samples", func() Metric {
+			return NewCounterVec("queries_total", "Number of queries.", "interface")
+		}, `# HELP queries_total Number of queries.
+# TYPE queries_total counter
+`},
+		{"gauge without labels", func() Metric {
+			g := NewGaugeVec("instances", "Number of instances.")
+			g.Set(5)
+			g.Add(-1.5)
+			return g
+		}, `# HELP instances Number of instances.
+# TYPE instances gauge
+instances 3.5
+`},
+		{"histogram", func() Metric {
+			h := NewHistogramVec("latency_seconds", "Latency.", []float64{0.1, 1}, "interface")
+			h.Observe(0.05, "eth0")
+			h.Observe(0.1, "eth0") // upper bounds are inclusive
+			h.Observe(0.5, "eth0")
+			h.Observe(3, "eth0")
+			return h
+		}, `# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{interface="eth0",le="0.1"} 2
+latency_seconds_bucket{interface="eth0",le="1"} 3
+latency_seconds_bucket{interface="eth0",le="+Inf"} 4
+latency_seconds_sum{interface="eth0"} 3.65
+latency_seconds_count{interface="eth0"} 4
+`},
+		{"escaping", func() Metric {
+			c := NewCounterVec("entries_total", "Entries of \"service\" types\nC:\\ path.", "service")
+			c.Inc("quote \" backslash \\ newline \n end")
+			return c
+		}, `# HELP entries_total Entries of "service" types\nC:\\ path.
+# TYPE entries_total counter
+entries_total{service="quote \" backslash \\ newline \n end"} 1
+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			n, err := tt.metric().WriteTo(&b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+			if n != int64(b.Len()) {
+				t.Errorf("WriteTo() = %d, wrote %d bytes", n, b.Len())
+			}
+		})
+	}
+}
+
+func TestLabelValuesCount(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic with a missing label value")
+		}
+	}()
+	NewCounterVec("queries_total", "Number of queries.", "interface", "domain").Inc("eth0")
+}
+
+func TestRegistryHandler(t *testing.T) {
+	registry := NewRegistry()
+	queries := NewCounterVec("queries_total", "Number of queries.")
+	instances := NewGaugeVec("instances", "Number of instances.")
+	registry.Register(queries, instances)
+	queries.Inc()
+
+	rec := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != CONTENT_TYPE {
+		t.Errorf("Content-Type %q, want %q", got, CONTENT_TYPE)
+	}
+	want := `# HELP queries_total Number of queries.
+# TYPE queries_total counter
+queries_total 1
+# HELP instances Number of instances.
+# TYPE instances gauge
+`
+	if got := rec.Body.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -1,44 +1,95 @@
 package network
 
 import (
-	"io/ioutil"
 	"log"
 	"net"
 	"reflect"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/hashicorp/mdns"
+	"github.com/miekg/dns"
+
+	"gitlab.com/patopest/mdns-discovery/mdns"
 )
 
 const (
 	QUERY_INTERVAL = 11 // in seconds
 	QUERY_TIMEOUT  = 10 // in seconds
 
-	// Used to query all peers for their services
-	// https://github.com/libp2p/specs/blob/master/discovery/mdns.md#dns-service-discovery
-	MDNS_META_QUERY = "_services._dns-sd._udp"
+	// Exponential backoff of the queries, as in RFC 6762 section 5.2
+	BACKOFF_MIN_INTERVAL = time.Second
+	BACKOFF_MAX_INTERVAL = 60 * time.Minute
+
+	MDNS_META_QUERY = mdns.META_QUERY // used to query all peers for their services
 	DEFAULT_DOMAIN  = "local"
 )
 
 type ServiceEntry = mdns.ServiceEntry // type alias
 
+// Options are the settings of the queries
+type Options struct {
+	Services []string      // service types queried (default: all of them, with MDNS_META_QUERY)
+	Interval time.Duration // between queries (default: QUERY_INTERVAL)
+	Timeout  time.Duration // how long to wait for the answers of a query, in the latency metric and one-shot discoveries (default: QUERY_TIMEOUT)
+	Backoff  bool          // start querying every BACKOFF_MIN_INTERVAL and double it up to BACKOFF_MAX_INTERVAL, instead of Interval
+	Passive  bool          // never query, only listen to the announcements and the responses to other hosts' queries
+}
+
+func (o Options) interval() time.Duration {
+	if o.Interval <= 0 {
+		return QUERY_INTERVAL * time.Second
+	}
+	return o.Interval
+}
+
+func (o Options) timeout() time.Duration {
+	if o.Timeout <= 0 {
+		return QUERY_TIMEOUT * time.Second
+	}
+	return o.Timeout
+}
+
+// EntryTTL returns how long entries should be kept without being seen again: 3 times the longest interval between queries
+func (o Options) EntryTTL() time.Duration {
+	if o.Backoff || o.Passive { // seen again only when announced or queried by other hosts
+		return 3 * BACKOFF_MAX_INTERVAL
+	}
+	return 3 * o.interval()
+}
+
 // Discovery manages all the DiscoveryServices
 type Discovery struct {
 	Interfaces []*Interface
 	Domains    []string
-
-	services  map[string][]*DiscoveryService
-	mu        sync.RWMutex
-	EntriesCh chan ServiceEntry // Channel for newly discovered entries
+	Options    Options
+
+	services   map[string][]*DiscoveryService
+	listeners  map[string]*mdns.Listener // by interface name, shared by its services
+	paused     bool                      // services don't query until resumed
+	generation atomic.Uint64             // incremented by Flush, see Entry.Generation
+	mu         sync.RWMutex
+	EntriesCh  chan Entry // Channel for discovered (or refreshed) entries
+	Observer   Observer   // notified of the services' activity, must be set before Start
 }
 
-func InitDiscovery(ifaces []string, domains []string, entriesCh chan ServiceEntry) *Discovery {
+func InitDiscovery(ifaces []string, domains []string, opts Options, entriesCh chan Entry) *Discovery {
+	d := NewDiscovery(ifaces, domains, entriesCh)
+	d.Options = opts
+	d.Start()
+	return d
+}
 
+// NewDiscovery returns a Discovery on the given interfaces (all available ones if empty), not started yet
+func NewDiscovery(ifaces []string, domains []string, entriesCh chan Entry) *Discovery {
 	d := &Discovery{
 		Domains:   domains,
 		services:  make(map[string][]*DiscoveryService, 0),
+		listeners: make(map[string]*mdns.Listener),
 		EntriesCh: entriesCh,
+		Observer:  nopObserver{},
 	}
 
 	if len(ifaces) == 0 {
@@ -47,15 +98,69 @@ func InitDiscovery(ifaces []string, domains []string, entriesCh chan ServiceEntr
 		d.Interfaces = GetInterfacesByName(ifaces)
 	}
 
+	return d
+}
+
+// Start starts the services querying each domain on each interface
+func (d *Discovery) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	for _, itf := range d.Interfaces {
-		for _, domain := range d.Domains {
-			service := NewDiscoveryService(MDNS_META_QUERY, domain, itf.Interface, d.EntriesCh)
+		d.startServices(itf)
+	}
+}
+
+// startServices starts a service for each domain and service type on an interface, all sharing its listener. Must be
+// called with the lock held.
+func (d *Discovery) startServices(itf *Interface) {
+	listener, ok := d.listeners[itf.Name]
+	if !ok {
+		var err error
+		if listener, err = mdns.Listen(itf.Interface); err != nil {
+			log.Printf("Failed to listen on interface %q: %v", itf.Name, err)
+			return
+		}
+		d.listeners[itf.Name] = listener
+		d.observe(itf.Name, listener)
+	}
+
+	serviceTypes := d.Options.Services
+	if len(serviceTypes) == 0 {
+		serviceTypes = []string{MDNS_META_QUERY}
+	}
+
+	for _, domain := range d.Domains {
+		for _, serviceType := range serviceTypes {
+			service := NewDiscoveryService(serviceType, domain, itf.Interface, d.EntriesCh)
+			service.generation = &d.generation
+			service.listener = listener
+			service.observer = d.Observer
+			service.options = d.Options
+			service.paused.Store(d.paused)
 			d.services[itf.Name] = append(d.services[itf.Name], service)
 			service.Start()
 		}
 	}
+}
 
-	return d
+// Reconfigure restarts the services of all interfaces with new domains and options
+func (d *Discovery) Reconfigure(domains []string, opts Options) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for name, services := range d.services {
+		for _, service := range services {
+			service.Stop()
+		}
+		delete(d.services, name)
+	}
+
+	d.Domains = domains
+	d.Options = opts
+	for _, itf := range d.Interfaces {
+		d.startServices(itf)
+	}
 }
 
 // EnableInterface adds an interface to discovery and starts services for it
@@ -70,12 +175,7 @@ func (d *Discovery) EnableInterface(iface *Interface) error {
 	}
 
 	d.Interfaces = append(d.Interfaces, iface)
-
-	for _, domain := range d.Domains {
-		service := NewDiscoveryService(MDNS_META_QUERY, domain, iface.Interface, d.EntriesCh)
-		d.services[iface.Name] = append(d.services[iface.Name], service)
-		service.Start()
-	}
+	d.startServices(iface)
 
 	return nil
 }
@@ -104,10 +204,89 @@ func (d *Discovery) DisableInterface(iface *Interface) error {
 		}
 		delete(d.services, iface.Name)
 	}
+	if listener, ok := d.listeners[iface.Name]; ok {
+		listener.Close()
+		delete(d.listeners, iface.Name)
+	}
 
 	return nil
 }
 
+// observe notifies the Observer of the packets received by the listener of an interface, once per packet instead of
+// once per service receiving it
+func (d *Discovery) observe(iface string, listener *mdns.Listener) {
+	listener.Subscribe(func(msg *dns.Msg) {
+		if msg.Response {
+			d.Observer.ResponseReceived(iface)
+		}
+	}, func() { d.Observer.MalformedPacket(iface) })
+}
+
+// Refresh makes all the services query now, restarting the backoff if enabled (not in passive mode)
+func (d *Discovery) Refresh() {
+	d.forEachService((*DiscoveryService).Refresh)
+}
+
+// Pause stops all the services from querying and listening until Resume
+func (d *Discovery) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.paused = true
+	for _, services := range d.services {
+		for _, service := range services {
+			service.paused.Store(true)
+		}
+	}
+}
+
+// Resume makes all the services query again, starting now
+func (d *Discovery) Resume() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.paused = false
+	for _, services := range d.services {
+		for _, service := range services {
+			service.paused.Store(false)
+			service.Refresh()
+		}
+	}
+}
+
+// IsPaused returns whether the services are paused
+func (d *Discovery) IsPaused() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.paused
+}
+
+// Flush makes all the services forget the entries found so far and query again. The entries found before, still on
+// their way to EntriesCh, are of an older Generation.
+func (d *Discovery) Flush() {
+	d.forEachService((*DiscoveryService).Flush)
+	d.generation.Add(1) // after the records are cleared, so that entries of the new one are only built from new ones
+	d.forEachService((*DiscoveryService).Refresh)
+}
+
+// Generation returns the number of flushes so far, entries of an older Generation were found before the last one
+func (d *Discovery) Generation() uint64 {
+	return d.generation.Load()
+}
+
+// forEachService calls f for each running service
+func (d *Discovery) forEachService(f func(*DiscoveryService)) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, services := range d.services {
+		for _, service := range services {
+			f(service)
+		}
+	}
+}
+
 // IsInterfaceEnabled checks if an interface is currently enabled
 func (d *Discovery) IsInterfaceEnabled(name string) bool {
 	d.mu.RLock()
@@ -121,39 +300,46 @@ func (d *Discovery) IsInterfaceEnabled(name string) bool {
 	return false
 }
 
-// A DiscoveryService queries the network for a single domain on a single interface
+// A DiscoveryService queries the network for a single domain on a single interface, and listens to all the mDNS
+// responses there
 type DiscoveryService struct {
-	Params      *mdns.QueryParam
-	Entries     []ServiceEntry
-	entriesCh   chan *mdns.ServiceEntry
-	timer       *time.Ticker
+	Service     string
+	Domain      string
+	Interface   *net.Interface // default one if nil
+	Entries     []Entry
+	entriesCh   chan Entry
 	stop        chan struct{}
-	discoveryCh chan ServiceEntry // Channel to send discovered entries back to Discovery
+	refresh     chan struct{}  // query now
+	flush       chan struct{}  // forget the entries
+	generation  *atomic.Uint64 // of the Discovery, see Entry.Generation
+	discoveryCh chan Entry     // Channel to send discovered entries back to Discovery
+	observer    Observer
+	options     Options
+	listener    *mdns.Listener // of the interface, shared with the other services
+	records     mdns.Cache
+	paused      atomic.Bool  // don't query, ignore the responses
+	lastQuery   atomic.Int64 // unix nano time the last query was sent
 }
 
-func NewDiscoveryService(service string, domain string, iface *net.Interface, discoveryCh chan ServiceEntry) *DiscoveryService {
-	entriesCh := make(chan *mdns.ServiceEntry, 10)
-	entries := make([]ServiceEntry, 0)
-	return &DiscoveryService{
+func NewDiscoveryService(service string, domain string, iface *net.Interface, discoveryCh chan Entry) *DiscoveryService {
+	entriesCh := make(chan Entry, 10)
+	entries := make([]Entry, 0)
+	d := &DiscoveryService{
+		Service:     service,
+		Domain:      domain,
+		Interface:   iface,
 		Entries:     entries,
 		entriesCh:   entriesCh,
+		refresh:     make(chan struct{}, 1),
+		flush:       make(chan struct{}, 1),
+		generation:  new(atomic.Uint64),
 		discoveryCh: discoveryCh,
-		Params: &mdns.QueryParam{
-			Service:             service,
-			Domain:              domain,
-			Timeout:             QUERY_TIMEOUT * time.Second,
-			Entries:             entriesCh,
-			Interface:           iface,
-			WantUnicastResponse: false,
-			DisableIPv4:         false,
-			DisableIPv6:         false,
-			Logger:              log.New(ioutil.Discard, "", 0),
-		},
+		observer:    nopObserver{},
 	}
+	return d
 }
 
 func (d *DiscoveryService) Start() {
-	d.timer = time.NewTicker(QUERY_INTERVAL * time.Second)
 	d.stop = make(chan struct{})
 
 	go d.Run()
@@ -163,42 +349,242 @@ func (d *DiscoveryService) Stop() {
 	close(d.stop)
 }
 
+// Refresh makes the service query now (unless paused or passive), restarting the backoff if enabled
+func (d *DiscoveryService) Refresh() {
+	select {
+	case d.refresh <- struct{}{}:
+	default: // already pending
+	}
+}
+
+// Flush makes the service forget the records and the entries found so far
+func (d *DiscoveryService) Flush() {
+	d.records.Clear()
+	select {
+	case d.flush <- struct{}{}:
+	default: // already pending
+	}
+}
+
+// Run handles the messages received by the listener (its own one if not started by a Discovery) and sends the
+// queries until the service is stopped
 func (d *DiscoveryService) Run() {
-	defer d.timer.Stop()
+	if d.listener == nil {
+		listener, err := mdns.Listen(d.Interface)
+		if err != nil {
+			log.Printf("Failed to listen on interface %q: %v", d.ifaceName(), err)
+			return
+		}
+		defer listener.Close()
+		d.listener = listener
+	}
+	unsubscribe := d.listener.Subscribe(d.handle, nil)
+	defer unsubscribe()
 
 	// Running the queries at interval in it's own goroutine
-	go func() {
-		mdns.Query(d.Params)
-		for {
-			select {
-			case <-d.stop:
-				return
-			case <-d.timer.C:
-				mdns.Query(d.Params)
-			}
-		}
-	}()
+	if !d.options.Passive {
+		go d.schedule()
+	}
 
 	for {
 		select {
 		case <-d.stop:
 			return
+		case <-d.flush:
+			d.Entries = make([]Entry, 0)
 		case entry := <-d.entriesCh:
-			if entry != nil {
-				found := false
-				for _, existing := range d.Entries {
-					if reflect.DeepEqual(entry, existing) {
-						found = true
-						break
-					}
-				}
-				if !found {
-					d.Entries = append(d.Entries, *entry)
-					// Send the new entry through the discovery channel
-					d.discoveryCh <- *entry
+			if entry.Generation < d.generation.Load() {
+				continue
+			}
+			if entry.Gone {
+				var removed bool
+				if d.Entries, removed = RemoveEntry(d.Entries, entry); removed {
+					d.discoveryCh <- entry
 				}
+				continue
 			}
+			if d.forwarded(entry) {
+				continue
+			}
+			d.Entries, _ = MergeEntry(d.Entries, entry)
+			// Send the new or refreshed entry through the discovery channel
+			d.discoveryCh <- entry
+		}
+	}
+
+}
+
+// forwarded returns whether an entry was already sent to the Discovery unchanged, recently enough for it not to
+// expire: its LastSeen is only refreshed every half EntryTTL, instead of each time it is answered
+func (d *DiscoveryService) forwarded(entry Entry) bool {
+	idx := slices.IndexFunc(d.Entries, func(sent Entry) bool { return sent.ID() == entry.ID() })
+	if idx < 0 {
+		return false
+	}
+	sent := d.Entries[idx]
+	return reflect.DeepEqual(sent.ServiceEntry, entry.ServiceEntry) && entry.LastSeen.Sub(sent.LastSeen) < d.options.EntryTTL()/2
+}
+
+// schedule sends the queries at interval, or with an exponential backoff, until the service is stopped
+func (d *DiscoveryService) schedule() {
+	interval := d.options.interval()
+	if d.options.Backoff {
+		interval = BACKOFF_MIN_INTERVAL
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case <-d.refresh:
+			if d.options.Backoff {
+				interval = BACKOFF_MIN_INTERVAL
+			}
+		case <-timer.C:
+		}
+		if d.paused.Load() { // wait for Resume to refresh
+			continue
+		}
+
+		start := time.Now()
+		d.query()
+
+		timer.Reset(time.Until(start.Add(interval)))
+		if d.options.Backoff {
+			interval = min(2*interval, BACKOFF_MAX_INTERVAL)
+		}
+	}
+}
+
+// query multicasts a query for the service type (and for each type found, with MDNS_META_QUERY), listing the answers
+// already known so that they aren't sent again (RFC 6762 section 7.1). Their entries are refreshed right away instead.
+func (d *DiscoveryService) query() {
+	generation := d.generation.Load()
+	now := time.Now()
+	d.records.Expire(now)
+
+	names := []string{mdns.Fqdn(d.Service, d.Domain)}
+	var known []dns.RR
+	if d.Service == MDNS_META_QUERY {
+		names = append(names, d.records.ServiceTypes(d.Domain)...)
+		known = d.records.Known(mdns.Fqdn(MDNS_META_QUERY, d.Domain), now) // the types, its answers
+	}
+	entries, instances := d.records.Entries(d.Service, d.Domain, d.Interface, now)
+	known = append(known, instances...)
+
+	if !d.multicast(names, known, now) {
+		return
+	}
+
+	for _, entry := range entries {
+		if slices.ContainsFunc(instances, func(rr dns.RR) bool { return strings.EqualFold(rr.(*dns.PTR).Ptr, entry.Name) }) {
+			d.send(entry, generation, false)
+		}
+	}
+}
+
+// queryTypes multicasts a query for service types just found with MDNS_META_QUERY, instead of waiting for the next
+// query without restarting the backoff. None of their instances are complete yet, there are no known answers.
+func (d *DiscoveryService) queryTypes(types []string) {
+	d.multicast(types, nil, time.Now())
+}
+
+// multicast sends the query packets for names with the known answers, and returns whether they were all sent
+func (d *DiscoveryService) multicast(names []string, known []dns.RR, now time.Time) bool {
+	d.lastQuery.Store(now.UnixNano())
+	d.observer.QuerySent(d.ifaceName(), d.Domain)
+	for _, msg := range mdns.QueryPackets(names, known) {
+		if err := d.listener.Send(msg); err != nil {
+			log.Printf("Failed to query on interface %q: %v", d.ifaceName(), err)
+			return false
+		}
+	}
+	return true
+}
+
+// handle adds the records of a response (to a query of any host, or an announcement) to the cache, and sends the
+// entries it completes or updates
+func (d *DiscoveryService) handle(msg *dns.Msg) {
+	if !msg.Response || d.paused.Load() {
+		return
+	}
+	generation := d.generation.Load()
+
+	now := time.Now()
+	if latency := now.Sub(time.Unix(0, d.lastQuery.Load())); latency <= d.options.timeout() {
+		d.observer.ResponseLatency(d.ifaceName(), d.Domain, latency)
+	}
+
+	records := append(append(append([]dns.RR{}, msg.Answer...), msg.Ns...), msg.Extra...)
+	types := d.records.ServiceTypes(d.Domain)
+	goodbyes := d.records.Add(records, now)
+	d.records.Expire(now)
+
+	for _, entry := range goodbyes {
+		if d.instanceOf(entry.Name) {
+			d.send(entry, generation, true)
 		}
 	}
 
+	if d.Service == MDNS_META_QUERY && !d.options.Passive {
+		found := slices.DeleteFunc(d.records.ServiceTypes(d.Domain), func(name string) bool { return slices.Contains(types, name) })
+		if len(found) > 0 {
+			d.queryTypes(found)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, rr := range records {
+		names[strings.ToLower(rr.Header().Name)] = true
+		if srv, ok := rr.(*dns.SRV); ok {
+			names[strings.ToLower(srv.Target)] = true
+		}
+		if ptr, ok := rr.(*dns.PTR); ok {
+			names[strings.ToLower(ptr.Ptr)] = true
+		}
+	}
+	entries, _ := d.records.Entries(d.Service, d.Domain, d.Interface, now)
+	for _, entry := range entries {
+		if names[strings.ToLower(entry.Name)] || names[strings.ToLower(entry.Host)] {
+			d.send(entry, generation, false)
+		}
+	}
+}
+
+// instanceOf returns whether an instance name is of the service type (any type if MDNS_META_QUERY) in the domain
+func (d *DiscoveryService) instanceOf(instance string) bool {
+	instance = strings.ToLower(instance)
+	if d.Service == MDNS_META_QUERY {
+		return strings.HasSuffix(instance, "."+strings.ToLower(dns.Fqdn(strings.Trim(d.Domain, "."))))
+	}
+	return strings.HasSuffix(instance, "."+mdns.Fqdn(d.Service, d.Domain))
+}
+
+// send sends an entry built from the records cached in the given generation to Run, unless the service is stopped.
+// gone sends the removal of an instance that announced its departure instead.
+func (d *DiscoveryService) send(entry *ServiceEntry, generation uint64, gone bool) {
+	e := Entry{
+		ServiceEntry: *entry,
+		LastSeen:     time.Now(),
+		Generation:   generation,
+		Gone:         gone,
+	}
+	if d.Interface != nil {
+		e.Interface = d.Interface.Name
+	}
+
+	select {
+	case d.entriesCh <- e:
+	case <-d.stop:
+	}
+}
+
+// ifaceName returns the name of the interface queried, empty for the default one
+func (d *DiscoveryService) ifaceName() string {
+	if d.Interface == nil {
+		return ""
+	}
+	return d.Interface.Name
 }
@@ -0,0 +1,307 @@
+package network
+
+import (
+	"net"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"gitlab.com/patopest/mdns-discovery/mdns"
+)
+
+// response returns an mDNS response made of the given records, in presentation format
+func response(t *testing.T, records ...string) *dns.Msg {
+	t.Helper()
+	msg := new(dns.Msg)
+	msg.Response = true
+	for _, record := range records {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg.Answer = append(msg.Answer, rr)
+	}
+	return msg
+}
+
+// fakeConn is a mdns.PacketConn receiving the packets of in (nothing if nil), and recording the packets sent
+type fakeConn struct {
+	in     chan []byte
+	closed chan struct{}
+	sent   []*dns.Msg
+}
+
+func (c *fakeConn) ReadFrom(b []byte) (int, int, error) {
+	select {
+	case packet := <-c.in:
+		return copy(b, packet), 0, nil
+	case <-c.closed:
+		return 0, 0, net.ErrClosed
+	}
+}
+
+func (c *fakeConn) Send(b []byte) error {
+	msg := new(dns.Msg)
+	if err := msg.Unpack(b); err != nil {
+		return err
+	}
+	c.sent = append(c.sent, msg)
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	close(c.closed)
+	return nil
+}
+
+func TestFlush(t *testing.T) {
+	d := &Discovery{services: map[string][]*DiscoveryService{}}
+	s := NewDiscoveryService("_http._tcp", DEFAULT_DOMAIN, nil, nil)
+	s.generation = &d.generation
+	d.services["eth0"] = []*DiscoveryService{s}
+
+	printer := response(t,
+		"_http._tcp.local. 4500 IN PTR Printer._http._tcp.local.",
+		"Printer._http._tcp.local. 120 IN SRV 0 0 80 printer.local.",
+		`Printer._http._tcp.local. 4500 IN TXT "path=/"`,
+		"printer.local. 120 IN A 192.168.1.2",
+	)
+	s.handle(printer)
+	if entry := <-s.entriesCh; entry.Generation != 0 {
+		t.Fatalf("entry found before flush of generation %d, want 0", entry.Generation)
+	}
+
+	d.Flush()
+	if got := d.Generation(); got != 1 {
+		t.Fatalf("Generation() = %d after a flush, want 1", got)
+	}
+	if entries, _ := s.records.Entries(s.Service, s.Domain, nil, time.Now()); len(entries) > 0 {
+		t.Errorf("entries still cached after flush: %v", entries)
+	}
+
+	s.handle(response(t, "printer.local. 120 IN A 192.168.1.3")) // not enough to build an entry without the flushed records
+	select {
+	case entry := <-s.entriesCh:
+		t.Fatalf("entry built from flushed records: %+v", entry)
+	default:
+	}
+
+	s.handle(printer)
+	if entry := <-s.entriesCh; entry.Generation != 1 {
+		t.Errorf("entry found after flush of generation %d, want 1", entry.Generation)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	conn := &fakeConn{closed: make(chan struct{})}
+	s := NewDiscoveryService(MDNS_META_QUERY, DEFAULT_DOMAIN, nil, nil)
+	s.listener = mdns.NewListener(nil, conn)
+	defer s.listener.Close()
+
+	now := time.Now()
+	s.records.Add(response(t,
+		"_services._dns-sd._udp.local. 4500 IN PTR _http._tcp.local.",
+		"_http._tcp.local. 4500 IN PTR Printer._http._tcp.local.",
+		"Printer._http._tcp.local. 120 IN SRV 0 0 80 printer.local.",
+		`Printer._http._tcp.local. 4500 IN TXT "path=/"`,
+		"printer.local. 120 IN A 192.168.1.2",
+	).Answer, now)
+	s.records.Add(response(t,
+		"_services._dns-sd._udp.local. 4500 IN PTR _ipp._tcp.local.", // less than half its TTL left
+		"_ipp._tcp.local. 4500 IN PTR Printer._ipp._tcp.local.",      // incomplete
+	).Answer, now.Add(-2500*time.Second))
+
+	s.query()
+
+	packets := conn.sent
+	if len(packets) != 1 {
+		t.Fatalf("%d packets sent, want 1", len(packets))
+	}
+	questions := []string{}
+	for _, q := range packets[0].Question {
+		questions = append(questions, q.Name)
+	}
+	if want := []string{"_services._dns-sd._udp.local.", "_http._tcp.local.", "_ipp._tcp.local."}; !slices.Equal(questions, want) {
+		t.Errorf("questions %v, want %v", questions, want)
+	}
+	answers := []string{}
+	for _, rr := range packets[0].Answer {
+		answers = append(answers, rr.(*dns.PTR).Ptr)
+	}
+	if want := []string{"_http._tcp.local.", "Printer._http._tcp.local."}; !slices.Equal(answers, want) {
+		t.Errorf("known answers %v, want %v", answers, want)
+	}
+
+	select {
+	case entry := <-s.entriesCh:
+		if entry.Name != "Printer._http._tcp.local." {
+			t.Errorf("refreshed %s, want the known answer", entry.Name)
+		}
+	default:
+		t.Error("known answer not refreshed")
+	}
+}
+
+func TestForwarded(t *testing.T) {
+	s := NewDiscoveryService("_http._tcp", DEFAULT_DOMAIN, nil, nil)
+	s.options = Options{Interval: 10 * time.Second} // entries expire after 30s
+	now := time.Now()
+	sent := Entry{
+		ServiceEntry: ServiceEntry{Name: "Printer._http._tcp.local.", Host: "printer.local.", Port: 80, InfoFields: []string{"path=/"}},
+		Interface:    "eth0",
+		LastSeen:     now,
+	}
+	s.Entries = []Entry{sent}
+
+	at := func(entry Entry, d time.Duration) Entry {
+		entry.LastSeen = now.Add(d)
+		return entry
+	}
+	changed := sent
+	changed.InfoFields = []string{"path=/admin"}
+	other := sent
+	other.Name = "Scanner._http._tcp.local."
+
+	tests := []struct {
+		name  string
+		entry Entry
+		want  bool
+	}{
+		{"refreshed", at(sent, 10*time.Second), true},
+		{"refreshed to keep", at(sent, 15*time.Second), false},
+		{"changed", at(changed, time.Second), false},
+		{"new", other, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.forwarded(tt.entry); got != tt.want {
+				t.Errorf("forwarded() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoodbye(t *testing.T) {
+	s := NewDiscoveryService("_http._tcp", DEFAULT_DOMAIN, nil, nil)
+	s.generation = new(atomic.Uint64)
+	other := NewDiscoveryService("_ipp._tcp", DEFAULT_DOMAIN, nil, nil)
+	other.generation = s.generation
+
+	printer := response(t,
+		"_http._tcp.local. 4500 IN PTR Printer._http._tcp.local.",
+		"Printer._http._tcp.local. 120 IN SRV 0 0 80 printer.local.",
+		`Printer._http._tcp.local. 4500 IN TXT "path=/"`,
+		"printer.local. 120 IN A 192.168.1.2",
+	)
+	goodbye := response(t, "_http._tcp.local. 0 IN PTR Printer._http._tcp.local.")
+	s.handle(printer)
+	other.handle(printer)
+	if entry := <-s.entriesCh; entry.Gone {
+		t.Fatalf("entry %+v gone before the goodbye", entry)
+	}
+
+	registry := NewRegistry(time.Minute)
+	events, unsubscribe := registry.Subscribe()
+	defer unsubscribe()
+	registry.Update(Entry{ServiceEntry: ServiceEntry{Name: "Printer._http._tcp.local.", Host: "printer.local.", Port: 80}})
+	if event := <-events; event.Type != EVENT_ADDED {
+		t.Fatalf("event %v, want added", event.Type)
+	}
+
+	s.handle(goodbye)
+	other.handle(goodbye)
+	entry := <-s.entriesCh
+	if !entry.Gone || entry.Name != "Printer._http._tcp.local." || entry.Port != 80 {
+		t.Fatalf("entry %+v, want Printer gone", entry)
+	}
+	select {
+	case entry := <-other.entriesCh:
+		t.Errorf("entry %+v sent by the service of another type", entry)
+	default:
+	}
+
+	registry.Update(entry)
+	if event := <-events; event.Type != EVENT_REMOVED || event.Entry.Name != entry.Name {
+		t.Errorf("event %v of %q, want %q removed", event.Type, event.Entry.Name, entry.Name)
+	}
+	if entries := registry.Entries(); len(entries) > 0 {
+		t.Errorf("entries %v left in the registry", entries)
+	}
+}
+
+func TestQueryNewTypes(t *testing.T) {
+	conn := &fakeConn{closed: make(chan struct{})}
+	s := NewDiscoveryService(MDNS_META_QUERY, DEFAULT_DOMAIN, nil, nil)
+	s.options = Options{Backoff: true}
+	s.listener = mdns.NewListener(nil, conn)
+	defer s.listener.Close()
+
+	s.handle(response(t, "_services._dns-sd._udp.local. 4500 IN PTR _http._tcp.local."))
+	s.handle(response(t,
+		"_services._dns-sd._udp.local. 4500 IN PTR _http._tcp.local.", // already known
+		"_services._dns-sd._udp.local. 4500 IN PTR _ipp._tcp.local.",
+	))
+
+	questions := [][]string{}
+	for _, packet := range conn.sent {
+		names := []string{}
+		for _, q := range packet.Question {
+			names = append(names, q.Name)
+		}
+		questions = append(questions, names)
+	}
+	want := [][]string{{"_http._tcp.local."}, {"_ipp._tcp.local."}}
+	if !slices.EqualFunc(questions, want, slices.Equal) {
+		t.Errorf("questions %v, want %v", questions, want)
+	}
+	select {
+	case <-s.refresh:
+		t.Error("backoff restarted by the types found")
+	default:
+	}
+}
+
+// eventObserver sends the packets it is notified of to events
+type eventObserver struct {
+	nopObserver
+	events chan string
+}
+
+func (o eventObserver) ResponseReceived(iface string) { o.events <- "response on " + iface }
+func (o eventObserver) MalformedPacket(iface string)  { o.events <- "malformed on " + iface }
+
+func TestObserve(t *testing.T) {
+	conn := &fakeConn{in: make(chan []byte), closed: make(chan struct{})}
+	listener := mdns.NewListener(nil, conn)
+	defer listener.Close()
+	observer := eventObserver{events: make(chan string, 10)}
+	d := &Discovery{Observer: observer}
+	d.observe("eth0", listener)
+	for _, service := range []string{"_http._tcp", "_ipp._tcp"} { // also receiving the packets
+		listener.Subscribe(NewDiscoveryService(service, DEFAULT_DOMAIN, nil, nil).handle, nil)
+	}
+
+	query := new(dns.Msg)
+	query.SetQuestion("_http._tcp.local.", dns.TypePTR)
+	for _, msg := range []*dns.Msg{query, response(t, "_services._dns-sd._udp.local. 4500 IN PTR _http._tcp.local.")} {
+		packet, err := msg.Pack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.in <- packet
+	}
+	conn.in <- []byte{0x00, 0x01} // malformed
+
+	got := []string{<-observer.events, <-observer.events}
+	if want := []string{"response on eth0", "malformed on eth0"}; !slices.Equal(got, want) {
+		t.Errorf("events %v, want %v", got, want)
+	}
+	select {
+	case event := <-observer.events:
+		t.Errorf("extra event %q", event)
+	default:
+	}
+}
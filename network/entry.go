@@ -0,0 +1,153 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Entry is a ServiceEntry along with where and when it was last received
+type Entry struct {
+	ServiceEntry
+	Interface  string
+	LastSeen   time.Time
+	Generation uint64 // flushes of the Discovery before it was found, entries of an older generation are stale
+	Gone       bool   // the instance announced its departure with a goodbye record, RFC 6762 section 10.1
+}
+
+// ID returns a key identifying the service instance an entry belongs to, stable across refreshes
+func (e Entry) ID() string {
+	return fmt.Sprintf("%s|%s|%s|%d", e.Interface, e.Name, e.Host, e.Port)
+}
+
+// MergeEntry adds entry to entries or, if an entry with the same ID already exists, replaces it with the new one (its
+// addresses or TXT record may have changed). It returns the updated entries and whether the entry is new.
+func MergeEntry(entries []Entry, entry Entry) ([]Entry, bool) {
+	idx := slices.IndexFunc(entries, func(existing Entry) bool { return existing.ID() == entry.ID() })
+	if idx < 0 {
+		return append(entries, entry), true
+	}
+	entries[idx] = entry
+	return entries, false
+}
+
+// RemoveEntry removes the entry with the same ID as entry from entries. It returns the updated entries and whether
+// one was removed.
+func RemoveEntry(entries []Entry, entry Entry) ([]Entry, bool) {
+	n := len(entries)
+	entries = slices.DeleteFunc(entries, func(existing Entry) bool { return existing.ID() == entry.ID() })
+	return entries, len(entries) < n
+}
+
+// ServiceType returns the service type of the entry's instance name, ex: '_http._tcp'
+func (e Entry) ServiceType() string {
+	for _, proto := range []string{"._tcp.", "._udp."} {
+		end := strings.Index(e.Name, proto)
+		if end < 0 {
+			continue
+		}
+		start := strings.LastIndex(e.Name[:end], "._")
+		if start < 0 {
+			return ""
+		}
+		return e.Name[start+1:end] + proto[:len(proto)-1]
+	}
+	return ""
+}
+
+// Instance returns the unescaped instance part of the entry's name, ex: 'Living Room' for
+// 'Living\ Room._googlecast._tcp.local.'
+func (e Entry) Instance() string {
+	instance := e.Name
+	if service := e.ServiceType(); service != "" {
+		instance, _, _ = strings.Cut(instance, "."+service+".")
+	}
+	return Unescape(instance)
+}
+
+// Unescape decodes the escaped characters of a name or TXT record in presentation format: '\DDD' is the byte of
+// decimal value DDD, ex: '\195\169' for 'é', and any other escaped character is output literally
+func Unescape(s string) string {
+	var buf strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			buf.WriteByte(s[i])
+			continue
+		}
+		end := i + 1
+		for end < len(s) && end < i+4 && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		if end == i+1 { // not a decimal value
+			buf.WriteByte(s[i+1])
+			i++
+			continue
+		}
+		val := 0
+		for _, digit := range s[i+1 : end] {
+			val = val*10 + int(digit-'0')
+		}
+		buf.WriteByte(byte(val))
+		i = end - 1
+	}
+	return buf.String()
+}
+
+// Domain returns the domain of the entry's instance name, ex: 'local'
+func (e Entry) Domain() string {
+	service := e.ServiceType()
+	if service == "" {
+		return ""
+	}
+	_, domain, _ := strings.Cut(e.Name, service+".")
+	return strings.TrimSuffix(domain, ".")
+}
+
+// Hostname returns the entry's host name without the trailing dot
+func (e Entry) Hostname() string {
+	return strings.TrimSuffix(e.Host, ".")
+}
+
+// IP returns the entry's IPv4 address, or its IPv6 address if it has none
+func (e Entry) IP() net.IP {
+	if e.AddrV4 != nil {
+		return e.AddrV4
+	}
+	return e.AddrV6
+}
+
+// TXTFields returns the 'key=value' fields of the entry's TXT record
+func (e Entry) TXTFields() []string {
+	if len(e.InfoFields) > 0 {
+		return e.InfoFields
+	}
+	if e.Info != "" {
+		return strings.Split(e.Info, "|")
+	}
+	return []string{}
+}
+
+// TXT returns the key/value pairs of the entry's TXT record
+func (e Entry) TXT() map[string]string {
+	txt := map[string]string{}
+	for _, field := range e.TXTFields() {
+		k, v, _ := strings.Cut(field, "=")
+		if k != "" {
+			txt[k] = v
+		}
+	}
+	return txt
+}
+
+// TXTValue returns the value of a key of the entry's TXT record
+func (e Entry) TXTValue(key string) (string, bool) {
+	for _, field := range e.TXTFields() {
+		k, v, _ := strings.Cut(field, "=")
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,106 @@
+package network
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMergeEntry(t *testing.T) {
+	now := time.Now()
+	printer := Entry{
+		ServiceEntry: ServiceEntry{Name: "Printer._ipp._tcp.local.", Host: "printer.local.", Port: 631, AddrV4: net.IPv4(192, 168, 1, 2)},
+		Interface:    "eth0",
+		LastSeen:     now,
+	}
+	withIPv6 := printer
+	withIPv6.AddrV6 = net.ParseIP("fe80::2")
+	withIPv6.LastSeen = now.Add(time.Second)
+	withTXT := printer
+	withTXT.InfoFields = []string{"rp=ipp/print"}
+	otherIface := printer
+	otherIface.Interface = "wlan0"
+
+	tests := []struct {
+		name    string
+		entries []Entry
+		entry   Entry
+		added   bool
+		want    []Entry
+	}{
+		{"new", []Entry{}, printer, true, []Entry{printer}},
+		{"refreshed", []Entry{printer}, printer, false, []Entry{printer}},
+		{"address added", []Entry{printer}, withIPv6, false, []Entry{withIPv6}},
+		{"TXT changed", []Entry{printer}, withTXT, false, []Entry{withTXT}},
+		{"other interface", []Entry{printer}, otherIface, true, []Entry{printer, otherIface}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, added := MergeEntry(tt.entries, tt.entry)
+			if added != tt.added {
+				t.Errorf("added = %v, want %v", added, tt.added)
+			}
+			if len(entries) != len(tt.want) {
+				t.Fatalf("got %d entries, want %d", len(entries), len(tt.want))
+			}
+			for i := range entries {
+				if entries[i].ID() != tt.want[i].ID() || !entries[i].AddrV6.Equal(tt.want[i].AddrV6) ||
+					len(entries[i].InfoFields) != len(tt.want[i].InfoFields) || !entries[i].LastSeen.Equal(tt.want[i].LastSeen) {
+					t.Errorf("entry %d = %+v, want %+v", i, entries[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEntryNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		domain  string
+	}{
+		{"Printer._ipp._tcp.local.", "_ipp._tcp", "local"},
+		{"Living Room._googlecast._tcp.local.", "_googlecast._tcp", "local"},
+		{"host._ssh._tcp.example.com.", "_ssh._tcp", "example.com"},
+		{"no-service.local.", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Entry{ServiceEntry: ServiceEntry{Name: tt.name}}
+			if got := e.ServiceType(); got != tt.service {
+				t.Errorf("ServiceType() = %q, want %q", got, tt.service)
+			}
+			if got := e.Domain(); got != tt.domain {
+				t.Errorf("Domain() = %q, want %q", got, tt.domain)
+			}
+		})
+	}
+}
+
+func TestInstance(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Printer._ipp._tcp.local.", "Printer"},
+		{`Living\ Room._googlecast._tcp.local.`, "Living Room"},
+		{`Caf\195\169._http._tcp.local.`, "Café"},
+		{`Caf\195\1692._http._tcp.local.`, "Café2"}, // a digit after a 3 digits escape
+		{`Dr\.\ Who\'s\ \"TV\"._airplay._tcp.local.`, `Dr. Who's "TV"`},
+		{`www\.example\.com._http._tcp.local.`, "www.example.com"},
+		{`back\\slash._http._tcp.local.`, `back\slash`},
+		{`trailing\`, `trailing\`},
+		{"no-service.local.", "no-service.local."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := Entry{ServiceEntry: ServiceEntry{Name: tt.name}}
+			if got := e.Instance(); got != tt.want {
+				t.Errorf("Instance() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,24 @@ package network
 
 import (
 	"net"
+	"time"
 )
 
+const FAKE_INTERFACE = "fake0"
+
+// FakeEntries wraps fake ServiceEntries as if they were just discovered
+func FakeEntries(data []ServiceEntry) []Entry {
+	entries := make([]Entry, 0, len(data))
+	for _, entry := range data {
+		entries = append(entries, Entry{
+			ServiceEntry: entry,
+			Interface:    FAKE_INTERFACE,
+			LastSeen:     time.Now(),
+		})
+	}
+	return entries
+}
+
 // Fake data to populate table
 
 var FakeData = []ServiceEntry{
@@ -431,4 +447,39 @@ var FakeDataLong = append(FakeData, []ServiceEntry{
 		Port:   3100,
 		Info:   "streams=12|bytes=1.2GB",
 	},
+	{
+		Name:   "Agecanonix._hap._tcp._local.",
+		Host:   "Agecanonix.local.",
+		AddrV4: net.IPv4(192, 168, 1, 20),
+		Port:   51827,
+		Info:   "c#=2|ff=0|id=3A:1B:7C:22:9F:10|md=Bridge|pv=1.1|s#=1|sf=0|ci=2",
+	},
+	{
+		Name:   "Falbala._googlecast._tcp._local.",
+		Host:   "Falbala.local.",
+		AddrV4: net.IPv4(192, 168, 1, 14),
+		Port:   8009,
+		Info:   "id=5e4c3a0b|ve=05|md=Chromecast Ultra|ca=201221|fn=Living Room|st=0|rs=",
+	},
+	{
+		Name:   "Abraracourcix._ipp._tcp._local.",
+		Host:   "Abraracourcix.local.",
+		AddrV4: net.IPv4(192, 168, 1, 13),
+		Port:   631,
+		Info:   "txtvers=1|rp=printers/office|ty=HP LaserJet Pro M404|note=Office|pdl=application/pdf,image/urf,image/pwg-raster|UUID=8d3b6b8c-4f2e-4a53-9a3e-1f6c5d2e7b90|TLS=1.2|Color=F|Duplex=T",
+	},
 }...)
+
+// Fake neighbor table resolving the MAC addresses of some of the fake hosts
+var FakeNeighbors = StaticNeighborTable{
+	"192.168.1.1":   fakeMAC("dc:a6:32:12:34:56"),
+	"192.168.1.14":  fakeMAC("f4:f5:e8:01:02:03"),
+	"192.168.1.34":  fakeMAC("84:cc:a8:9a:bc:de"),
+	"192.168.1.145": fakeMAC("00:11:32:aa:bb:cc"),
+	"192.168.1.254": fakeMAC("f0:18:98:76:54:32"),
+}
+
+func fakeMAC(s string) net.HardwareAddr {
+	mac, _ := net.ParseMAC(s)
+	return mac
+}
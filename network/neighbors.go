@@ -0,0 +1,158 @@
+package network
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Kernel IPv4 neighbor (ARP) table on Linux
+const ARP_TABLE_PATH = "/proc/net/arp"
+
+// NeighborTable looks up the MAC addresses of the hosts on the local network
+type NeighborTable interface {
+	// Refresh reloads the table from the system
+	Refresh() error
+	// Lookup returns the MAC address of an IP, if known
+	Lookup(ip net.IP) (net.HardwareAddr, bool)
+}
+
+// LookupMAC returns the MAC address of an entry, trying its IPv4 then IPv6 address
+func LookupMAC(t NeighborTable, entry Entry) net.HardwareAddr {
+	for _, ip := range []net.IP{entry.AddrV4, entry.AddrV6} {
+		if mac, ok := t.Lookup(ip); ok {
+			return mac
+		}
+	}
+	return nil
+}
+
+// NewNeighborTable returns the system's neighbor table: IPv4 and IPv6 neighbors from netlink on
+// Linux, falling back to the ARP table file (IPv4 only)
+func NewNeighborTable() NeighborTable {
+	return &fallbackNeighborTable{
+		tables: []NeighborTable{newNetlinkNeighborTable(), NewARPFileTable(ARP_TABLE_PATH)},
+	}
+}
+
+// neighborMap is a snapshot of a neighbor table by IP
+type neighborMap struct {
+	neighbors map[string]net.HardwareAddr
+	mu        sync.RWMutex
+}
+
+func (t *neighborMap) set(neighbors map[string]net.HardwareAddr) {
+	t.mu.Lock()
+	t.neighbors = neighbors
+	t.mu.Unlock()
+}
+
+func (t *neighborMap) Lookup(ip net.IP) (net.HardwareAddr, bool) {
+	if ip == nil {
+		return nil, false
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	mac, ok := t.neighbors[ip.String()]
+	return mac, ok
+}
+
+// ARPFileTable is a neighbor table read from a file in the /proc/net/arp format
+type ARPFileTable struct {
+	neighborMap
+	Path string
+}
+
+// NewARPFileTable returns a neighbor table read from path (ex: a fake table for tests)
+func NewARPFileTable(path string) *ARPFileTable {
+	return &ARPFileTable{Path: path}
+}
+
+func (t *ARPFileTable) Refresh() error {
+	neighbors, err := ReadARPTable(t.Path)
+	if err != nil {
+		return err
+	}
+	t.set(neighbors)
+	return nil
+}
+
+// StaticNeighborTable is a fixed neighbor table, by IP
+type StaticNeighborTable map[string]net.HardwareAddr
+
+func (t StaticNeighborTable) Refresh() error { return nil }
+func (t StaticNeighborTable) Lookup(ip net.IP) (net.HardwareAddr, bool) {
+	if ip == nil {
+		return nil, false
+	}
+	mac, ok := t[ip.String()]
+	return mac, ok
+}
+
+// fallbackNeighborTable uses the first table that refreshes successfully
+type fallbackNeighborTable struct {
+	tables []NeighborTable
+	active NeighborTable
+	mu     sync.RWMutex
+}
+
+func (t *fallbackNeighborTable) Refresh() error {
+	var err error
+	for _, table := range t.tables {
+		if err = table.Refresh(); err == nil {
+			t.mu.Lock()
+			t.active = table
+			t.mu.Unlock()
+			return nil
+		}
+	}
+	return err
+}
+
+func (t *fallbackNeighborTable) Lookup(ip net.IP) (net.HardwareAddr, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.active == nil {
+		return nil, false
+	}
+	return t.active.Lookup(ip)
+}
+
+// ReadARPTable returns the MAC addresses of the IPv4 neighbors listed in an ARP table file
+// (/proc/net/arp format), by IP
+func ReadARPTable(path string) (map[string]net.HardwareAddr, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	neighbors := map[string]net.HardwareAddr{}
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// IP address  HW type  Flags  HW address  Mask  Device
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || fields[2] == "0x0" { // incomplete entry
+			continue
+		}
+		ip := net.ParseIP(fields[0])
+		mac, err := net.ParseMAC(fields[3])
+		if ip == nil || err != nil || isZeroMAC(mac) {
+			continue
+		}
+		neighbors[ip.String()] = mac
+	}
+	return neighbors, scanner.Err()
+}
+
+func isZeroMAC(mac net.HardwareAddr) bool {
+	for _, b := range mac {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+//go:build linux
+
+package network
+
+import (
+	"encoding/binary"
+	"net"
+	"syscall"
+)
+
+// Neighbor states (linux/neighbour.h) of entries without a usable MAC address
+const (
+	NUD_INCOMPLETE = 0x01
+	NUD_FAILED     = 0x20
+)
+
+// Neighbor attributes (linux/neighbour.h)
+const (
+	NDA_DST    = 1
+	NDA_LLADDR = 2
+)
+
+const sizeofNdMsg = 12 // struct ndmsg
+
+// netlinkNeighborTable reads the kernel's IPv4 and IPv6 neighbor tables with RTM_GETNEIGH
+type netlinkNeighborTable struct {
+	neighborMap
+}
+
+func newNetlinkNeighborTable() NeighborTable {
+	return &netlinkNeighborTable{}
+}
+
+func (t *netlinkNeighborTable) Refresh() error {
+	data, err := syscall.NetlinkRIB(syscall.RTM_GETNEIGH, syscall.AF_UNSPEC)
+	if err != nil {
+		return err
+	}
+	msgs, err := syscall.ParseNetlinkMessage(data)
+	if err != nil {
+		return err
+	}
+
+	neighbors := map[string]net.HardwareAddr{}
+	for _, msg := range msgs {
+		if msg.Header.Type != syscall.RTM_NEWNEIGH || len(msg.Data) < sizeofNdMsg {
+			continue
+		}
+		state := binary.NativeEndian.Uint16(msg.Data[8:10])
+		if state&(NUD_INCOMPLETE|NUD_FAILED) != 0 {
+			continue
+		}
+
+		var ip net.IP
+		var mac net.HardwareAddr
+		attrs := msg.Data[sizeofNdMsg:]
+		for len(attrs) >= syscall.SizeofRtAttr {
+			length := int(binary.NativeEndian.Uint16(attrs[0:2]))
+			kind := binary.NativeEndian.Uint16(attrs[2:4])
+			if length < syscall.SizeofRtAttr || length > len(attrs) {
+				break
+			}
+			value := attrs[syscall.SizeofRtAttr:length]
+			switch kind {
+			case NDA_DST:
+				ip = net.IP(append([]byte{}, value...))
+			case NDA_LLADDR:
+				mac = net.HardwareAddr(append([]byte{}, value...))
+			}
+			attrs = attrs[min(rtaAlign(length), len(attrs)):]
+		}
+
+		if ip != nil && len(mac) == 6 && !isZeroMAC(mac) {
+			neighbors[ip.String()] = mac
+		}
+	}
+
+	t.set(neighbors)
+	return nil
+}
+
+func rtaAlign(length int) int {
+	return (length + syscall.RTA_ALIGNTO - 1) & ^(syscall.RTA_ALIGNTO - 1)
+}
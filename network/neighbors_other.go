@@ -0,0 +1,23 @@
+//go:build !linux
+
+package network
+
+import (
+	"errors"
+	"net"
+)
+
+// netlinkNeighborTable is only available on Linux
+type netlinkNeighborTable struct{}
+
+func newNetlinkNeighborTable() NeighborTable {
+	return &netlinkNeighborTable{}
+}
+
+func (t *netlinkNeighborTable) Refresh() error {
+	return errors.New("netlink neighbor table not supported on this platform")
+}
+
+func (t *netlinkNeighborTable) Lookup(ip net.IP) (net.HardwareAddr, bool) {
+	return nil, false
+}
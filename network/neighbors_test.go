@@ -0,0 +1,77 @@
+package network
+
+import (
+	"net"
+	"testing"
+)
+
+func TestARPFileTable(t *testing.T) {
+	table := NewARPFileTable("testdata/arp")
+	if err := table.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip  string
+		mac string // empty if unknown
+	}{
+		{"192.168.1.1", "aa:bb:cc:00:00:01"},
+		{"192.168.1.145", "aa:bb:cc:00:00:91"},
+		{"192.168.1.20", ""}, // incomplete
+		{"192.168.1.21", ""}, // no MAC address
+		{"192.168.1.99", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			mac, ok := table.Lookup(net.ParseIP(tt.ip))
+			if ok != (tt.mac != "") || (ok && mac.String() != tt.mac) {
+				t.Errorf("Lookup() = %v, %v, want %q", mac, ok, tt.mac)
+			}
+		})
+	}
+}
+
+func TestLookupMAC(t *testing.T) {
+	table := StaticNeighborTable{
+		"192.168.1.1": net.HardwareAddr{0xaa, 0xbb, 0xcc, 0, 0, 1},
+		"fe80::2":     net.HardwareAddr{0xaa, 0xbb, 0xcc, 0, 0, 2},
+	}
+
+	tests := []struct {
+		name  string
+		entry ServiceEntry
+		mac   string
+	}{
+		{"IPv4", ServiceEntry{AddrV4: net.ParseIP("192.168.1.1"), AddrV6: net.ParseIP("fe80::2")}, "aa:bb:cc:00:00:01"},
+		{"IPv6 fallback", ServiceEntry{AddrV4: net.ParseIP("192.168.1.2"), AddrV6: net.ParseIP("fe80::2")}, "aa:bb:cc:00:00:02"},
+		{"unknown", ServiceEntry{AddrV4: net.ParseIP("192.168.1.2")}, ""},
+		{"no address", ServiceEntry{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LookupMAC(table, Entry{ServiceEntry: tt.entry}).String(); got != tt.mac {
+				t.Errorf("LookupMAC() = %q, want %q", got, tt.mac)
+			}
+		})
+	}
+}
+
+func TestFallbackNeighborTable(t *testing.T) {
+	table := &fallbackNeighborTable{
+		tables: []NeighborTable{NewARPFileTable("testdata/missing"), NewARPFileTable("testdata/arp")},
+	}
+	if _, ok := table.Lookup(net.ParseIP("192.168.1.1")); ok {
+		t.Error("Lookup() found a MAC address before Refresh")
+	}
+	if err := table.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if mac, ok := table.Lookup(net.ParseIP("192.168.1.1")); !ok || mac.String() != "aa:bb:cc:00:00:01" {
+		t.Errorf("Lookup() = %v, %v, want the MAC address of the second table", mac, ok)
+	}
+
+	table.tables = []NeighborTable{NewARPFileTable("testdata/missing")}
+	if err := table.Refresh(); err == nil {
+		t.Error("Refresh() succeeded without any table available")
+	}
+}
@@ -0,0 +1,19 @@
+package network
+
+import "time"
+
+// Observer is notified of the activity of the discovery services, ex: to export metrics
+type Observer interface {
+	QuerySent(iface, domain string)
+	ResponseReceived(iface string)                               // once per packet, whichever services it answers
+	ResponseLatency(iface, domain string, latency time.Duration) // of a response to the last query, within its timeout
+	MalformedPacket(iface string)
+}
+
+// nopObserver ignores all notifications
+type nopObserver struct{}
+
+func (nopObserver) QuerySent(iface, domain string)                              {}
+func (nopObserver) ResponseReceived(iface string)                               {}
+func (nopObserver) ResponseLatency(iface, domain string, latency time.Duration) {}
+func (nopObserver) MalformedPacket(iface string)                                {}
@@ -0,0 +1,160 @@
+package network
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How long an entry stays in a Registry without being seen again
+const DEFAULT_ENTRY_TTL = 3 * QUERY_INTERVAL * time.Second
+
+// Size of the subscribers' channels, events are dropped when full
+const EVENTS_BUFFER_SIZE = 256
+
+// EventType is the kind of change of a Registry
+type EventType int
+
+const (
+	EVENT_ADDED   EventType = iota // new service instance
+	EVENT_UPDATED                  // instance announced with a different record (ex: TXT)
+	EVENT_REMOVED                  // instance not seen for the registry TTL, or gone with a goodbye record
+)
+
+func (t EventType) String() string {
+	switch t {
+	case EVENT_ADDED:
+		return "added"
+	case EVENT_UPDATED:
+		return "updated"
+	case EVENT_REMOVED:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a change of the entries of a Registry
+type Event struct {
+	Type  EventType
+	Entry Entry
+	Time  time.Time
+}
+
+// Registry keeps the entries currently announced on the network, by ID, dropping the ones not seen
+// for TTL, and notifies subscribers of the changes
+type Registry struct {
+	TTL time.Duration
+
+	entries     map[string]Entry
+	subscribers map[chan Event]struct{}
+	mu          sync.RWMutex
+}
+
+// NewRegistry returns an empty registry expiring entries after ttl
+func NewRegistry(ttl time.Duration) *Registry {
+	return &Registry{
+		TTL:         ttl,
+		entries:     map[string]Entry{},
+		subscribers: map[chan Event]struct{}{},
+	}
+}
+
+// Run adds the entries received on entriesCh and expires the stale ones until ctx is cancelled
+func (r *Registry) Run(ctx context.Context, entriesCh <-chan Entry) {
+	ticker := time.NewTicker(max(r.TTL/4, time.Second))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry := <-entriesCh:
+			r.Update(entry)
+		case now := <-ticker.C:
+			r.Expire(now)
+		}
+	}
+}
+
+// Update adds a new entry or refreshes an existing one, or removes it if it is gone
+func (r *Registry) Update(entry Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, ok := r.entries[entry.ID()]
+	if entry.Gone {
+		if ok {
+			delete(r.entries, entry.ID())
+			r.publish(Event{Type: EVENT_REMOVED, Entry: existing, Time: entry.LastSeen})
+		}
+		return
+	}
+	r.entries[entry.ID()] = entry
+	switch {
+	case !ok:
+		r.publish(Event{Type: EVENT_ADDED, Entry: entry, Time: entry.LastSeen})
+	case !reflect.DeepEqual(existing.ServiceEntry, entry.ServiceEntry):
+		r.publish(Event{Type: EVENT_UPDATED, Entry: entry, Time: entry.LastSeen})
+	}
+}
+
+// Expire removes the entries last seen more than TTL before now
+func (r *Registry) Expire(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, entry := range r.entries {
+		if now.Sub(entry.LastSeen) > r.TTL {
+			delete(r.entries, id)
+			r.publish(Event{Type: EVENT_REMOVED, Entry: entry, Time: now})
+		}
+	}
+}
+
+// Entries returns the current entries, sorted by ID
+func (r *Registry) Entries() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]Entry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b Entry) int {
+		return strings.Compare(a.ID(), b.ID())
+	})
+	return entries
+}
+
+// Subscribe returns a channel receiving the registry events until unsubscribe is called
+func (r *Registry) Subscribe() (events <-chan Event, unsubscribe func()) {
+	ch := make(chan Event, EVENTS_BUFFER_SIZE)
+	r.mu.Lock()
+	r.subscribers[ch] = struct{}{}
+	r.mu.Unlock()
+
+	return ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if _, ok := r.subscribers[ch]; ok {
+			delete(r.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// publish sends an event to all subscribers, without blocking on slow ones. Must be called with the lock held.
+func (r *Registry) publish(event Event) {
+	for ch := range r.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("registry: dropped %s event of %s, subscriber too slow", event.Type, event.Entry.Name)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package probe
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// Default HTTP prober settings
+const (
+	DEFAULT_HTTP_TIMEOUT = 3 * time.Second // of the whole request, body included
+	MAX_HTTP_BODY_SIZE   = 64 * 1024       // read to look for the page title
+)
+
+// HTTP(S) service types and the scheme to fetch them with
+var httpSchemes = map[string]string{
+	"_http._tcp":  "http",
+	"_https._tcp": "https",
+}
+
+var titleRegexp = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// HTTPInfo is the metadata of a web service
+type HTTPInfo struct {
+	URL      string    `json:"url"`
+	Status   string    `json:"status,omitempty"` // ex: '200 OK'
+	Server   string    `json:"server,omitempty"` // Server header
+	Title    string    `json:"title,omitempty"`  // HTML page title
+	Location string    `json:"location,omitempty"`
+	Cert     *CertInfo `json:"cert,omitempty"` // HTTPS only
+	Error    string    `json:"error,omitempty"`
+}
+
+// CertInfo is the TLS certificate presented by a web service
+type CertInfo struct {
+	Subject  string    `json:"subject"`
+	Issuer   string    `json:"issuer"`
+	NotAfter time.Time `json:"not_after"`
+}
+
+// HTTPTarget is a web service to fetch
+type HTTPTarget struct {
+	ID   string // entry ID the metadata belongs to
+	URL  string // with the IP as host, ex: 'http://192.168.1.10:8080/admin'
+	Host string // host name sent in the Host header and TLS SNI
+}
+
+// HTTPTargetOf returns the URL of an '_http._tcp' or '_https._tcp' entry, at the path of its 'path' TXT value
+func HTTPTargetOf(entry network.Entry) (HTTPTarget, bool) {
+	scheme, ok := httpSchemes[entry.ServiceType()]
+	ip := entry.IP()
+	if !ok || ip == nil || entry.Port == 0 {
+		return HTTPTarget{}, false
+	}
+
+	url := fmt.Sprintf("%s://%s/", scheme, net.JoinHostPort(ip.String(), strconv.Itoa(entry.Port)))
+	if path, ok := entry.TXTValue("path"); ok {
+		url += strings.TrimPrefix(path, "/")
+	}
+	return HTTPTarget{ID: entry.ID(), URL: url, Host: entry.Hostname()}, true
+}
+
+// HTTPProber fetches the metadata of web services. Redirects are not followed and certificates
+// are not verified (most devices have self-signed ones), only reported.
+type HTTPProber struct {
+	Timeout time.Duration // of each request
+}
+
+// NewHTTPProber returns an HTTP prober with the default timeout
+func NewHTTPProber() *HTTPProber {
+	return &HTTPProber{Timeout: DEFAULT_HTTP_TIMEOUT}
+}
+
+// Fetch gets the target's page and returns its metadata, with the error if it failed
+func (p *HTTPProber) Fetch(ctx context.Context, target HTTPTarget) HTTPInfo {
+	info := HTTPInfo{URL: target.URL}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.URL, nil)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	req.Host = target.Host
+
+	resp, err := p.client(target.Host).Do(req)
+	if err != nil {
+		info.Error = reason(err)
+		return info
+	}
+	defer resp.Body.Close()
+
+	info.Status = resp.Status
+	info.Server = resp.Header.Get("Server")
+	info.Location = resp.Header.Get("Location")
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		cert := resp.TLS.PeerCertificates[0]
+		info.Cert = &CertInfo{
+			Subject:  cert.Subject.String(),
+			Issuer:   cert.Issuer.String(),
+			NotAfter: cert.NotAfter,
+		}
+	}
+
+	if strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_HTTP_BODY_SIZE)) // title may be found in a partial body
+		if match := titleRegexp.FindSubmatch(body); match != nil {
+			info.Title = strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+		}
+	}
+	return info
+}
+
+// client returns a single-use HTTP client sending serverName in the TLS handshake (SNI)
+func (p *HTTPProber) client(serverName string) *http.Client {
+	return &http.Client{
+		Timeout: p.Timeout,
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{ServerName: serverName, InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
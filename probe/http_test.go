@@ -0,0 +1,127 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+func TestFetch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "lighttpd/1.4")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><head><TITLE lang=en>\n  Printer &amp; Scanner\n</TITLE></head></html>"))
+	})
+	mux.HandleFunc("/host", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<title>" + r.Host + "</title>"))
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"title": "<title>not a page</title>"}`))
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/login", http.StatusFound)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("redirect followed")
+	})
+	mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<title>Large</title>" + strings.Repeat(" ", 4*MAX_HTTP_BODY_SIZE)))
+	})
+	mux.HandleFunc("/late-title", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(strings.Repeat(" ", MAX_HTTP_BODY_SIZE) + "<title>Too late</title>"))
+	})
+	mux.HandleFunc("/partial", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><title>Partial</title>"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done() // the rest of the body never comes
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	plain := httptest.NewServer(mux)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(mux)
+	defer secure.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		want HTTPInfo
+	}{
+		{"page", plain.URL + "/", HTTPInfo{Status: "200 OK", Server: "lighttpd/1.4", Title: "Printer & Scanner"}},
+		{"host header", plain.URL + "/host", HTTPInfo{Status: "200 OK", Title: "printer.local"}},
+		{"not HTML", plain.URL + "/json", HTTPInfo{Status: "200 OK"}},
+		{"redirect", plain.URL + "/redirect", HTTPInfo{Status: "302 Found", Location: "/login"}},
+		{"large body", plain.URL + "/large", HTTPInfo{Status: "200 OK", Title: "Large"}},
+		{"title beyond limit", plain.URL + "/late-title", HTTPInfo{Status: "200 OK"}},
+		{"partial body", plain.URL + "/partial", HTTPInfo{Status: "200 OK", Title: "Partial"}},
+		{"timeout", plain.URL + "/slow", HTTPInfo{Error: "timeout"}},
+		{"TLS", secure.URL + "/", HTTPInfo{Status: "200 OK", Server: "lighttpd/1.4", Title: "Printer & Scanner"}},
+		{"TLS redirect", secure.URL + "/redirect", HTTPInfo{Status: "302 Found", Location: "/login"}},
+	}
+
+	p := &HTTPProber{Timeout: 300 * time.Millisecond}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := p.Fetch(context.Background(), HTTPTarget{URL: tt.url, Host: "printer.local"})
+			tt.want.URL = tt.url
+			secure := strings.HasPrefix(tt.url, "https://")
+			if secure != (info.Cert != nil) {
+				t.Errorf("Fetch() cert = %v, want one: %v", info.Cert, secure)
+			}
+			info.Cert = nil
+			if info != tt.want {
+				t.Errorf("Fetch() = %+v, want %+v", info, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchCert(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	info := NewHTTPProber().Fetch(context.Background(), HTTPTarget{URL: server.URL, Host: "example.com"})
+	if info.Error != "" {
+		t.Fatalf("Fetch() error: %s", info.Error)
+	}
+	cert := server.Certificate()
+	if info.Cert == nil || info.Cert.Subject != cert.Subject.String() || !info.Cert.NotAfter.Equal(cert.NotAfter) {
+		t.Errorf("Fetch() cert = %+v, want the server's (%s, %s)", info.Cert, cert.Subject, cert.NotAfter)
+	}
+}
+
+func TestHTTPTargetOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry network.ServiceEntry
+		url   string // empty if not a web service
+	}{
+		{"HTTP", network.ServiceEntry{Name: "NAS._http._tcp.local.", AddrV4: net.ParseIP("192.168.1.10"), Port: 80}, "http://192.168.1.10:80/"},
+		{"path", network.ServiceEntry{Name: "NAS._http._tcp.local.", AddrV4: net.ParseIP("192.168.1.10"), Port: 8080, InfoFields: []string{"path=/admin"}}, "http://192.168.1.10:8080/admin"},
+		{"HTTPS IPv6", network.ServiceEntry{Name: "NAS._https._tcp.local.", AddrV6: net.ParseIP("fe80::1"), Port: 443}, "https://[fe80::1]:443/"},
+		{"not HTTP", network.ServiceEntry{Name: "NAS._smb._tcp.local.", AddrV4: net.ParseIP("192.168.1.10"), Port: 445}, ""},
+		{"no address", network.ServiceEntry{Name: "NAS._http._tcp.local.", Port: 80}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, ok := HTTPTargetOf(network.Entry{ServiceEntry: tt.entry})
+			if ok != (tt.url != "") || target.URL != tt.url {
+				t.Errorf("HTTPTargetOf() = %q, %v, want %q", target.URL, ok, tt.url)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// Default prober settings
+const (
+	DEFAULT_TIMEOUT     = 2 * time.Second
+	DEFAULT_INTERVAL    = 50 * time.Millisecond // between the start of 2 probes
+	DEFAULT_CONCURRENCY = 8
+)
+
+// State is the reachability of a service
+type State int
+
+const (
+	STATE_UNKNOWN  State = iota // not probed
+	STATE_PROBING               // probe in progress
+	STATE_UP                    // TCP connection accepted or UDP reply received
+	STATE_DOWN                  // connection refused, unreachable or timed out
+	STATE_NO_REPLY              // UDP datagram sent without reply nor error, the service may still be up
+)
+
+func (s State) String() string {
+	switch s {
+	case STATE_PROBING:
+		return "probing"
+	case STATE_UP:
+		return "up"
+	case STATE_DOWN:
+		return "down"
+	case STATE_NO_REPLY:
+		return "no reply"
+	default:
+		return ""
+	}
+}
+
+// Result is the outcome of a probe
+type Result struct {
+	State   State
+	Latency time.Duration // time to connect or to receive a reply, when up
+	Err     error         // reason the service is down
+	Time    time.Time     // when the probe finished
+}
+
+// String formats the result for display, ex: 'up 3ms' or 'down (connection refused)'
+func (r Result) String() string {
+	switch r.State {
+	case STATE_UP:
+		return fmt.Sprintf("%s %s", r.State, formatLatency(r.Latency))
+	case STATE_DOWN:
+		if r.Err != nil {
+			return fmt.Sprintf("%s (%s)", r.State, reason(r.Err))
+		}
+	}
+	return r.State.String()
+}
+
+// Target is a service to probe
+type Target struct {
+	ID      string // entry ID the result belongs to
+	Network string // 'tcp' or 'udp'
+	Address string // 'host:port'
+}
+
+// TargetOf returns the probe target of an entry: its IP and port, over UDP for '_udp' services
+func TargetOf(entry network.Entry) (Target, bool) {
+	ip := entry.IP()
+	if ip == nil || entry.Port == 0 {
+		return Target{}, false
+	}
+	proto := "tcp"
+	if strings.HasSuffix(entry.ServiceType(), "._udp") {
+		proto = "udp"
+	}
+	return Target{
+		ID:      entry.ID(),
+		Network: proto,
+		Address: net.JoinHostPort(ip.String(), strconv.Itoa(entry.Port)),
+	}, true
+}
+
+// TargetResult is the result of probing a target
+type TargetResult struct {
+	Target
+	Result
+}
+
+// Prober checks that services accept connections
+type Prober struct {
+	Timeout     time.Duration // of a single probe
+	Interval    time.Duration // minimum delay between the start of 2 probes (rate limit)
+	Concurrency int           // maximum number of probes in progress
+}
+
+// New returns a prober with the default settings
+func New() *Prober {
+	return &Prober{
+		Timeout:     DEFAULT_TIMEOUT,
+		Interval:    DEFAULT_INTERVAL,
+		Concurrency: DEFAULT_CONCURRENCY,
+	}
+}
+
+// ProbeAll probes the targets with rate limiting, sending each result on results which is closed
+// when all are done or ctx is cancelled. Targets not probed before cancellation have no result.
+func (p *Prober) ProbeAll(ctx context.Context, targets []Target, results chan<- TargetResult) {
+	defer close(results)
+
+	ticker := time.NewTicker(max(p.Interval, time.Millisecond))
+	defer ticker.Stop()
+	slots := make(chan struct{}, max(p.Concurrency, 1))
+	var wg sync.WaitGroup
+
+	for i, target := range targets {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+			case <-ticker.C:
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case slots <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := p.Probe(ctx, target.Network, target.Address)
+			<-slots
+			if ctx.Err() != nil { // cancelled during the probe
+				return
+			}
+			select {
+			case results <- TargetResult{Target: target, Result: result}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Probe checks a single 'host:port' address, with a TCP connect or a UDP datagram
+func (p *Prober) Probe(ctx context.Context, proto string, address string) Result {
+	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	var result Result
+	switch proto {
+	case "udp":
+		result = probeUDP(ctx, address)
+	default:
+		result = probeTCP(ctx, address)
+	}
+	result.Time = time.Now()
+	return result
+}
+
+// probeTCP opens and closes a TCP connection
+func probeTCP(ctx context.Context, address string) Result {
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return Result{State: STATE_DOWN, Err: err}
+	}
+	latency := time.Since(start)
+	conn.Close()
+	return Result{State: STATE_UP, Latency: latency}
+}
+
+// probeUDP sends an empty datagram and waits for a reply. Most UDP services ignore unexpected
+// datagrams, so only an ICMP 'port unreachable' (seen as a refused connection) means down.
+func probeUDP(ctx context.Context, address string) Result {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", address)
+	if err != nil {
+		return Result{State: STATE_DOWN, Err: err}
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) }) // cancellation
+	defer stop()
+
+	start := time.Now()
+	if _, err := conn.Write([]byte{}); err != nil {
+		return Result{State: STATE_DOWN, Err: err}
+	}
+	buf := make([]byte, 1)
+	if _, err := conn.Read(buf); err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return Result{State: STATE_DOWN, Err: err}
+		}
+		return Result{State: STATE_NO_REPLY}
+	}
+	return Result{State: STATE_UP, Latency: time.Since(start)}
+}
+
+// reason returns a short description of a probe error
+func reason(err error) string {
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection refused"
+	case errors.Is(err, syscall.ECONNRESET):
+		return "connection reset"
+	case errors.Is(err, syscall.EHOSTUNREACH):
+		return "host unreachable"
+	case errors.Is(err, syscall.ENETUNREACH):
+		return "network unreachable"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timeout"
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	return err.Error()
+}
+
+// formatLatency rounds a latency for display, ex: '3ms' or '250µs'
+func formatLatency(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(time.Millisecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
@@ -0,0 +1,199 @@
+package probe
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// tcpListener returns the address of a local TCP listener accepting connections
+func tcpListener(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return l.Addr().String()
+}
+
+// udpListener returns the address of a local UDP listener, replying to each datagram if reply is set
+func udpListener(t *testing.T, reply bool) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			_, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if reply {
+				conn.WriteTo([]byte("pong"), addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+// closedPort returns a local address nothing listens on
+func closedPort(t *testing.T, proto string) string {
+	t.Helper()
+	var address string
+	if proto == "udp" {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		address = conn.LocalAddr().String()
+		conn.Close()
+	} else {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		address = l.Addr().String()
+		l.Close()
+	}
+	return address
+}
+
+func TestProbe(t *testing.T) {
+	tests := []struct {
+		name    string
+		proto   string
+		address string
+		state   State
+		reason  string
+	}{
+		{"TCP up", "tcp", tcpListener(t), STATE_UP, ""},
+		{"TCP refused", "tcp", closedPort(t, "tcp"), STATE_DOWN, "connection refused"},
+		{"UDP reply", "udp", udpListener(t, true), STATE_UP, ""},
+		{"UDP refused", "udp", closedPort(t, "udp"), STATE_DOWN, "connection refused"},
+		{"UDP no reply", "udp", udpListener(t, false), STATE_NO_REPLY, ""},
+	}
+
+	p := &Prober{Timeout: 300 * time.Millisecond}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := p.Probe(context.Background(), tt.proto, tt.address)
+			if result.State != tt.state {
+				t.Fatalf("Probe() = %v, want %v", result, tt.state)
+			}
+			if tt.reason != "" && (result.Err == nil || reason(result.Err) != tt.reason) {
+				t.Errorf("Probe() error = %v, want %q", result.Err, tt.reason)
+			}
+			if result.Time.IsZero() {
+				t.Error("Probe() has no time")
+			}
+		})
+	}
+}
+
+// probeAll runs ProbeAll, returning the results and how long it took
+func probeAll(ctx context.Context, p *Prober, targets []Target) ([]TargetResult, time.Duration) {
+	results := make(chan TargetResult)
+	start := time.Now()
+	go p.ProbeAll(ctx, targets, results)
+
+	all := []TargetResult{}
+	for result := range results {
+		all = append(all, result)
+	}
+	return all, time.Since(start)
+}
+
+func TestProbeAllRateLimit(t *testing.T) {
+	address := tcpListener(t)
+	targets := []Target{}
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		targets = append(targets, Target{ID: id, Network: "tcp", Address: address})
+	}
+
+	p := &Prober{Timeout: time.Second, Interval: 100 * time.Millisecond, Concurrency: 8}
+	results, elapsed := probeAll(context.Background(), p, targets)
+	if len(results) != len(targets) {
+		t.Fatalf("got %d results, want %d", len(results), len(targets))
+	}
+	if elapsed < 4*p.Interval {
+		t.Errorf("probed %d targets in %s, want at least %s apart", len(targets), elapsed, p.Interval)
+	}
+	for _, result := range results {
+		if result.State != STATE_UP {
+			t.Errorf("%s: %v, want up", result.ID, result.Result)
+		}
+	}
+}
+
+func TestProbeAllConcurrency(t *testing.T) {
+	address := udpListener(t, false)
+	targets := []Target{}
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		targets = append(targets, Target{ID: id, Network: "udp", Address: address})
+	}
+
+	// each probe lasts until the timeout, 2 at a time
+	p := &Prober{Timeout: 200 * time.Millisecond, Interval: time.Millisecond, Concurrency: 2}
+	results, elapsed := probeAll(context.Background(), p, targets)
+	if len(results) != len(targets) {
+		t.Fatalf("got %d results, want %d", len(results), len(targets))
+	}
+	if elapsed < 3*p.Timeout {
+		t.Errorf("probed %d targets in %s, more than %d at a time", len(targets), elapsed, p.Concurrency)
+	}
+	if elapsed >= 6*p.Timeout {
+		t.Errorf("probed %d targets in %s, one at a time", len(targets), elapsed)
+	}
+}
+
+func TestProbeAllCancel(t *testing.T) {
+	address := udpListener(t, false)
+	targets := []Target{}
+	for _, id := range []string{"a", "b", "c", "d", "e", "f"} {
+		targets = append(targets, Target{ID: id, Network: "udp", Address: address})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(100*time.Millisecond, cancel)
+	p := &Prober{Timeout: 5 * time.Second, Interval: time.Millisecond, Concurrency: 2}
+	results, elapsed := probeAll(ctx, p, targets)
+	if len(results) != 0 {
+		t.Errorf("got %d results after cancellation, want none", len(results))
+	}
+	if elapsed >= time.Second {
+		t.Errorf("ProbeAll returned %s after cancellation", elapsed)
+	}
+}
+
+func TestResultString(t *testing.T) {
+	tests := []struct {
+		result Result
+		want   string
+	}{
+		{Result{State: STATE_UP, Latency: 3200 * time.Microsecond}, "up 3ms"},
+		{Result{State: STATE_UP, Latency: 250 * time.Microsecond}, "up 250µs"},
+		{Result{State: STATE_DOWN, Err: context.DeadlineExceeded}, "down (timeout)"},
+		{Result{State: STATE_NO_REPLY}, "no reply"},
+		{Result{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.result.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+	"gitlab.com/patopest/mdns-discovery/sd"
+)
+
+// How long to wait for more changes before writing the targets file
+const SD_SETTLE_DELAY = 500 * time.Millisecond
+
+func newPrometheusCmd() *cobra.Command {
+	var file string
+	var listen string
+
+	cmd := &cobra.Command{
+		Use:   "prometheus",
+		Short: "Keep discovering services and export them as Prometheus scrape targets (file_sd or HTTP SD)",
+		Example: "  mdns-discovery prometheus --file /etc/prometheus/targets/mdns.json\n" +
+			"  mdns-discovery prometheus --listen :9101 # http_sd_configs url: http://localhost:9101/targets",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if file == "" && listen == "" {
+				return errors.New("at least one of --file or --listen is required")
+			}
+
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			targets := cfg.Prometheus
+			if len(targets) == 0 {
+				targets = sd.DefaultPrometheusTargets
+			}
+			prometheus, err := sd.NewPrometheus(targets)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			store, saveHistory, err := recordHistory(ctx, cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			defer saveHistory()
+
+			registry := network.NewRegistry(discoveryOptions().EntryTTL())
+			events, unsubscribe := registry.Subscribe()
+			defer unsubscribe()
+			runDiscovery(ctx, registry, nil, store)
+			groups := func() []sd.TargetGroup { return prometheus.TargetGroups(registry.Entries()) }
+
+			errCh := make(chan error, 1)
+			if listen != "" {
+				mux := http.NewServeMux()
+				mux.Handle("GET /targets", sd.Handler(groups))
+				server := &http.Server{Addr: listen, Handler: mux}
+				go func() { errCh <- server.ListenAndServe() }()
+				defer server.Close()
+				fmt.Fprintf(cmd.ErrOrStderr(), "Serving Prometheus HTTP SD targets on http://%s/targets\n", listen)
+			}
+
+			writeFile := func() {
+				if file == "" {
+					return
+				}
+				if written, err := sd.WriteFile(file, groups()); err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "failed to write %s: %v\n", file, err)
+				} else if written {
+					log.Println("updated", file)
+				}
+			}
+			writeFile() // create the file even if nothing is discovered
+
+			// write once a burst of changes is over (ex: all the answers to a query)
+			var settled <-chan time.Time
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case err := <-errCh:
+					return err
+				case <-events:
+					settled = time.After(SD_SETTLE_DELAY)
+				case <-settled:
+					writeFile()
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Write the targets to a file_sd JSON file, updated when the discovered services change")
+	cmd.Flags().StringVarP(&listen, "listen", "l", "", "Serve the targets for http_sd_configs at /targets on this address, ex: ':9101'")
+
+	return cmd
+}
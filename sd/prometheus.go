@@ -0,0 +1,172 @@
+package sd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gitlab.com/patopest/mdns-discovery/config"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// Prefix of the meta labels of each target, available for relabeling
+const META_LABEL_PREFIX = "__meta_mdns_"
+
+// Default service types turned into targets when none is configured
+var DefaultPrometheusTargets = []config.PrometheusTarget{
+	{Service: "_prometheus-http._tcp", Labels: map[string]string{"__metrics_path__": "{{.TXT.path}}"}},
+}
+
+// TargetGroup is a Prometheus static config, the format of both file_sd files and HTTP SD responses
+type TargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels,omitempty"`
+}
+
+// labelData is the data available to the templates of the labels
+type labelData struct {
+	Instance string
+	Service  string
+	Host     string
+	IP       string
+	Port     int
+	TXT      map[string]string
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// Prometheus turns the entries matching the configured service types into scrape targets
+type Prometheus struct {
+	mappings map[string][]*mapping // by service type
+}
+
+type mapping struct {
+	labels map[string]*template.Template
+}
+
+// NewPrometheus parses the label templates of the target definitions
+func NewPrometheus(targets []config.PrometheusTarget) (*Prometheus, error) {
+	p := &Prometheus{mappings: map[string][]*mapping{}}
+	for _, target := range targets {
+		m := &mapping{labels: map[string]*template.Template{}}
+		for name, value := range target.Labels {
+			tmpl, err := template.New(name).Option("missingkey=zero").Parse(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid label '%s' of %s: %w", name, target.Service, err)
+			}
+			m.labels[name] = tmpl
+		}
+		p.mappings[target.Service] = append(p.mappings[target.Service], m)
+	}
+	return p, nil
+}
+
+// TargetGroups returns a target group for each entry of a configured service type, in order
+func (p *Prometheus) TargetGroups(entries []network.Entry) []TargetGroup {
+	groups := []TargetGroup{}
+	for _, entry := range entries {
+		ip := entry.IP()
+		if ip == nil {
+			continue
+		}
+		for _, m := range p.mappings[entry.ServiceType()] {
+			groups = append(groups, TargetGroup{
+				Targets: []string{net.JoinHostPort(ip.String(), strconv.Itoa(entry.Port))},
+				Labels:  m.render(entry),
+			})
+		}
+	}
+	return groups
+}
+
+// render returns the meta labels of an entry along with the configured ones, skipping the empty ones
+func (m *mapping) render(entry network.Entry) map[string]string {
+	data := labelData{
+		Instance: entry.Instance(),
+		Service:  entry.ServiceType(),
+		Host:     entry.Hostname(),
+		IP:       entry.IP().String(),
+		Port:     entry.Port,
+		TXT:      entry.TXT(),
+	}
+
+	labels := map[string]string{
+		META_LABEL_PREFIX + "instance":  data.Instance,
+		META_LABEL_PREFIX + "service":   data.Service,
+		META_LABEL_PREFIX + "host":      data.Host,
+		META_LABEL_PREFIX + "interface": entry.Interface,
+	}
+	for key, value := range data.TXT {
+		labels[META_LABEL_PREFIX+"txt_"+invalidLabelChars.ReplaceAllString(key, "_")] = value
+	}
+	for name, tmpl := range m.labels {
+		var value strings.Builder
+		if err := tmpl.Execute(&value, data); err == nil {
+			labels[name] = value.String()
+		}
+	}
+
+	for name, value := range labels {
+		if value == "" {
+			delete(labels, name)
+		}
+	}
+	return labels
+}
+
+// MarshalTargetGroups returns the target groups as indented JSON
+func MarshalTargetGroups(groups []TargetGroup) ([]byte, error) {
+	return json.MarshalIndent(groups, "", "  ")
+}
+
+// WriteFile atomically replaces the file at path with the target groups, if they changed.
+// It returns whether the file was written.
+func WriteFile(path string, groups []TargetGroup) (bool, error) {
+	data, err := MarshalTargetGroups(groups)
+	if err != nil {
+		return false, err
+	}
+	data = append(data, '\n')
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+
+	// Prometheus watches the file, so it must never see it partially written
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return false, err
+	}
+	return true, os.Rename(tmp.Name(), path)
+}
+
+// Handler serves the target groups in the HTTP SD format
+func Handler(groups func() []TargetGroup) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := MarshalTargetGroups(groups())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
@@ -0,0 +1,134 @@
+package sd
+
+import (
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gitlab.com/patopest/mdns-discovery/config"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// entries are the services found in the tests
+var entries = []network.Entry{
+	entry("gateway._prometheus-http._tcp.local.", "gateway.local.", "192.168.1.1", "", 9100, "path=/metrics"),
+	entry(`Kitchen\ Sensor._prometheus-http._tcp.local.`, "kitchen.local.", "192.168.1.20", "", 80, "path=/prometheus", "fw-version=1.2"),
+	entry("nas._prometheus-http._tcp.local.", "nas.local.", "", "2001:db8::20", 9090),
+	entry("unresolved._prometheus-http._tcp.local.", "unresolved.local.", "", "", 9100),
+	entry("Obelix._node-exporter._tcp.local.", "Obelix.local.", "192.168.1.10", "", 9100, "env=lab"),
+	entry("Obelix._ssh._tcp.local.", "Obelix.local.", "192.168.1.10", "", 22),
+}
+
+func entry(name, host, ipv4, ipv6 string, port int, txt ...string) network.Entry {
+	e := network.Entry{ServiceEntry: network.ServiceEntry{Name: name, Host: host, Port: port, InfoFields: txt}, Interface: "eth0"}
+	if ipv4 != "" {
+		e.AddrV4 = net.ParseIP(ipv4)
+	}
+	if ipv6 != "" {
+		e.AddrV6 = net.ParseIP(ipv6)
+	}
+	return e
+}
+
+// prometheus turns the default and a custom service type into targets
+func prometheus(t *testing.T) *Prometheus {
+	t.Helper()
+	targets := append(slices.Clone(DefaultPrometheusTargets), config.PrometheusTarget{
+		Service: "_node-exporter._tcp",
+		Labels:  map[string]string{"job": "node", "env": "{{.TXT.env}}", "instance": "{{.Host}}:{{.Port}}"},
+	})
+	p, err := NewPrometheus(targets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mdns.json")
+	groups := prometheus(t).TargetGroups(entries)
+
+	written, err := WriteFile(path, groups)
+	if err != nil || !written {
+		t.Fatalf("WriteFile() = %v, %v, want written", written, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden(t, string(data), "testdata/file_sd.json")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("mode %v, want 0644", info.Mode().Perm())
+	}
+
+	// Unchanged targets don't touch the file
+	if written, err := WriteFile(path, groups); err != nil || written {
+		t.Errorf("WriteFile() = %v, %v, want not written", written, err)
+	}
+
+	written, err = WriteFile(path, groups[:1])
+	if err != nil || !written {
+		t.Fatalf("WriteFile() = %v, %v, want written", written, err)
+	}
+	files, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("%d files, want only the target file (no temporary one left)", len(files))
+	}
+}
+
+func TestWriteFileEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mdns.json")
+	if _, err := WriteFile(path, prometheus(t).TargetGroups(nil)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[]\n" {
+		t.Errorf("got %q, want an empty list", data)
+	}
+}
+
+func TestNewPrometheus(t *testing.T) {
+	_, err := NewPrometheus([]config.PrometheusTarget{{Service: "_http._tcp", Labels: map[string]string{"path": "{{.TXT.path"}}})
+	if err == nil || !strings.Contains(err.Error(), "invalid label 'path' of _http._tcp") {
+		t.Errorf("error %v, want invalid label", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	p := prometheus(t)
+	rec := httptest.NewRecorder()
+	Handler(func() []TargetGroup { return p.TargetGroups(entries) }).ServeHTTP(rec, httptest.NewRequest("GET", "/sd", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type %q, want application/json", ct)
+	}
+	// the same content as the file, without its trailing newline
+	golden(t, rec.Body.String()+"\n", "testdata/file_sd.json")
+}
+
+// golden compares the output of a test to the content of a file
+func golden(t *testing.T, got, path string) {
+	t.Helper()
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("got:\n%s\nwant (%s):\n%s", got, path, want)
+	}
+}
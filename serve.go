@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gitlab.com/patopest/mdns-discovery/api"
+	"gitlab.com/patopest/mdns-discovery/hooks"
+	"gitlab.com/patopest/mdns-discovery/metrics"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+func newServeCmd() *cobra.Command {
+	var metricsAddr string
+	var listenAddr string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Keep discovering services in the background, serve metrics or a REST API about them and fire hooks",
+		Example: `  mdns-discovery serve --metrics :9100
+  mdns-discovery serve --listen 127.0.0.1:8080
+  curl 'localhost:8080/services?service=_http._tcp'`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			var runner *hooks.Runner
+			if len(cfg.Hooks) > 0 && !viper.GetBool("no-hooks") {
+				if runner, err = hooks.New(cfg.Hooks); err != nil {
+					return err
+				}
+				runner.Logger = log.New(cmd.ErrOrStderr(), "", log.LstdFlags)
+			}
+			if metricsAddr == "" && listenAddr == "" && runner == nil {
+				return errors.New("at least one of --metrics or --listen is required, or hooks in the config file")
+			}
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			registry := network.NewRegistry(discoveryOptions().EntryTTL())
+			if runner != nil {
+				events, unsubscribe := registry.Subscribe()
+				defer unsubscribe()
+				go runner.Run(ctx, events)
+				fmt.Fprintf(cmd.ErrOrStderr(), "Firing %d hook(s) from %s\n", len(cfg.Hooks), cfg.Path())
+			}
+			var observer network.Observer
+			var promRegistry *metrics.Registry
+			if metricsAddr != "" {
+				promRegistry = metrics.NewRegistry()
+				discoveryMetrics := metrics.NewDiscovery(promRegistry)
+				events, unsubscribe := registry.Subscribe()
+				defer unsubscribe()
+				go discoveryMetrics.Watch(ctx, events)
+				observer = discoveryMetrics
+			}
+			store, saveHistory, err := recordHistory(ctx, cmd.ErrOrStderr())
+			if err != nil {
+				return err
+			}
+			defer saveHistory()
+			discovery := runDiscovery(ctx, registry, observer, store)
+
+			// Both can share the same address
+			muxes := map[string]*http.ServeMux{}
+			mux := func(addr string) *http.ServeMux {
+				if _, ok := muxes[addr]; !ok {
+					muxes[addr] = http.NewServeMux()
+				}
+				return muxes[addr]
+			}
+			if metricsAddr != "" {
+				mux(metricsAddr).Handle("GET /metrics", promRegistry.Handler())
+				fmt.Fprintf(cmd.ErrOrStderr(), "Serving metrics on http://%s/metrics\n", metricsAddr)
+			}
+			if listenAddr != "" {
+				mux(listenAddr).Handle("/", api.NewServer(registry, discovery).Handler())
+				fmt.Fprintf(cmd.ErrOrStderr(), "Serving API on http://%s/\n", listenAddr)
+				if !isLoopback(listenAddr) {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: the API has no authentication, anyone reaching %s can list the services and toggle the interfaces\n", listenAddr)
+				}
+			}
+
+			errCh := make(chan error, len(muxes))
+			for addr, mux := range muxes {
+				server := &http.Server{Addr: addr, Handler: mux}
+				go func() { errCh <- server.ListenAndServe() }()
+				defer server.Close()
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case err := <-errCh:
+				return err
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&metricsAddr, "metrics", "", "", "Serve Prometheus metrics at /metrics on this address, ex: ':9100'")
+	cmd.Flags().StringVarP(&listenAddr, "listen", "l", "", "Serve the REST API on this address, ex: '127.0.0.1:8080' (unauthenticated, bind to localhost unless the network is trusted)")
+
+	return cmd
+}
+
+// isLoopback returns whether a listen address only accepts local connections, ex: '127.0.0.1:8080' but not ':8080'
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"gitlab.com/patopest/mdns-discovery/app/table"
+	"gitlab.com/patopest/mdns-discovery/snapshot"
+)
+
+// Exit code of the diff command when the services drifted from the baseline
+const DRIFT_EXIT_CODE = 1
+
+func newSnapshotCmd() *cobra.Command {
+	var timeout time.Duration
+	var output string
+
+	cmd := &cobra.Command{
+		Use:     "snapshot",
+		Short:   "Discover services for a while and save them as a baseline for the diff command (headless)",
+		Example: "  mdns-discovery snapshot -o baseline.json\n  mdns-discovery snapshot --view lab -o lab.json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			s, err := scanSnapshot(timeout)
+			if err != nil {
+				return err
+			}
+			if output == "" || output == "-" {
+				return s.Write(os.Stdout)
+			}
+
+			f, err := os.Create(output)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			if err := s.Write(f); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Saved %d services to %s\n", len(s.Services), output)
+			return f.Close()
+		},
+	}
+
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 0, "How long to listen for services, the query timeout if 0")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write the snapshot to (default: stdout)")
+
+	return cmd
+}
+
+func newDiffCmd() *cobra.Command {
+	var timeout time.Duration
+	var output string
+	var ignore []string
+
+	cmd := &cobra.Command{
+		Use:   "diff <baseline.json> [current.json]",
+		Short: "Compare the services discovered now (or another snapshot) to a baseline, exits with 1 on drift (headless)",
+		Example: `  mdns-discovery diff baseline.json
+  mdns-discovery diff monday.json tuesday.json --ignore ipv6,txt.seq`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseline, err := snapshot.Load(args[0])
+			if err != nil {
+				return err
+			}
+			var current snapshot.Snapshot
+			if len(args) > 1 {
+				current, err = snapshot.Load(args[1])
+			} else {
+				current, err = scanSnapshot(timeout)
+			}
+			if err != nil {
+				return err
+			}
+
+			diff := snapshot.Compare(baseline, current, ignore)
+			switch output {
+			case "text":
+				err = snapshot.WriteReport(os.Stdout, diff)
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(diff)
+			default:
+				err = fmt.Errorf("unknown output format '%s'", output)
+			}
+			if err != nil {
+				return err
+			}
+
+			if diff.HasDrift() {
+				os.Exit(DRIFT_EXIT_CODE)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 0, "How long to listen for services, the query timeout if 0")
+	cmd.Flags().StringVarP(&output, "output", "o", "text", "Output format: 'text' or 'json'")
+	cmd.Flags().StringSliceVarP(&ignore, "ignore", "", nil, "Fields not to compare: 'hostname', 'ipv4', 'ipv6', 'port', 'txt' or 'txt.<key>'")
+
+	return cmd
+}
+
+// loadBaseline reads the snapshot given with --baseline, returns nil if none
+func loadBaseline() (*snapshot.Snapshot, error) {
+	path := viper.GetString("baseline")
+	if path == "" {
+		return nil, nil
+	}
+	baseline, err := snapshot.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	return &baseline, nil
+}
+
+// scanSnapshot discovers services for the given duration and returns a snapshot of the ones matching --view
+func scanSnapshot(timeout time.Duration) (snapshot.Snapshot, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return snapshot.Snapshot{}, err
+	}
+
+	t := table.New()
+	if name := viper.GetString("view"); name != "" {
+		view, ok := cfg.GetView(name)
+		if !ok {
+			return snapshot.Snapshot{}, fmt.Errorf("unknown view '%s'", name)
+		}
+		t.ApplyView(view)
+	}
+	t.SetRows(discoverEntries(timeout))
+
+	return snapshot.New(t.VisibleEntries(), time.Now()), nil
+}
@@ -0,0 +1,275 @@
+package snapshot
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/api"
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+// Version of the snapshot file format
+const VERSION = 1
+
+// Snapshot is the services found by a scan
+type Snapshot struct {
+	Version  int           `json:"version"`
+	Time     time.Time     `json:"time"`
+	Services []api.Service `json:"services"` // sorted by name, one per instance name
+}
+
+// New returns a snapshot of the entries. An instance seen on several interfaces is only kept once, as seen on the
+// first interface by name so that snapshots don't depend on the order entries were found in, with the addresses it
+// lacks there taken from the other interfaces and its latest last seen time.
+func New(entries []network.Entry, t time.Time) Snapshot {
+	entries = slices.Clone(entries)
+	slices.SortFunc(entries, func(a, b network.Entry) int {
+		return cmp.Or(strings.Compare(a.Interface, b.Interface), strings.Compare(a.ID(), b.ID()))
+	})
+
+	byName := map[string]*api.Service{}
+	for _, entry := range entries {
+		service := api.NewService(entry)
+		existing, ok := byName[service.Name]
+		if !ok {
+			byName[service.Name] = &service
+			continue
+		}
+		existing.IPv4 = cmp.Or(existing.IPv4, service.IPv4)
+		existing.IPv6 = cmp.Or(existing.IPv6, service.IPv6)
+		if service.LastSeen.After(existing.LastSeen) {
+			existing.LastSeen = service.LastSeen
+		}
+	}
+
+	s := Snapshot{Version: VERSION, Time: t, Services: []api.Service{}}
+	for _, service := range byName {
+		s.Services = append(s.Services, *service)
+	}
+	slices.SortFunc(s.Services, func(a, b api.Service) int { return strings.Compare(a.Name, b.Name) })
+	return s
+}
+
+// Load reads a snapshot file
+func Load(path string) (Snapshot, error) {
+	var s Snapshot
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return s, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Version != VERSION {
+		return s, fmt.Errorf("%s: unsupported snapshot version %d", path, s.Version)
+	}
+	return s, nil
+}
+
+// Write writes the snapshot as indented JSON
+func (s Snapshot) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Get returns the service with the given instance name
+func (s Snapshot) Get(name string) (api.Service, bool) {
+	idx := slices.IndexFunc(s.Services, func(service api.Service) bool { return service.Name == name })
+	if idx < 0 {
+		return api.Service{}, false
+	}
+	return s.Services[idx], true
+}
+
+// Entry converts a snapshot service back to an entry
+func Entry(service api.Service) network.Entry {
+	entry := network.Entry{Interface: service.Interface, LastSeen: service.LastSeen}
+	entry.Name = service.Name
+	entry.Host = service.Hostname + "."
+	entry.AddrV4 = net.ParseIP(service.IPv4)
+	entry.AddrV6 = net.ParseIP(service.IPv6)
+	entry.Port = service.Port
+	for _, key := range slices.Sorted(maps.Keys(service.TXT)) {
+		entry.InfoFields = append(entry.InfoFields, key+"="+service.TXT[key])
+	}
+	return entry
+}
+
+// Status is how a service differs from the baseline
+type Status int
+
+const (
+	STATUS_UNCHANGED Status = iota
+	STATUS_ADDED            // not in the baseline
+	STATUS_REMOVED          // only in the baseline
+	STATUS_CHANGED          // with different fields
+)
+
+func (s Status) String() string {
+	switch s {
+	case STATUS_ADDED:
+		return "added"
+	case STATUS_REMOVED:
+		return "removed"
+	case STATUS_CHANGED:
+		return "changed"
+	default:
+		return ""
+	}
+}
+
+// FieldChange is a field of a service that differs from the baseline
+type FieldChange struct {
+	Field  string `json:"field"` // 'hostname', 'ipv4', 'ipv6', 'port' or 'txt.<key>'
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// Change is a service present in both snapshots with different fields
+type Change struct {
+	Name   string        `json:"name"`
+	Fields []FieldChange `json:"fields"`
+}
+
+// Diff is the difference between a baseline and a current snapshot
+type Diff struct {
+	Added   []api.Service `json:"added"`
+	Removed []api.Service `json:"removed"`
+	Changed []Change      `json:"changed"`
+}
+
+// HasDrift returns whether the snapshots differ
+func (d Diff) HasDrift() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0
+}
+
+// Status returns how the service with the given instance name differs from the baseline
+func (d Diff) Status(name string) Status {
+	has := func(service api.Service) bool { return service.Name == name }
+	switch {
+	case slices.ContainsFunc(d.Added, has):
+		return STATUS_ADDED
+	case slices.ContainsFunc(d.Removed, has):
+		return STATUS_REMOVED
+	case slices.ContainsFunc(d.Changed, func(change Change) bool { return change.Name == name }):
+		return STATUS_CHANGED
+	default:
+		return STATUS_UNCHANGED
+	}
+}
+
+// Compare returns the services added, removed and changed since the baseline, matched by instance name.
+// Fields in ignore ('ipv6', 'txt.<key>' or 'txt' for all TXT keys) are not compared.
+func Compare(baseline, current Snapshot, ignore []string) Diff {
+	diff := Diff{Added: []api.Service{}, Removed: []api.Service{}, Changed: []Change{}}
+
+	for _, before := range baseline.Services {
+		after, ok := current.Get(before.Name)
+		if !ok {
+			diff.Removed = append(diff.Removed, before)
+			continue
+		}
+		if fields := CompareServices(before, after, ignore); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, Change{Name: before.Name, Fields: fields})
+		}
+	}
+	for _, after := range current.Services {
+		if _, ok := baseline.Get(after.Name); !ok {
+			diff.Added = append(diff.Added, after)
+		}
+	}
+	return diff
+}
+
+// CompareServices returns the fields of two services that differ, except the ignored ones
+func CompareServices(before, after api.Service, ignore []string) []FieldChange {
+	changes := []FieldChange{}
+	ignored := func(field string) bool {
+		return slices.Contains(ignore, field) || (strings.HasPrefix(field, "txt.") && slices.Contains(ignore, "txt"))
+	}
+	compare := func(field, a, b string) {
+		if a != b && !ignored(field) {
+			changes = append(changes, FieldChange{Field: field, Before: a, After: b})
+		}
+	}
+
+	compare("hostname", before.Hostname, after.Hostname)
+	compare("ipv4", before.IPv4, after.IPv4)
+	compare("ipv6", before.IPv6, after.IPv6)
+	compare("port", strconv.Itoa(before.Port), strconv.Itoa(after.Port))
+
+	keys := slices.Collect(maps.Keys(before.TXT))
+	for key := range after.TXT {
+		if _, ok := before.TXT[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		a, inBefore := before.TXT[key]
+		b, inAfter := after.TXT[key]
+		if (a != b || inBefore != inAfter) && !ignored("txt."+key) {
+			changes = append(changes, FieldChange{Field: "txt." + key, Before: txtValue(a, inBefore), After: txtValue(b, inAfter)})
+		}
+	}
+	return changes
+}
+
+// WriteReport writes the diff as text, one line per added (+), removed (-) or changed (~) service followed by
+// its changed fields, and a summary
+func WriteReport(w io.Writer, diff Diff) error {
+	for _, service := range diff.Added {
+		fmt.Fprintf(w, "+ %s  %s\n", service.Name, address(service))
+	}
+	for _, service := range diff.Removed {
+		fmt.Fprintf(w, "- %s  %s\n", service.Name, address(service))
+	}
+	for _, change := range diff.Changed {
+		fmt.Fprintf(w, "~ %s\n", change.Name)
+		for _, field := range change.Fields {
+			fmt.Fprintf(w, "    %s: %s → %s\n", field.Field, quote(field.Before), quote(field.After))
+		}
+	}
+
+	if !diff.HasDrift() {
+		_, err := fmt.Fprintln(w, "no drift")
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d added, %d removed, %d changed\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	return err
+}
+
+// address returns the host, IP and port of a service, ex: 'Obelix.local 192.168.1.145:22'
+func address(service api.Service) string {
+	ip := service.IPv4
+	if ip == "" {
+		ip = service.IPv6
+	}
+	return fmt.Sprintf("%s %s", service.Hostname, net.JoinHostPort(ip, strconv.Itoa(service.Port)))
+}
+
+// txtValue returns the value of a TXT key, '<none>' if it is missing
+func txtValue(value string, ok bool) string {
+	if !ok {
+		return "<none>"
+	}
+	return value
+}
+
+// quote shows empty values explicitly
+func quote(s string) string {
+	if s == "" {
+		return `""`
+	}
+	return s
+}
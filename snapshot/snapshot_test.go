@@ -0,0 +1,102 @@
+package snapshot
+
+import (
+	"net"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"gitlab.com/patopest/mdns-discovery/network"
+)
+
+func entry(iface, ipv4, ipv6 string, lastSeen time.Time) network.Entry {
+	return network.Entry{
+		ServiceEntry: network.ServiceEntry{
+			Name:   "Obelix._ssh._tcp.local.",
+			Host:   "obelix.local.",
+			AddrV4: net.ParseIP(ipv4),
+			AddrV6: net.ParseIP(ipv6),
+			Port:   22,
+		},
+		Interface: iface,
+		LastSeen:  lastSeen,
+	}
+}
+
+func TestNew(t *testing.T) {
+	now := time.Now()
+	wlan := entry("wlan0", "", "fe80::2", now.Add(time.Second))
+	eth := entry("eth0", "192.168.1.145", "", now)
+	other := entry("eth0", "192.168.1.1", "", now)
+	other.Name = "Asterix._ssh._tcp.local."
+
+	for _, entries := range [][]network.Entry{{wlan, eth, other}, {eth, other, wlan}, {other, wlan, eth}} {
+		s := New(entries, now)
+		if len(s.Services) != 2 || s.Services[0].Name != other.Name {
+			t.Fatalf("New() = %+v, want 2 services sorted by name", s.Services)
+		}
+		obelix := s.Services[1]
+		if obelix.Interface != "eth0" || obelix.IPv4 != "192.168.1.145" || obelix.IPv6 != "fe80::2" || !obelix.LastSeen.Equal(wlan.LastSeen) {
+			t.Errorf("New() = %+v, want eth0's with wlan0's IPv6 and last seen time", obelix)
+		}
+		if first := New([]network.Entry{wlan, eth, other}, now); !reflect.DeepEqual(s, first) {
+			t.Errorf("New() depends on the order of the entries: %+v != %+v", s, first)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	now := time.Now()
+	obelix := entry("eth0", "192.168.1.145", "fe80::2", now)
+	moved := obelix
+	moved.AddrV4 = net.ParseIP("192.168.1.146")
+	moved.AddrV6 = net.ParseIP("fe80::3")
+	asterix := entry("eth0", "192.168.1.1", "", now)
+	asterix.Name = "Asterix._ssh._tcp.local."
+
+	tests := []struct {
+		name     string
+		baseline []network.Entry
+		current  []network.Entry
+		ignore   []string
+		added    []string
+		removed  []string
+		changed  []string // fields
+	}{
+		{"unchanged", []network.Entry{obelix}, []network.Entry{obelix}, nil, nil, nil, nil},
+		{"added", []network.Entry{obelix}, []network.Entry{obelix, asterix}, nil, []string{asterix.Name}, nil, nil},
+		{"removed", []network.Entry{obelix, asterix}, []network.Entry{obelix}, nil, nil, []string{asterix.Name}, nil},
+		{"changed", []network.Entry{obelix}, []network.Entry{moved}, nil, nil, nil, []string{"ipv4", "ipv6"}},
+		{"ignored", []network.Entry{obelix}, []network.Entry{moved}, []string{"ipv6"}, nil, nil, []string{"ipv4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Compare(New(tt.baseline, now), New(tt.current, now), tt.ignore)
+			added, removed, changed := []string{}, []string{}, []string{}
+			for _, service := range diff.Added {
+				added = append(added, service.Name)
+			}
+			for _, service := range diff.Removed {
+				removed = append(removed, service.Name)
+			}
+			for _, change := range diff.Changed {
+				for _, field := range change.Fields {
+					changed = append(changed, field.Field)
+				}
+			}
+			if !slices.Equal(added, tt.added) && len(added)+len(tt.added) > 0 {
+				t.Errorf("added = %q, want %q", added, tt.added)
+			}
+			if !slices.Equal(removed, tt.removed) && len(removed)+len(tt.removed) > 0 {
+				t.Errorf("removed = %q, want %q", removed, tt.removed)
+			}
+			if !slices.Equal(changed, tt.changed) && len(changed)+len(tt.changed) > 0 {
+				t.Errorf("changed = %q, want %q", changed, tt.changed)
+			}
+			if diff.HasDrift() != (len(tt.added)+len(tt.removed)+len(tt.changed) > 0) {
+				t.Errorf("HasDrift() = %v", diff.HasDrift())
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package txt
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AirPlay feature bits ('features' key)
+var airplayFeatures = map[int]string{
+	0:  "video",
+	1:  "photo",
+	5:  "slideshow",
+	7:  "screen mirroring",
+	9:  "audio",
+	11: "audio redundant",
+	14: "FairPlay authentication",
+	15: "metadata (text)",
+	16: "metadata (artwork)",
+	17: "metadata (progress)",
+	26: "MFi authentication",
+	30: "unified services",
+	38: "buffered audio",
+	40: "PTP clock",
+	41: "screen multi codec",
+	46: "HomeKit pairing",
+	48: "transient pairing",
+}
+
+func init() {
+	Register("_airplay._tcp", decodeAirPlay)
+	Register("_raop._tcp", decodeAirPlay)
+}
+
+// decodeAirPlay decodes the TXT record of AirPlay receivers
+func decodeAirPlay(txt map[string]string) []Field {
+	b := fieldsBuilder{txt: txt}
+	b.add("model", "model", "Model", nil)
+	b.add("am", "model", "Model", nil) // RAOP
+	b.add("features", "features", "Features", decodeAirPlayFeatures)
+	b.add("ft", "features", "Features", decodeAirPlayFeatures) // RAOP
+	b.add("srcvers", "version", "AirPlay version", nil)
+	b.add("vs", "version", "AirPlay version", nil) // RAOP
+	b.add("deviceid", "device_id", "Device ID", nil)
+	b.add("osvers", "os_version", "OS version", nil)
+	return b.fields
+}
+
+// decodeAirPlayFeatures decodes a features bitmask, ex: '0x5A7FFFF7,0x1E' (low and high 32 bits)
+func decodeAirPlayFeatures(value string) string {
+	var mask uint64
+	for i, part := range strings.SplitN(value, ",", 2) {
+		bits, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(part), "0x"), 16, 32)
+		if err != nil {
+			return value
+		}
+		mask |= bits << (32 * i)
+	}
+	if set := flags(mask, airplayFeatures); len(set) > 0 {
+		return strings.Join(set, ", ")
+	}
+	return value
+}
@@ -0,0 +1,25 @@
+package txt
+
+import (
+	"strings"
+)
+
+func init() {
+	Register("_esphomelib._tcp", decodeESPHome)
+}
+
+// decodeESPHome decodes the TXT record of ESPHome devices
+func decodeESPHome(txt map[string]string) []Field {
+	b := fieldsBuilder{txt: txt}
+	b.add("friendly_name", "friendly_name", "Friendly name", nil)
+	b.add("version", "version", "ESPHome version", nil)
+	b.add("platform", "platform", "Platform", strings.ToUpper)
+	b.add("board", "board", "Board", nil)
+	b.add("esp", "chip", "Chip", func(v string) string { return "ESP32-" + strings.ToUpper(v) })
+	b.add("idf_version", "idf_version", "ESP-IDF version", nil)
+	b.add("network", "network", "Network", nil)
+	b.add("mac", "mac", "MAC address", nil)
+	b.add("project_name", "project", "Project", nil)
+	b.add("project_version", "project_version", "Project version", nil)
+	return b.fields
+}
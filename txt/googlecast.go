@@ -0,0 +1,39 @@
+package txt
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Google Cast capability bits ('ca' key)
+var castCapabilities = map[int]string{
+	0: "video out",
+	1: "video in",
+	2: "audio out",
+	3: "audio in",
+	4: "dev mode",
+	5: "multizone group",
+}
+
+func init() {
+	Register("_googlecast._tcp", decodeGoogleCast)
+}
+
+// decodeGoogleCast decodes the TXT record of Chromecasts and other Google Cast devices
+func decodeGoogleCast(txt map[string]string) []Field {
+	b := fieldsBuilder{txt: txt}
+	b.add("fn", "friendly_name", "Friendly name", nil)
+	b.add("md", "model", "Model", nil)
+	b.add("rs", "status", "Status", nil)
+	b.add("st", "busy", "Busy", func(v string) string { return yesNo(v) })
+	b.add("ca", "capabilities", "Capabilities", func(v string) string {
+		ca, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return v
+		}
+		return strings.Join(flags(ca, castCapabilities), ", ")
+	})
+	b.add("id", "device_id", "Device ID", nil)
+	b.add("ve", "version", "Protocol version", nil)
+	return b.fields
+}
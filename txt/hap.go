@@ -0,0 +1,94 @@
+package txt
+
+import (
+	"strconv"
+	"strings"
+)
+
+// HomeKit accessory categories (HAP specification, 'ci' key)
+var hapCategories = map[int]string{
+	1:  "Other",
+	2:  "Bridge",
+	3:  "Fan",
+	4:  "Garage Door Opener",
+	5:  "Lightbulb",
+	6:  "Door Lock",
+	7:  "Outlet",
+	8:  "Switch",
+	9:  "Thermostat",
+	10: "Sensor",
+	11: "Security System",
+	12: "Door",
+	13: "Window",
+	14: "Window Covering",
+	15: "Programmable Switch",
+	16: "Range Extender",
+	17: "IP Camera",
+	18: "Video Doorbell",
+	19: "Air Purifier",
+	20: "Heater",
+	21: "Air Conditioner",
+	22: "Humidifier",
+	23: "Dehumidifier",
+	28: "Sprinkler",
+	29: "Faucet",
+	30: "Shower System",
+	31: "Television",
+	32: "Remote Control",
+	33: "Router",
+}
+
+// HomeKit status flags ('sf' key)
+var hapStatusFlags = map[int]string{
+	0: "not paired",
+	1: "Wi-Fi not configured",
+	2: "problem detected",
+}
+
+// HomeKit pairing feature flags ('ff' key)
+var hapFeatureFlags = map[int]string{
+	0: "hardware authentication",
+	1: "software authentication",
+}
+
+func init() {
+	Register("_hap._tcp", decodeHAP)
+	Register("_hap._udp", decodeHAP)
+}
+
+// decodeHAP decodes the TXT record of HomeKit accessories
+func decodeHAP(txt map[string]string) []Field {
+	b := fieldsBuilder{txt: txt}
+	b.add("md", "model", "Model", nil)
+	b.add("ci", "category", "Category", func(v string) string {
+		ci, err := strconv.Atoi(v)
+		if name, ok := hapCategories[ci]; err == nil && ok {
+			return name
+		}
+		return "unknown (" + v + ")"
+	})
+	b.add("sf", "status", "Status", func(v string) string {
+		sf, err := strconv.ParseUint(v, 10, 8)
+		if err != nil {
+			return v
+		}
+		if sf&1 == 0 {
+			return strings.Join(append([]string{"paired"}, flags(sf, hapStatusFlags)...), ", ")
+		}
+		return strings.Join(flags(sf, hapStatusFlags), ", ")
+	})
+	b.add("ff", "pairing_features", "Pairing features", func(v string) string {
+		ff, err := strconv.ParseUint(v, 10, 8)
+		if err != nil {
+			return v
+		}
+		if set := flags(ff, hapFeatureFlags); len(set) > 0 {
+			return strings.Join(set, ", ")
+		}
+		return "none"
+	})
+	b.add("id", "device_id", "Device ID", nil)
+	b.add("pv", "protocol_version", "Protocol version", nil)
+	b.add("c#", "config_number", "Config number", nil)
+	return b.fields
+}
@@ -0,0 +1,54 @@
+package txt
+
+import (
+	"strings"
+)
+
+// Names of the document formats ('pdl' key)
+var ippFormats = map[string]string{
+	"application/pdf":                 "PDF",
+	"application/postscript":          "PostScript",
+	"application/vnd.hp-pcl":          "PCL",
+	"application/vnd.hp-pclxl":        "PCL XL",
+	"application/octet-stream":        "raw",
+	"application/vnd.cups-raster":     "CUPS Raster",
+	"application/vnd.cups-postscript": "CUPS PostScript",
+	"image/pwg-raster":                "PWG Raster",
+	"image/urf":                       "AirPrint (URF)",
+	"image/jpeg":                      "JPEG",
+	"image/png":                       "PNG",
+	"text/plain":                      "text",
+}
+
+func init() {
+	Register("_ipp._tcp", decodeIPP)
+	Register("_ipps._tcp", decodeIPP)
+	Register("_printer._tcp", decodeIPP)
+	Register("_pdl-datastream._tcp", decodeIPP)
+}
+
+// decodeIPP decodes the TXT record of printers (Bonjour Printing specification)
+func decodeIPP(txt map[string]string) []Field {
+	b := fieldsBuilder{txt: txt}
+	b.add("ty", "model", "Model", nil)
+	b.add("note", "location", "Location", nil)
+	b.add("rp", "queue", "Queue", nil)
+	b.add("pdl", "formats", "Formats", func(v string) string {
+		formats := []string{}
+		for _, mime := range strings.Split(v, ",") {
+			mime = strings.TrimSpace(mime)
+			if name, ok := ippFormats[strings.ToLower(mime)]; ok {
+				formats = append(formats, name)
+			} else if mime != "" {
+				formats = append(formats, mime)
+			}
+		}
+		return strings.Join(formats, ", ")
+	})
+	b.add("UUID", "uuid", "UUID", nil)
+	b.add("TLS", "tls", "TLS version", nil)
+	b.add("Color", "color", "Color", yesNo)
+	b.add("Duplex", "duplex", "Duplex", yesNo)
+	b.add("adminurl", "admin_url", "Admin URL", nil)
+	return b.fields
+}
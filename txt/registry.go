@@ -0,0 +1,97 @@
+package txt
+
+import (
+	"strings"
+	"sync"
+
+	"gitlab.com/patopest/mdns-discovery/catalog"
+)
+
+// Field is a TXT record attribute decoded into a human-readable value
+type Field struct {
+	Key   string // machine-readable key, ex: 'category'
+	Label string // human-readable label, ex: 'Category'
+	Value string
+}
+
+// Decoder turns the raw TXT attributes of a service type into decoded fields
+type Decoder func(txt map[string]string) []Field
+
+var (
+	decoders = map[string]Decoder{}
+	mu       sync.RWMutex
+)
+
+// Register adds (or replaces) the decoder of a service type, ex: '_hap._tcp' or 'hap'
+func Register(service string, decoder Decoder) {
+	mu.Lock()
+	defer mu.Unlock()
+	decoders[catalog.ServiceName(service)] = decoder
+}
+
+// Decode returns the decoded fields of a TXT record, nil if there is no decoder for the service type
+func Decode(service string, txt map[string]string) []Field {
+	mu.RLock()
+	decoder, ok := decoders[catalog.ServiceName(service)]
+	mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return decoder(txt)
+}
+
+// get returns the value of a TXT key, ignoring its case
+func get(txt map[string]string, key string) (string, bool) {
+	if value, ok := txt[key]; ok {
+		return value, true
+	}
+	for k, value := range txt {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// fieldsBuilder collects the fields of a decoder, skipping missing TXT keys
+type fieldsBuilder struct {
+	txt    map[string]string
+	fields []Field
+}
+
+// add decodes the value of a TXT key (if present) with decode, or keeps it as-is if decode is nil
+func (b *fieldsBuilder) add(txtKey, key, label string, decode func(string) string) {
+	value, ok := get(b.txt, txtKey)
+	if !ok || value == "" {
+		return
+	}
+	if decode != nil {
+		value = decode(value)
+	}
+	b.fields = append(b.fields, Field{Key: key, Label: label, Value: value})
+}
+
+// flags returns the names of the bits set in mask, by bit index
+func flags(mask uint64, names map[int]string) []string {
+	set := []string{}
+	for bit := 0; bit < 64; bit++ {
+		if mask&(1<<bit) == 0 {
+			continue
+		}
+		if name, ok := names[bit]; ok {
+			set = append(set, name)
+		}
+	}
+	return set
+}
+
+// yesNo decodes boolean TXT values ('T'/'F', 'true'/'false', '1'/'0')
+func yesNo(value string) string {
+	switch strings.ToLower(value) {
+	case "t", "true", "1", "yes":
+		return "yes"
+	case "f", "false", "0", "no":
+		return "no"
+	}
+	return value
+}
@@ -0,0 +1,162 @@
+package txt
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// record parses the 'key=value' strings of a TXT record as received from a device
+func record(attributes ...string) map[string]string {
+	txt := map[string]string{}
+	for _, attribute := range attributes {
+		key, value, _ := strings.Cut(attribute, "=")
+		txt[key] = value
+	}
+	return txt
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		service string
+		txt     map[string]string
+		want    []Field
+	}{
+		{"HomeKit bridge paired", "_hap._tcp", record("c#=12", "ff=1", "id=EC:B5:FA:0C:5D:1A", "md=BSB002", "pv=1.1", "s#=1", "sf=0", "ci=2", "sh=ZtD0pw=="),
+			[]Field{
+				{"model", "Model", "BSB002"},
+				{"category", "Category", "Bridge"},
+				{"status", "Status", "paired"},
+				{"pairing_features", "Pairing features", "hardware authentication"},
+				{"device_id", "Device ID", "EC:B5:FA:0C:5D:1A"},
+				{"protocol_version", "Protocol version", "1.1"},
+				{"config_number", "Config number", "12"},
+			}},
+		{"HomeKit sensor not paired", "_hap._tcp", record("c#=1", "ff=2", "id=4A:8F:02:11:C3:7E", "md=Eve Degree 00AAA0000", "pv=1.1", "s#=1", "sf=1", "ci=10"),
+			[]Field{
+				{"model", "Model", "Eve Degree 00AAA0000"},
+				{"category", "Category", "Sensor"},
+				{"status", "Status", "not paired"},
+				{"pairing_features", "Pairing features", "software authentication"},
+				{"device_id", "Device ID", "4A:8F:02:11:C3:7E"},
+				{"protocol_version", "Protocol version", "1.1"},
+				{"config_number", "Config number", "1"},
+			}},
+		{"HomeKit over UDP with problem", "_hap._udp", record("md=Thread Sensor", "ci=99", "sf=5", "ff=0"),
+			[]Field{
+				{"model", "Model", "Thread Sensor"},
+				{"category", "Category", "unknown (99)"},
+				{"status", "Status", "not paired, problem detected"},
+				{"pairing_features", "Pairing features", "none"},
+			}},
+		{"AirPlay Apple TV", "_airplay._tcp", record("acl=0", "deviceid=A8:51:AB:10:21:AE", "features=0x4A7FDFD5,0xBC157FDE", "flags=0x18644",
+			"model=AppleTV11,1", "osvers=16.1", "srcvers=670.6.2", "vv=2"),
+			[]Field{
+				{"model", "Model", "AppleTV11,1"},
+				{"features", "Features", "video, screen mirroring, audio, audio redundant, FairPlay authentication, metadata (text), " +
+					"metadata (artwork), metadata (progress), unified services, buffered audio, PTP clock, screen multi codec, " +
+					"HomeKit pairing, transient pairing"},
+				{"version", "AirPlay version", "670.6.2"},
+				{"device_id", "Device ID", "A8:51:AB:10:21:AE"},
+				{"os_version", "OS version", "16.1"},
+			}},
+		{"RAOP AirPort Express", "_raop._tcp", record("txtvers=1", "ch=2", "cn=0,1", "et=0,4", "sr=44100", "ss=16", "tp=UDP",
+			"am=AirPort10,115", "ft=0x445F8A00", "vs=366.0"),
+			[]Field{
+				{"model", "Model", "AirPort10,115"},
+				{"features", "Features", "audio, audio redundant, metadata (text), metadata (artwork), metadata (progress), MFi authentication, unified services"},
+				{"version", "AirPlay version", "366.0"},
+			}},
+		{"AirPlay invalid features", "_airplay._tcp", record("features=0xZZ", "model=AudioAccessory5,1"),
+			[]Field{
+				{"model", "Model", "AudioAccessory5,1"},
+				{"features", "Features", "0xZZ"},
+			}},
+		{"Chromecast", "_googlecast._tcp", record("id=a1b2c3d4e5f60718293a4b5c6d7e8f90", "cd=3D4BCA8F6E8C2C1D", "rm=", "ve=05", "md=Chromecast Ultra",
+			"ic=/setup/icon.png", "fn=Living Room TV", "ca=4101", "st=0", "bs=FA8FCA7A1F4C", "nf=1", "rs="),
+			[]Field{
+				{"friendly_name", "Friendly name", "Living Room TV"},
+				{"model", "Model", "Chromecast Ultra"},
+				{"busy", "Busy", "no"},
+				{"capabilities", "Capabilities", "video out, audio out"},
+				{"device_id", "Device ID", "a1b2c3d4e5f60718293a4b5c6d7e8f90"},
+				{"version", "Protocol version", "05"},
+			}},
+		{"Google Nest speaker playing", "_googlecast._tcp", record("md=Google Nest Mini", "fn=Kitchen speaker", "ca=2052", "st=1", "rs=Spotify", "ve=05"),
+			[]Field{
+				{"friendly_name", "Friendly name", "Kitchen speaker"},
+				{"model", "Model", "Google Nest Mini"},
+				{"status", "Status", "Spotify"},
+				{"busy", "Busy", "yes"},
+				{"capabilities", "Capabilities", "audio out"},
+				{"version", "Protocol version", "05"},
+			}},
+		{"IPP Brother laser", "_ipp._tcp", record("txtvers=1", "qtotal=1", "pdl=application/octet-stream,image/urf,image/pwg-raster", "rp=ipp/print",
+			"ty=Brother HL-L2350DW series", "product=(Brother HL-L2350DW series)", "adminurl=http://BRW3C2AF4A1B2C3.local./net/net/airprint.html",
+			"priority=25", "usb_MFG=Brother", "usb_MDL=HL-L2350DW series", "Color=F", "Duplex=T", "TLS=1.2", "UUID=e3248000-80ce-11db-8000-3c2af4a1b2c3", "note="),
+			[]Field{
+				{"model", "Model", "Brother HL-L2350DW series"},
+				{"queue", "Queue", "ipp/print"},
+				{"formats", "Formats", "raw, AirPrint (URF), PWG Raster"},
+				{"uuid", "UUID", "e3248000-80ce-11db-8000-3c2af4a1b2c3"},
+				{"tls", "TLS version", "1.2"},
+				{"color", "Color", "no"},
+				{"duplex", "Duplex", "yes"},
+				{"admin_url", "Admin URL", "http://BRW3C2AF4A1B2C3.local./net/net/airprint.html"},
+			}},
+		{"LPD HP with lowercase keys", "_printer._tcp", record("ty=HP LaserJet Pro M404n", "note=2nd floor",
+			"pdl=application/postscript,application/vnd.hp-PCL,application/vnd.hp-PCLXL,application/pdf,image/urf,application/x-unknown",
+			"rp=RAW", "color=t", "duplex=t"),
+			[]Field{
+				{"model", "Model", "HP LaserJet Pro M404n"},
+				{"location", "Location", "2nd floor"},
+				{"queue", "Queue", "RAW"},
+				{"formats", "Formats", "PostScript, PCL, PCL XL, PDF, AirPrint (URF), application/x-unknown"},
+				{"color", "Color", "yes"},
+				{"duplex", "Duplex", "yes"},
+			}},
+		{"ESPHome ESP32-C3", "_esphomelib._tcp", record("friendly_name=Kitchen Sensor", "version=2024.6.1", "mac=a0b76588e7c4", "platform=esp32",
+			"board=esp32-c3-devkitm-1", "esp=c3", "network=wifi", "project_name=acme.kitchen-sensor", "project_version=1.2.0"),
+			[]Field{
+				{"friendly_name", "Friendly name", "Kitchen Sensor"},
+				{"version", "ESPHome version", "2024.6.1"},
+				{"platform", "Platform", "ESP32"},
+				{"board", "Board", "esp32-c3-devkitm-1"},
+				{"chip", "Chip", "ESP32-C3"},
+				{"network", "Network", "wifi"},
+				{"mac", "MAC address", "a0b76588e7c4"},
+				{"project", "Project", "acme.kitchen-sensor"},
+				{"project_version", "Project version", "1.2.0"},
+			}},
+		{"ESPHome ESP8266", "_esphomelib._tcp", record("version=2023.12.9", "mac=5ccf7f0a1b2c", "platform=ESP8266", "board=d1_mini", "network=wifi"),
+			[]Field{
+				{"version", "ESPHome version", "2023.12.9"},
+				{"platform", "Platform", "ESP8266"},
+				{"board", "Board", "d1_mini"},
+				{"network", "Network", "wifi"},
+				{"mac", "MAC address", "5ccf7f0a1b2c"},
+			}},
+		{"full service type", "_ipp._tcp.local.", record("ty=Office Printer"), []Field{{"model", "Model", "Office Printer"}}},
+		{"no decoder", "_ssh._tcp", record("u=admin"), nil},
+		{"empty record", "_hap._tcp", record(), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Decode(tt.service, tt.txt)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Decode() =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegister(t *testing.T) {
+	Register("_test-register._tcp", func(txt map[string]string) []Field {
+		return []Field{{Key: "a", Label: "A", Value: txt["a"]}}
+	})
+	if got := Decode("_Test-Register._tcp.local.", record("a=1")); !slices.Equal(got, []Field{{"a", "A", "1"}}) {
+		t.Errorf("Decode() = %v", got)
+	}
+}